@@ -38,6 +38,16 @@ func main() {
 		log.Fatalf("Ошибка загрузки конфигурации: %v", err)
 	}
 
+	// Подкоманда migrate управляет схемой базы данных и не запускает сервер
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		os.Exit(runMigrate(cfg, os.Args[2:]))
+	}
+
+	// Подкоманда token выпускает JWT для локальной разработки
+	if len(os.Args) > 1 && os.Args[1] == "token" {
+		os.Exit(runToken(cfg, os.Args[2:]))
+	}
+
 	// Инициализация логгера
 	l, err := logger.NewLogger(cfg.Log.Level)
 	if err != nil {
@@ -57,6 +67,12 @@ func main() {
 		Handler: application.Router(),
 	}
 
+	// Сервер метрик слушает отдельный порт, недоступный клиентам API
+	metricsServer := &http.Server{
+		Addr:    cfg.HTTP.MetricsPort,
+		Handler: application.MetricsRouter(),
+	}
+
 	// Канал для сигналов завершения
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
@@ -68,18 +84,35 @@ func main() {
 			l.Fatal("Ошибка запуска HTTP сервера", logger.Error(err))
 		}
 	}()
+	go func() {
+		l.Info("Запуск сервера метрик", logger.String("address", cfg.HTTP.MetricsPort))
+		if err := metricsServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			l.Fatal("Ошибка запуска сервера метрик", logger.Error(err))
+		}
+	}()
 
 	// Ожидание сигнала завершения
 	<-quit
 	l.Info("Получен сигнал завершения, начинаю graceful shutdown...")
 
+	// Сначала проверка готовности переходит в not_ready, и балансировщик выводит
+	// экземпляр из ротации; уже направленные запросы продолжают обрабатываться
+	application.Drain()
+	if cfg.HTTP.DrainDelay > 0 {
+		l.Info("Ожидание вывода из балансировки", logger.Any("delay", cfg.HTTP.DrainDelay))
+		time.Sleep(cfg.HTTP.DrainDelay)
+	}
+
 	// Graceful shutdown
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		l.Fatal("Ошибка при graceful shutdown", logger.Error(err))
 	}
+	if err := metricsServer.Shutdown(ctx); err != nil {
+		l.Fatal("Ошибка при остановке сервера метрик", logger.Error(err))
+	}
 
 	// Закрытие соединения с базой данных
 	if err := application.Close(); err != nil {
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/auth"
+	"github.com/danya1733/practiceGO/internal/config"
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+// tokenUsage описывает подкоманду token
+const tokenUsage = `Использование: app token [флаги] <subject>
+
+Выпускает JWT HS256, подписанный AUTH_JWT_SECRET, для локальной разработки.
+
+Флаги:
+  -role string       роль пользователя: admin, merchandiser или clerk; без роли доступны только API ключи
+  -warehouse uuid    склад пользователя с ролью clerk; флаг можно повторять
+  -ttl duration      срок действия токена в формате Go duration (по умолчанию 24h)`
+
+// defaultTokenTTL срок действия токена по умолчанию
+const defaultTokenTTL = 24 * time.Hour
+
+// warehouseList собирает значения повторяющегося флага -warehouse
+type warehouseList []uuid.UUID
+
+// String реализует flag.Value
+func (l *warehouseList) String() string {
+	return fmt.Sprint([]uuid.UUID(*l))
+}
+
+// Set реализует flag.Value
+func (l *warehouseList) Set(value string) error {
+	id, err := uuid.Parse(value)
+	if err != nil {
+		return err
+	}
+	*l = append(*l, id)
+	return nil
+}
+
+// runToken выполняет подкоманду token и возвращает код завершения процесса
+func runToken(cfg *config.Config, args []string) int {
+	var (
+		role       string
+		ttl        time.Duration
+		warehouses warehouseList
+	)
+
+	flags := flag.NewFlagSet("token", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&role, "role", "", "")
+	flags.DurationVar(&ttl, "ttl", defaultTokenTTL, "")
+	flags.Var(&warehouses, "warehouse", "")
+
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		if err != nil && err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		fmt.Fprintln(os.Stderr, tokenUsage)
+		return 2
+	}
+	if ttl <= 0 {
+		fmt.Fprintf(os.Stderr, "Некорректный срок действия токена: %s\n", ttl)
+		return 2
+	}
+	if role != "" && !domain.Role(role).Valid() {
+		fmt.Fprintf(os.Stderr, "Неизвестная роль: %s\n", role)
+		return 2
+	}
+
+	if cfg.Auth.JWTAlgorithm != config.JWTAlgorithmHS256 {
+		fmt.Fprintf(os.Stderr, "Выпуск токенов поддерживается только для %s\n", config.JWTAlgorithmHS256)
+		return 1
+	}
+
+	claims := auth.Claims{
+		Role:       domain.Role(role),
+		Warehouses: warehouses,
+	}
+	claims.Subject = flags.Arg(0)
+
+	token, err := auth.SignHS256(cfg.Auth, claims, ttl)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	fmt.Println(token)
+	return 0
+}
@@ -32,10 +32,13 @@ type OkResponse struct {
 // Ответ с ошибкой
 // swagger:response errorResponse
 type ErrorResponse struct {
-	// Сообщение об ошибке
+	// Код, описание и детали ошибки
 	// in: body
 	Body struct {
-		Error string `json:"error"`
+		Code      string      `json:"code"`
+		Message   string      `json:"message"`
+		Details   interface{} `json:"details,omitempty"`
+		RequestID string      `json:"request_id,omitempty"`
 	}
 }
 
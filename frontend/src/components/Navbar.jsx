@@ -19,7 +19,7 @@ const Navbar = () => {
     React.useEffect(() => {
         const checkHealth = async () => {
             try {
-                const res = await fetch('/api/health'); // Direct call or use api.healthCheck
+                const res = await fetch('/api/health/ready'); // Direct call or use api.healthCheck
                 setIsHealthy(res.ok);
             } catch {
                 setIsHealthy(false);
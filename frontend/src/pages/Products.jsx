@@ -74,7 +74,7 @@ const Products = () => {
     const handleUpdate = async (e) => {
         e.preventDefault();
         try {
-            await api.updateProduct(editingProduct.id, {
+            await api.updateProduct(editingProduct.id, editingProduct.version, {
                 ...form,
                 weight: parseFloat(form.weight)
             });
@@ -83,7 +83,7 @@ const Products = () => {
             setForm({ name: '', description: '', weight: '', barcode: '', characteristics: '' });
             fetchProducts();
         } catch (error) {
-            alert('Error updating product');
+            alert('Error updating product: ' + error.message);
         }
     };
 
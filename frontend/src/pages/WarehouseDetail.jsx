@@ -89,11 +89,13 @@ const WarehouseDetail = () => {
 
     const saveEdit = async (item) => {
         try {
+            // Each update bumps the inventory version, so the second one uses the version from the first response
+            let version = item.version;
             if (editForm.quantity !== item.quantity) {
-                await api.updateQuantity(id, item.product_id, editForm.quantity);
+                ({ version } = await api.updateQuantity(id, item.product_id, version, editForm.quantity));
             }
             if (editForm.discount !== item.discount) {
-                await api.updateDiscount(id, item.product_id, editForm.discount);
+                await api.updateDiscount(id, item.product_id, version, editForm.discount);
             }
             setEditingItem(null);
             fetchData();
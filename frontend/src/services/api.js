@@ -1,10 +1,25 @@
 const API_URL = import.meta.env.VITE_API_URL || '/api';
+const API_KEY = import.meta.env.VITE_API_KEY;
+
+// Adds the API key header to every request when VITE_API_KEY is set
+const request = (url, options = {}) => fetch(url, {
+    ...options,
+    headers: { ...options.headers, ...(API_KEY ? { 'X-API-Key': API_KEY } : {}) },
+});
+
+// Builds the If-Match header from the record version returned by the API (ETag)
+const ifMatch = (version) => ({ 'If-Match': `"${version}"` });
+
+// Throws a dedicated error when the record was changed by someone else since it was loaded
+const checkVersion = (res) => {
+    if (res.status === 412) throw new Error('Record was modified by another user, reload and try again');
+};
 
 export const api = {
     // Health
     healthCheck: async () => {
         try {
-            const res = await fetch(`${API_URL}/health`);
+            const res = await request(`${API_URL}/health/ready`);
             return res.ok;
         } catch (e) {
             return false;
@@ -13,12 +28,12 @@ export const api = {
 
     // Warehouses
     getWarehouses: async () => {
-        const res = await fetch(`${API_URL}/warehouses`);
+        const res = await request(`${API_URL}/warehouses`);
         if (!res.ok) throw new Error('Failed to fetch warehouses');
         return res.json();
     },
     createWarehouse: async (data) => {
-        const res = await fetch(`${API_URL}/warehouses`, {
+        const res = await request(`${API_URL}/warehouses`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify(data),
@@ -28,10 +43,20 @@ export const api = {
     },
 
     // Products
+    // The catalogue is paginated by cursor; follow next_cursor to collect every page
     getProducts: async () => {
-        const res = await fetch(`${API_URL}/products`);
-        if (!res.ok) throw new Error('Failed to fetch products');
-        return res.json();
+        const products = [];
+        let cursor = '';
+        do {
+            const params = new URLSearchParams({ limit: '200' });
+            if (cursor) params.set('cursor', cursor);
+            const res = await request(`${API_URL}/products?${params}`);
+            if (!res.ok) throw new Error('Failed to fetch products');
+            const page = await res.json();
+            products.push(...page.items);
+            cursor = page.next_cursor;
+        } while (cursor);
+        return products;
     },
     createProduct: async (data) => {
         let payload = { ...data };
@@ -43,7 +68,7 @@ export const api = {
             payload.characteristics = {};
         }
 
-        const res = await fetch(`${API_URL}/products`, {
+        const res = await request(`${API_URL}/products`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify(payload),
@@ -51,7 +76,7 @@ export const api = {
         if (!res.ok) throw new Error('Failed to create product');
         return res.json();
     },
-    updateProduct: async (id, data) => {
+    updateProduct: async (id, version, data) => {
         let payload = { ...data };
         try {
             if (typeof payload.characteristics === 'string') {
@@ -61,28 +86,29 @@ export const api = {
             // keep as is or empty
         }
 
-        const res = await fetch(`${API_URL}/products/${id}`, {
+        const res = await request(`${API_URL}/products/${id}`, {
             method: 'PUT',
-            headers: { 'Content-Type': 'application/json' },
+            headers: { 'Content-Type': 'application/json', ...ifMatch(version) },
             body: JSON.stringify(payload),
         });
+        checkVersion(res);
         if (!res.ok) throw new Error('Failed to update product');
         return res.json();
     },
 
     // Inventory
     getWarehouseProducts: async (id) => {
-        const res = await fetch(`${API_URL}/warehouses/${id}/products`);
+        const res = await request(`${API_URL}/warehouses/${id}/products`);
         if (!res.ok) throw new Error('Failed to fetch warehouse products');
         return res.json();
     },
     getWarehouseProduct: async (warehouseId, productId) => {
-        const res = await fetch(`${API_URL}/warehouses/${warehouseId}/products/${productId}`);
+        const res = await request(`${API_URL}/warehouses/${warehouseId}/products/${productId}`);
         if (!res.ok) throw new Error('Failed to fetch warehouse product');
         return res.json();
     },
     createInventory: async (data) => {
-        const res = await fetch(`${API_URL}/inventory`, {
+        const res = await request(`${API_URL}/inventory`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify(data),
@@ -90,28 +116,30 @@ export const api = {
         if (!res.ok) throw new Error('Failed to add inventory');
         return res.json();
     },
-    updateQuantity: async (warehouseId, productId, quantity) => {
-        const res = await fetch(`${API_URL}/inventory/quantity`, {
+    updateQuantity: async (warehouseId, productId, version, quantity) => {
+        const res = await request(`${API_URL}/inventory/quantity`, {
             method: 'PUT',
-            headers: { 'Content-Type': 'application/json' },
+            headers: { 'Content-Type': 'application/json', ...ifMatch(version) },
             body: JSON.stringify({ warehouse_id: warehouseId, product_id: productId, quantity: parseInt(quantity) }),
         });
+        checkVersion(res);
         if (!res.ok) throw new Error('Failed to update quantity');
         return res.json();
     },
-    updateDiscount: async (warehouseId, productId, discount) => {
-        const res = await fetch(`${API_URL}/inventory/discount`, {
+    updateDiscount: async (warehouseId, productId, version, discount) => {
+        const res = await request(`${API_URL}/inventory/discount`, {
             method: 'PUT',
-            headers: { 'Content-Type': 'application/json' },
+            headers: { 'Content-Type': 'application/json', ...ifMatch(version) },
             body: JSON.stringify({ warehouse_id: warehouseId, product_id: productId, discount: parseFloat(discount) }),
         });
+        checkVersion(res);
         if (!res.ok) throw new Error('Failed to update discount');
         return res.json();
     },
 
     // Purchase & Calculation
     calculate: async (warehouseId, products) => {
-        const res = await fetch(`${API_URL}/warehouses/calculate`, {
+        const res = await request(`${API_URL}/warehouses/calculate`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({ warehouse_id: warehouseId, products }),
@@ -120,7 +148,7 @@ export const api = {
         return res.json();
     },
     purchase: async (warehouseId, products) => {
-        const res = await fetch(`${API_URL}/warehouses/purchase`, {
+        const res = await request(`${API_URL}/warehouses/purchase`, {
             method: 'POST',
             headers: { 'Content-Type': 'application/json' },
             body: JSON.stringify({ warehouse_id: warehouseId, products }),
@@ -131,14 +159,24 @@ export const api = {
 
     // Analytics
     getWarehouseAnalytics: async (id) => {
-        const res = await fetch(`${API_URL}/analytics/warehouses/${id}`);
+        const res = await request(`${API_URL}/analytics/warehouses/${id}`);
         // Return null if 204 or 404
         if (!res.ok) return null;
         return res.json();
     },
     getTopWarehouses: async () => {
-        const res = await fetch(`${API_URL}/analytics/warehouses/top`);
+        const res = await request(`${API_URL}/analytics/warehouses/top`);
         if (!res.ok) return [];
         return res.json();
+    },
+    getSalesSeries: async ({ from, to, granularity = 'day', warehouseId, productId } = {}) => {
+        const params = new URLSearchParams({ granularity });
+        if (from) params.set('from', from);
+        if (to) params.set('to', to);
+        if (warehouseId) params.set('warehouse_id', warehouseId);
+        if (productId) params.set('product_id', productId);
+        const res = await request(`${API_URL}/analytics/sales?${params}`);
+        if (!res.ok) throw new Error('Failed to fetch sales series');
+        return res.json();
     }
 };
@@ -1,53 +1,122 @@
 package app
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sync"
 
+	"github.com/danya1733/practiceGO/internal/auth"
 	"github.com/danya1733/practiceGO/internal/config"
 	"github.com/danya1733/practiceGO/internal/handler"
+	"github.com/danya1733/practiceGO/internal/metrics"
 	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/danya1733/practiceGO/internal/service"
+	"github.com/danya1733/practiceGO/internal/tracing"
 	"github.com/danya1733/practiceGO/pkg/logger"
 )
 
 // App представляет приложение
 type App struct {
-	cfg           *config.Config
-	logger        *logger.Logger
-	db            *repository.PostgresDB
-	handler       *handler.Handler
-	warehouseRepo *repository.WarehouseRepository
-	productRepo   *repository.ProductRepository
-	inventoryRepo *repository.InventoryRepository
-	analyticsRepo *repository.AnalyticsRepository
+	cfg     *config.Config
+	logger  *logger.Logger
+	db      *repository.PostgresDB // nil для хранилища в памяти
+	handler *handler.Handler
+	sales   *service.SalesService
+	health  *service.HealthService
+	metrics *metrics.Metrics
+	// idempotency удаляет истекшие ключи идемпотентности в фоновой задаче
+	idempotency *service.IdempotencyService
+
+	// shutdownTracing отправляет оставшиеся спаны и останавливает трассировку
+	shutdownTracing func(context.Context) error
+
+	// Фоновые задачи
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
 }
 
 // NewApp создает новое приложение
 func NewApp(cfg *config.Config, logger *logger.Logger) (*App, error) {
-	// Инициализация базы данных
-	db, err := repository.NewPostgresDB(cfg.Database)
+	// Проверка JWT настраивается до подключения к хранилищу,
+	// чтобы ошибка в ключах не оставляла открытое подключение
+	verifier, err := auth.NewVerifier(cfg.Auth)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Auth.Enabled {
+		logger.Warn("Аутентификация отключена: все запросы выполняются от имени anonymous")
+	}
+
+	// Трассировка настраивается до подключения к хранилищу,
+	// чтобы запросы к базе данных при запуске тоже попадали в спаны
+	shutdownTracing, err := tracing.Setup(cfg.Tracing)
 	if err != nil {
 		return nil, err
 	}
 
-	// Инициализация репозиториев
-	warehouseRepo := repository.NewWarehouseRepository(db.GetPool())
-	productRepo := repository.NewProductRepository(db.GetPool())
-	inventoryRepo := repository.NewInventoryRepository(db.GetPool())
-	analyticsRepo := repository.NewAnalyticsRepository(db.GetPool())
+	// Инициализация хранилища и репозиториев
+	repos, db, err := openStorage(cfg, logger)
+	if err != nil {
+		shutdownTracing(context.Background())
+		return nil, err
+	}
+
+	// Метрики; статистика пула соединений доступна только для PostgreSQL
+	m := metrics.New()
+	if db != nil {
+		m.Register(metrics.NewPoolCollector(db.GetPool()))
+	}
+
+	// Инициализация сервисов
+	sales := service.NewSalesService(repos.inventory, repos.products, repos.reservations, repos.orders, m, cfg.Reservation)
+	health := service.NewHealthService(repos.health)
+	idempotency := service.NewIdempotencyService(repos.idempotency, cfg.Idempotency.TTL)
 
 	// Инициализация обработчика HTTP запросов
-	h := handler.NewHandler(warehouseRepo, productRepo, inventoryRepo, analyticsRepo, logger)
-
-	return &App{
-		cfg:           cfg,
-		logger:        logger,
-		db:            db,
-		handler:       h,
-		warehouseRepo: warehouseRepo,
-		productRepo:   productRepo,
-		inventoryRepo: inventoryRepo,
-		analyticsRepo: analyticsRepo,
-	}, nil
+	h := handler.NewHandler(
+		service.NewWarehouseService(repos.warehouses),
+		service.NewProductService(repos.products),
+		service.NewInventoryService(repos.inventory, repos.products, repos.movements),
+		sales,
+		service.NewTransferService(repos.transfers),
+		service.NewAnalyticsService(repos.analytics),
+		service.NewAuthService(repos.auth, verifier, cfg.Auth.Enabled),
+		service.NewAuditService(repos.audit),
+		health,
+		idempotency,
+		m,
+		cfg.Log,
+		logger,
+	)
+
+	a := &App{
+		cfg:     cfg,
+		logger:  logger,
+		db:      db,
+		handler: h,
+		sales:   sales,
+		health:  health,
+		metrics: m,
+
+		idempotency:     idempotency,
+		shutdownTracing: shutdownTracing,
+	}
+
+	// Запуск фоновых задач
+	ctx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.wg.Add(2)
+	go func() {
+		defer a.wg.Done()
+		a.runSweeper(ctx, a.cfg.Reservation.SweepInterval, a.sweepReservations)
+	}()
+	go func() {
+		defer a.wg.Done()
+		a.runSweeper(ctx, a.cfg.Idempotency.SweepInterval, a.sweepIdempotencyKeys)
+	}()
+
+	return a, nil
 }
 
 // Router возвращает маршрутизатор HTTP
@@ -55,8 +124,31 @@ func (a *App) Router() http.Handler {
 	return a.handler.RegisterRoutes()
 }
 
-// Close закрывает соединения
+// MetricsRouter возвращает маршрутизатор сервера метрик Prometheus
+func (a *App) MetricsRouter() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", a.metrics.Handler())
+	return mux
+}
+
+// Drain переводит приложение в состояние остановки: проверка готовности
+// возвращает not_ready, но запросы продолжают обрабатываться
+func (a *App) Drain() {
+	a.health.Drain()
+}
+
+// Close останавливает фоновые задачи и закрывает соединения
 func (a *App) Close() error {
-	a.db.Close()
+	a.cancel()
+	a.wg.Wait()
+	if a.db != nil {
+		a.db.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.cfg.HTTP.ShutdownTimeout)
+	defer cancel()
+	if err := a.shutdownTracing(ctx); err != nil {
+		return fmt.Errorf("ошибка остановки трассировки: %w", err)
+	}
 	return nil
 }
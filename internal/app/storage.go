@@ -0,0 +1,113 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danya1733/practiceGO/internal/config"
+	"github.com/danya1733/practiceGO/internal/migration"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/danya1733/practiceGO/internal/repository/memory"
+	"github.com/danya1733/practiceGO/internal/service"
+	"github.com/danya1733/practiceGO/migrations"
+	"github.com/danya1733/practiceGO/pkg/logger"
+)
+
+// repositories набор репозиториев выбранного хранилища
+type repositories struct {
+	warehouses   service.WarehouseRepository
+	products     service.ProductRepository
+	inventory    service.InventoryRepository
+	analytics    service.AnalyticsRepository
+	orders       service.OrderRepository
+	reservations service.ReservationRepository
+	movements    service.StockMovementRepository
+	transfers    service.TransferRepository
+	auth         service.AuthRepository
+	audit        service.AuditRepository
+	health       service.HealthRepository
+	idempotency  service.IdempotencyRepository
+}
+
+// newPostgresRepositories создает репозитории, работающие с PostgreSQL
+func newPostgresRepositories(db *repository.PostgresDB, migrator *migration.Migrator) repositories {
+	pool := db.GetPool()
+	return repositories{
+		warehouses:   repository.NewWarehouseRepository(pool),
+		products:     repository.NewProductRepository(pool),
+		inventory:    repository.NewInventoryRepository(pool),
+		analytics:    repository.NewAnalyticsRepository(pool),
+		orders:       repository.NewOrderRepository(pool),
+		reservations: repository.NewReservationRepository(pool),
+		movements:    repository.NewStockMovementRepository(pool),
+		transfers:    repository.NewTransferRepository(pool),
+		auth:         repository.NewAuthRepository(pool),
+		audit:        repository.NewAuditRepository(pool),
+		health:       repository.NewHealthRepository(pool, migrator),
+		idempotency:  repository.NewIdempotencyRepository(pool),
+	}
+}
+
+// newMemoryRepositories создает репозитории, хранящие данные в памяти
+func newMemoryRepositories() repositories {
+	store := memory.NewStore()
+	return repositories{
+		warehouses:   memory.NewWarehouseRepository(store),
+		products:     memory.NewProductRepository(store),
+		inventory:    memory.NewInventoryRepository(store),
+		analytics:    memory.NewAnalyticsRepository(store),
+		orders:       memory.NewOrderRepository(store),
+		reservations: memory.NewReservationRepository(store),
+		movements:    memory.NewStockMovementRepository(store),
+		transfers:    memory.NewTransferRepository(store),
+		auth:         memory.NewAuthRepository(store),
+		audit:        memory.NewAuditRepository(store),
+		health:       memory.NewHealthRepository(store),
+		idempotency:  memory.NewIdempotencyRepository(store),
+	}
+}
+
+// openStorage открывает хранилище, выбранное в конфигурации.
+// Для хранилища в памяти подключение к базе данных не создается и db равен nil.
+// Если включен MigrateOnStart, перед началом работы применяются встроенные миграции
+func openStorage(cfg *config.Config, l *logger.Logger) (repositories, *repository.PostgresDB, error) {
+	if cfg.Storage.Backend == config.StorageBackendMemory {
+		return newMemoryRepositories(), nil, nil
+	}
+
+	db, err := repository.NewPostgresDB(cfg.Database)
+	if err != nil {
+		return repositories{}, nil, err
+	}
+
+	// Мигратор нужен и без MigrateOnStart: проверка готовности сравнивает
+	// версию схемы с версией последней встроенной миграции
+	migrator, err := migration.New(db.GetPool(), migrations.FS)
+	if err != nil {
+		db.Close()
+		return repositories{}, nil, err
+	}
+
+	if cfg.Database.MigrateOnStart {
+		if err := migrateUp(migrator, l); err != nil {
+			db.Close()
+			return repositories{}, nil, err
+		}
+	}
+
+	return newPostgresRepositories(db, migrator), db, nil
+}
+
+// migrateUp применяет встроенные миграции
+func migrateUp(migrator *migration.Migrator, l *logger.Logger) error {
+	applied, err := migrator.Up(context.Background())
+	if err != nil {
+		return fmt.Errorf("ошибка применения миграций: %w", err)
+	}
+
+	for _, m := range applied {
+		l.Info("Применена миграция", logger.Any("version", m.Version), logger.String("name", m.Name))
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"github.com/danya1733/practiceGO/pkg/logger"
+)
+
+// runSweeper вызывает sweep с периодом interval, пока не отменен ctx.
+// Каждая очистка запускается в своем цикле, поэтому медленная очистка
+// не задерживает другие
+func (a *App) runSweeper(ctx context.Context, interval time.Duration, sweep func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sweep(ctx)
+		}
+	}
+}
+
+// sweepReservations снимает истекшие резервы пачками, пока они не закончатся
+func (a *App) sweepReservations(ctx context.Context) {
+	total, err := a.sales.ReleaseExpiredReservations(ctx, a.cfg.Reservation.SweepBatch)
+	if err != nil && ctx.Err() == nil {
+		a.logger.Error("Ошибка при снятии истекших резервов", logger.Error(err))
+	}
+
+	if total > 0 {
+		a.logger.Info("Сняты истекшие резервы", logger.Int("count", total))
+	}
+}
+
+// sweepIdempotencyKeys удаляет истекшие ключи идемпотентности
+func (a *App) sweepIdempotencyKeys(ctx context.Context) {
+	total, err := a.idempotency.DeleteExpired(ctx)
+	if err != nil && ctx.Err() == nil {
+		a.logger.Error("Ошибка при удалении истекших ключей идемпотентности", logger.Error(err))
+	}
+
+	if total > 0 {
+		a.logger.Info("Удалены истекшие ключи идемпотентности", logger.Int("count", total))
+	}
+}
@@ -0,0 +1,43 @@
+// Package auth содержит проверку JWT и работу с API ключами
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// APIKeyPrefix начало каждого API ключа; по нему API ключ отличается от JWT
+const APIKeyPrefix = "wms_"
+
+// apiKeyBytes количество случайных байт в API ключе
+const apiKeyBytes = 32
+
+// displayPrefixLen количество символов ключа, сохраняемых открыто для отображения в списке
+const displayPrefixLen = len(APIKeyPrefix) + 8
+
+// GenerateAPIKey создает новый API ключ и возвращает сам ключ,
+// его открытый префикс для отображения и хеш для хранения
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	buf := make([]byte, apiKeyBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", "", err
+	}
+
+	key = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+	return key, key[:displayPrefixLen], HashAPIKey(key), nil
+}
+
+// HashAPIKey возвращает хеш API ключа.
+// Ключ содержит 256 бит случайных данных, поэтому медленная хеш-функция не нужна
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIKey проверяет, похожа ли строка на API ключ
+func IsAPIKey(credential string) bool {
+	return strings.HasPrefix(credential, APIKeyPrefix)
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, prefix, hash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("создание API ключа: %v", err)
+	}
+
+	if !strings.HasPrefix(key, APIKeyPrefix) || !IsAPIKey(key) {
+		t.Errorf("ключ %q не начинается с %s", key, APIKeyPrefix)
+	}
+	if !strings.HasPrefix(key, prefix) || len(prefix) != displayPrefixLen {
+		t.Errorf("префикс %q не является началом ключа длиной %d", prefix, displayPrefixLen)
+	}
+	if hash != HashAPIKey(key) {
+		t.Error("хеш ключа не совпадает с HashAPIKey")
+	}
+	if strings.Contains(hash, key) {
+		t.Error("хеш содержит сам ключ")
+	}
+
+	other, _, otherHash, err := GenerateAPIKey()
+	if err != nil {
+		t.Fatalf("создание API ключа: %v", err)
+	}
+	if other == key || otherHash == hash {
+		t.Error("два ключа совпали")
+	}
+}
+
+func TestHashAPIKey(t *testing.T) {
+	// SHA-256 в шестнадцатеричной записи
+	const want = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+	if got := HashAPIKey("test"); got != want {
+		t.Errorf("HashAPIKey(test) = %s, ожидалось %s", got, want)
+	}
+}
+
+func TestIsAPIKey(t *testing.T) {
+	tests := []struct {
+		credential string
+		want       bool
+	}{
+		{"wms_abc", true},
+		{"eyJhbGciOiJIUzI1NiJ9.e30.sig", false},
+		{"WMS_abc", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := IsAPIKey(tt.credential); got != tt.want {
+			t.Errorf("IsAPIKey(%q) = %v, ожидалось %v", tt.credential, got, tt.want)
+		}
+	}
+}
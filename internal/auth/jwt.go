@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/config"
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ErrJWTDisabled возвращается, если ключ для проверки JWT не настроен
+var ErrJWTDisabled = errors.New("проверка JWT не настроена")
+
+// Claims содержимое JWT: стандартные claims, роль и склады пользователя
+type Claims struct {
+	jwt.RegisteredClaims
+	Role       domain.Role `json:"role,omitempty"`
+	Warehouses []uuid.UUID `json:"warehouses,omitempty"`
+}
+
+// Verifier проверяет подпись и срок действия JWT
+type Verifier struct {
+	key     interface{}
+	options []jwt.ParserOption
+}
+
+// NewVerifier создает проверку JWT по настройкам аутентификации.
+// Если ключ не задан, возвращается Verifier, отклоняющий все токены
+func NewVerifier(cfg config.AuthConfig) (*Verifier, error) {
+	v := &Verifier{
+		options: []jwt.ParserOption{
+			jwt.WithValidMethods([]string{cfg.JWTAlgorithm}),
+			jwt.WithExpirationRequired(),
+			jwt.WithLeeway(30 * time.Second),
+		},
+	}
+	if cfg.JWTIssuer != "" {
+		v.options = append(v.options, jwt.WithIssuer(cfg.JWTIssuer))
+	}
+	if cfg.JWTAudience != "" {
+		v.options = append(v.options, jwt.WithAudience(cfg.JWTAudience))
+	}
+
+	switch cfg.JWTAlgorithm {
+	case config.JWTAlgorithmHS256:
+		if cfg.JWTSecret != "" {
+			v.key = []byte(cfg.JWTSecret)
+		}
+	case config.JWTAlgorithmRS256:
+		if cfg.JWTPublicKeyFile != "" {
+			pem, err := os.ReadFile(cfg.JWTPublicKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка чтения открытого ключа JWT: %w", err)
+			}
+			key, err := jwt.ParseRSAPublicKeyFromPEM(pem)
+			if err != nil {
+				return nil, fmt.Errorf("ошибка разбора открытого ключа JWT: %w", err)
+			}
+			v.key = key
+		}
+	}
+
+	return v, nil
+}
+
+// Verify проверяет токен и возвращает его claims.
+// Токен без claim sub или с неизвестной ролью отклоняется
+func (v *Verifier) Verify(token string) (Claims, error) {
+	if v.key == nil {
+		return Claims{}, ErrJWTDisabled
+	}
+
+	var claims Claims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return v.key, nil
+	}, v.options...)
+	if err != nil {
+		return Claims{}, err
+	}
+
+	if claims.Subject == "" {
+		return Claims{}, errors.New("в токене нет claim sub")
+	}
+	// Пользователь без роли аутентифицирован, но может работать только со своими API ключами
+	if claims.Role != "" && !claims.Role.Valid() {
+		return Claims{}, fmt.Errorf("неизвестная роль %q", claims.Role)
+	}
+
+	return claims, nil
+}
+
+// SignHS256 выпускает токен HS256 с claims со сроком действия ttl.
+// Используется для локальной разработки, когда токены не выпускает внешний сервис
+func SignHS256(cfg config.AuthConfig, claims Claims, ttl time.Duration) (string, error) {
+	if cfg.JWTSecret == "" {
+		return "", ErrJWTDisabled
+	}
+
+	now := time.Now()
+	claims.Issuer = cfg.JWTIssuer
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	if cfg.JWTAudience != "" {
+		claims.Audience = jwt.ClaimStrings{cfg.JWTAudience}
+	}
+
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(cfg.JWTSecret))
+}
@@ -0,0 +1,181 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/config"
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// testConfig настройки проверки JWT с HS256
+var testConfig = config.AuthConfig{
+	Enabled:      true,
+	JWTAlgorithm: config.JWTAlgorithmHS256,
+	JWTSecret:    "test-secret",
+}
+
+// newTestVerifier создает проверку JWT по настройкам cfg
+func newTestVerifier(t *testing.T, cfg config.AuthConfig) *Verifier {
+	t.Helper()
+
+	v, err := NewVerifier(cfg)
+	if err != nil {
+		t.Fatalf("создание проверки JWT: %v", err)
+	}
+	return v
+}
+
+// sign подписывает claims методом method ключом key
+func sign(t *testing.T, method jwt.SigningMethod, key interface{}, claims jwt.Claims) string {
+	t.Helper()
+
+	token, err := jwt.NewWithClaims(method, claims).SignedString(key)
+	if err != nil {
+		t.Fatalf("подпись JWT: %v", err)
+	}
+	return token
+}
+
+// validClaims возвращает claims администратора, истекающие через ttl
+func validClaims(ttl time.Duration) Claims {
+	return Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   "alice",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+		Role: domain.RoleAdmin,
+	}
+}
+
+func TestVerifyAcceptsValidToken(t *testing.T) {
+	v := newTestVerifier(t, testConfig)
+
+	token, err := SignHS256(testConfig, Claims{
+		RegisteredClaims: jwt.RegisteredClaims{Subject: "alice"},
+		Role:             domain.RoleClerk,
+	}, time.Hour)
+	if err != nil {
+		t.Fatalf("выпуск JWT: %v", err)
+	}
+
+	claims, err := v.Verify(token)
+	if err != nil {
+		t.Fatalf("проверка JWT: %v", err)
+	}
+	if claims.Subject != "alice" || claims.Role != domain.RoleClerk {
+		t.Errorf("субъект %q, роль %q; ожидалось alice и clerk", claims.Subject, claims.Role)
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	v := newTestVerifier(t, testConfig)
+	secret := []byte(testConfig.JWTSecret)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("создание ключа RSA: %v", err)
+	}
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, validClaims(time.Hour)).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatalf("выпуск JWT без подписи: %v", err)
+	}
+
+	withoutExp := validClaims(time.Hour)
+	withoutExp.ExpiresAt = nil
+
+	withoutSubject := validClaims(time.Hour)
+	withoutSubject.Subject = ""
+
+	unknownRole := validClaims(time.Hour)
+	unknownRole.Role = "root"
+
+	tests := []struct {
+		name  string
+		token string
+	}{
+		{"алгоритм none", unsigned},
+		{"алгоритм HS384 вне списка разрешенных", sign(t, jwt.SigningMethodHS384, secret, validClaims(time.Hour))},
+		{"алгоритм RS256 вне списка разрешенных", sign(t, jwt.SigningMethodRS256, rsaKey, validClaims(time.Hour))},
+		{"чужой секрет", sign(t, jwt.SigningMethodHS256, []byte("other-secret"), validClaims(time.Hour))},
+		{"нет claim exp", sign(t, jwt.SigningMethodHS256, secret, withoutExp)},
+		{"истек больше допуска назад", sign(t, jwt.SigningMethodHS256, secret, validClaims(-time.Minute))},
+		{"нет claim sub", sign(t, jwt.SigningMethodHS256, secret, withoutSubject)},
+		{"неизвестная роль", sign(t, jwt.SigningMethodHS256, secret, unknownRole)},
+		{"не JWT", "not-a-token"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := v.Verify(tt.token); err == nil {
+				t.Error("токен принят, ожидалась ошибка")
+			}
+		})
+	}
+}
+
+func TestVerifyLeeway(t *testing.T) {
+	v := newTestVerifier(t, testConfig)
+
+	// Расхождение часов до 30 секунд допускается
+	token := sign(t, jwt.SigningMethodHS256, []byte(testConfig.JWTSecret), validClaims(-10*time.Second))
+	if _, err := v.Verify(token); err != nil {
+		t.Errorf("токен, истекший в пределах допуска, отклонен: %v", err)
+	}
+}
+
+func TestVerifyIssuerAndAudience(t *testing.T) {
+	cfg := testConfig
+	cfg.JWTIssuer = "https://auth.example.com"
+	cfg.JWTAudience = "wms"
+	v := newTestVerifier(t, cfg)
+	secret := []byte(cfg.JWTSecret)
+
+	valid := validClaims(time.Hour)
+	valid.Issuer = cfg.JWTIssuer
+	valid.Audience = jwt.ClaimStrings{cfg.JWTAudience}
+	if _, err := v.Verify(sign(t, jwt.SigningMethodHS256, secret, valid)); err != nil {
+		t.Errorf("токен с верными iss и aud отклонен: %v", err)
+	}
+
+	otherIssuer := valid
+	otherIssuer.Issuer = "https://evil.example.com"
+	if _, err := v.Verify(sign(t, jwt.SigningMethodHS256, secret, otherIssuer)); err == nil {
+		t.Error("токен с чужим iss принят")
+	}
+
+	otherAudience := valid
+	otherAudience.Audience = jwt.ClaimStrings{"billing"}
+	if _, err := v.Verify(sign(t, jwt.SigningMethodHS256, secret, otherAudience)); err == nil {
+		t.Error("токен с чужим aud принят")
+	}
+}
+
+func TestVerifyWithoutKey(t *testing.T) {
+	cfg := testConfig
+	cfg.JWTSecret = ""
+	v := newTestVerifier(t, cfg)
+
+	token := sign(t, jwt.SigningMethodHS256, []byte("any"), validClaims(time.Hour))
+	if _, err := v.Verify(token); !errors.Is(err, ErrJWTDisabled) {
+		t.Errorf("ожидалась ErrJWTDisabled, получено %v", err)
+	}
+}
+
+func TestVerifyAcceptsTokenWithoutRole(t *testing.T) {
+	v := newTestVerifier(t, testConfig)
+
+	// Пользователь без роли может работать только со своими API ключами
+	claims := validClaims(time.Hour)
+	claims.Role = ""
+	verified, err := v.Verify(sign(t, jwt.SigningMethodHS256, []byte(testConfig.JWTSecret), claims))
+	if err != nil {
+		t.Fatalf("токен без роли отклонен: %v", err)
+	}
+	if verified.Role != "" {
+		t.Errorf("роль %q, ожидалась пустая", verified.Role)
+	}
+}
@@ -2,6 +2,8 @@ package domain
 
 import (
 	"encoding/json"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 )
@@ -9,27 +11,135 @@ import (
 // Warehouse представляет склад
 type Warehouse struct {
 	ID      uuid.UUID `json:"id"`
-	Address string    `json:"address"`
+	Address string    `json:"address" validate:"required,max=500"`
 }
 
 // Product представляет товар
 type Product struct {
 	ID              uuid.UUID       `json:"id"`
-	Name            string          `json:"name"`
-	Description     string          `json:"description"`
-	Characteristics json.RawMessage `json:"characteristics"`
-	Weight          float64         `json:"weight"`
-	Barcode         string          `json:"barcode"`
+	Name            string          `json:"name" validate:"required,max=255"`
+	Description     string          `json:"description" validate:"max=2000"`
+	Characteristics json.RawMessage `json:"characteristics" validate:"required,json_object"`
+	Weight          float64         `json:"weight" validate:"gt=0"`
+	Barcode         string          `json:"barcode" validate:"required,max=64"`
+	// Version увеличивается при каждом изменении товара и возвращается в заголовке ETag
+	Version int `json:"version"`
+}
+
+// ProductSort представляет порядок сортировки каталога товаров
+type ProductSort string
+
+// Порядки сортировки товаров; минус означает сортировку по убыванию
+const (
+	ProductSortName        ProductSort = "name"
+	ProductSortNameDesc    ProductSort = "-name"
+	ProductSortWeight      ProductSort = "weight"
+	ProductSortWeightDesc  ProductSort = "-weight"
+	ProductSortBarcode     ProductSort = "barcode"
+	ProductSortBarcodeDesc ProductSort = "-barcode"
+)
+
+// Desc сообщает, что товары сортируются по убыванию
+func (s ProductSort) Desc() bool {
+	return len(s) > 0 && s[0] == '-'
+}
+
+// ProductFilter представляет параметры выборки каталога товаров
+type ProductFilter struct {
+	Search    string // полнотекстовый поиск по названию и описанию
+	Barcode   string
+	MinWeight *float64
+	MaxWeight *float64
+	// Attributes фильтрует товары по характеристикам; все условия должны выполняться
+	Attributes []AttributeFilter
+	Sort       ProductSort
+	Limit      int
+	// After задает позицию, после которой начинается страница
+	After *ProductCursor
+}
+
+// AttributeOp представляет операцию сравнения характеристики товара
+type AttributeOp string
+
+// Операции сравнения характеристик
+const (
+	AttributeEq  AttributeOp = "eq"
+	AttributeNe  AttributeOp = "ne"
+	AttributeGt  AttributeOp = "gt"
+	AttributeGte AttributeOp = "gte"
+	AttributeLt  AttributeOp = "lt"
+	AttributeLte AttributeOp = "lte"
+)
+
+// Ordered сообщает, что операция сравнивает числа, а не проверяет равенство
+func (op AttributeOp) Ordered() bool {
+	return op == AttributeGt || op == AttributeGte || op == AttributeLt || op == AttributeLte
+}
+
+// AttributeFilter представляет условие на характеристику товара по пути Path
+// в JSON характеристик, например ["size", "eu"] для {"size": {"eu": 42}}
+type AttributeFilter struct {
+	Path []string
+	Op   AttributeOp
+	// Values для eq и ne: характеристика равна любому из значений (для ne - ни одному).
+	// Значение совпадает со строкой, а если похоже на число или true/false - и с числом или логическим значением
+	Values []string
+	// Number задает границу для gt, gte, lt и lte; сравниваются только числовые характеристики
+	Number float64
+}
+
+// AttributeFacet представляет характеристику товаров и число товаров с каждым ее значением
+type AttributeFacet struct {
+	// Key путь к характеристике через точку, как в фильтре attr.<key>
+	Key string `json:"key"`
+	// Count число товаров, у которых характеристика задана
+	Count  int                   `json:"count"`
+	Values []AttributeValueCount `json:"values"`
+}
+
+// AttributeValueCount представляет значение характеристики и число товаров с ним
+type AttributeValueCount struct {
+	Value json.RawMessage `json:"value"`
+	Count int             `json:"count"`
+}
+
+// WarehouseProductFilter представляет параметры выборки товаров на складе
+type WarehouseProductFilter struct {
+	Attributes []AttributeFilter
+	Page       int
+	Limit      int
+}
+
+// ProductCursor представляет позицию в каталоге товаров: значение поля сортировки
+// и ID последнего товара предыдущей страницы
+type ProductCursor struct {
+	Sort    ProductSort `json:"sort"`
+	Name    string      `json:"name,omitempty"`
+	Weight  float64     `json:"weight,omitempty"`
+	Barcode string      `json:"barcode,omitempty"`
+	ID      uuid.UUID   `json:"id"`
+}
+
+// ProductPage представляет страницу каталога товаров
+type ProductPage struct {
+	Items []Product `json:"items"`
+	// NextCursor передается в параметре cursor для получения следующей страницы;
+	// пуст на последней странице
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // Inventory представляет связь между товаром и складом
 type Inventory struct {
 	ID          uuid.UUID `json:"id"`
-	WarehouseID uuid.UUID `json:"warehouse_id"`
-	ProductID   uuid.UUID `json:"product_id"`
-	Quantity    int       `json:"quantity"`
-	Price       float64   `json:"price"`
-	Discount    float64   `json:"discount"` // в процентах
+	WarehouseID uuid.UUID `json:"warehouse_id" validate:"required"`
+	ProductID   uuid.UUID `json:"product_id" validate:"required"`
+	Quantity    int       `json:"quantity" validate:"gte=0"`
+	Reserved    int       `json:"reserved"`  // зарезервировано активными резервами
+	Available   int       `json:"available"` // доступно для продажи: quantity - reserved
+	Price       float64   `json:"price" validate:"gte=0"`
+	Discount    float64   `json:"discount" validate:"gte=0,lte=100"` // в процентах
+	// Version увеличивается при каждом изменении остатка, в том числе при продажах и резервах
+	Version int `json:"version"`
 }
 
 // InventoryWithProduct представляет инвентарь с информацией о товаре
@@ -56,25 +166,505 @@ type WarehouseAnalytics struct {
 
 // ProductPurchase представляет информацию о покупке товара
 type ProductPurchase struct {
-	ProductID uuid.UUID `json:"product_id"`
-	Quantity  int       `json:"quantity"`
+	ProductID uuid.UUID `json:"product_id" validate:"required"`
+	Quantity  int       `json:"quantity" validate:"gt=0"`
 }
 
 // PurchaseRequest представляет запрос на покупку товаров
 type PurchaseRequest struct {
-	WarehouseID uuid.UUID         `json:"warehouse_id"`
-	Products    []ProductPurchase `json:"products"`
+	WarehouseID uuid.UUID         `json:"warehouse_id" validate:"required"`
+	Products    []ProductPurchase `json:"products" validate:"required_without=ReservationID,omitempty,min=1,dive"`
+	// Reserve при расчете стоимости создает резерв на товары
+	Reserve bool `json:"reserve,omitempty"`
+	// ReservationTTL время жизни резерва в секундах
+	ReservationTTL int `json:"reservation_ttl,omitempty" validate:"gte=0"`
+	// ReservationID при покупке списывает ранее созданный резерв;
+	// если Products не указаны, покупаются товары из резерва
+	ReservationID *uuid.UUID `json:"reservation_id,omitempty"`
+}
+
+// InventoryQuantityUpdate представляет запрос на изменение количества товара на складе
+type InventoryQuantityUpdate struct {
+	WarehouseID string       `json:"warehouse_id" validate:"required,uuid"`
+	ProductID   string       `json:"product_id" validate:"required,uuid"`
+	Quantity    int          `json:"quantity"`
+	Type        MovementType `json:"type" validate:"omitempty,oneof=receipt adjustment return write_off"`
+	Reason      string       `json:"reason" validate:"max=255"`
+}
+
+// InventoryDiscountUpdate представляет запрос на изменение скидки на товар
+type InventoryDiscountUpdate struct {
+	WarehouseID string  `json:"warehouse_id" validate:"required,uuid"`
+	ProductID   string  `json:"product_id" validate:"required,uuid"`
+	Discount    float64 `json:"discount" validate:"gte=0,lte=100"` // в процентах
+}
+
+// PriceFunc вычисляет цену единицы товара с учетом скидки в процентах
+type PriceFunc func(price, discount float64) float64
+
+// CalculationItem представляет позицию расчета стоимости
+type CalculationItem struct {
+	ProductID         uuid.UUID `json:"product_id"`
+	Name              string    `json:"name"`
+	Quantity          int       `json:"quantity"`
+	Price             float64   `json:"price"`
+	PriceWithDiscount float64   `json:"price_with_discount"`
+	TotalPrice        float64   `json:"total_price"`
 }
 
 // CalculationResult представляет результат расчета стоимости товаров
 type CalculationResult struct {
-	TotalSum float64 `json:"total_sum"`
-	Items    []struct {
-		ProductID         uuid.UUID `json:"product_id"`
-		Name              string    `json:"name"`
-		Quantity          int       `json:"quantity"`
-		Price             float64   `json:"price"`
-		PriceWithDiscount float64   `json:"price_with_discount"`
-		TotalPrice        float64   `json:"total_price"`
-	} `json:"items"`
+	TotalSum float64           `json:"total_sum"`
+	Items    []CalculationItem `json:"items"`
+	// Reservation заполняется, если при расчете был создан резерв
+	Reservation *Reservation `json:"reservation,omitempty"`
+}
+
+// Order представляет заказ (совершенную покупку)
+type Order struct {
+	ID          uuid.UUID   `json:"id"`
+	WarehouseID uuid.UUID   `json:"warehouse_id"`
+	TotalSum    float64     `json:"total_sum"`
+	CreatedAt   time.Time   `json:"created_at"`
+	Items       []OrderItem `json:"items"`
+}
+
+// OrderItem представляет позицию заказа с фактически примененной ценой и скидкой
+type OrderItem struct {
+	ID                uuid.UUID `json:"id"`
+	OrderID           uuid.UUID `json:"order_id"`
+	ProductID         uuid.UUID `json:"product_id"`
+	Quantity          int       `json:"quantity"`
+	Price             float64   `json:"price"`
+	Discount          float64   `json:"discount"` // в процентах
+	PriceWithDiscount float64   `json:"price_with_discount"`
+	TotalPrice        float64   `json:"total_price"`
+}
+
+// OrderFilter представляет параметры выборки заказов
+type OrderFilter struct {
+	WarehouseID *uuid.UUID
+	From        *time.Time
+	To          *time.Time
+	Page        int
+	Limit       int
+}
+
+// ReservationStatus представляет статус резерва
+type ReservationStatus string
+
+// Статусы резерва
+const (
+	ReservationActive   ReservationStatus = "active"
+	ReservationConsumed ReservationStatus = "consumed"
+	ReservationReleased ReservationStatus = "released"
+	ReservationExpired  ReservationStatus = "expired"
+)
+
+// Reservation представляет резерв товаров на складе до момента покупки
+type Reservation struct {
+	ID          uuid.UUID         `json:"id"`
+	WarehouseID uuid.UUID         `json:"warehouse_id"`
+	Status      ReservationStatus `json:"status"`
+	ExpiresAt   time.Time         `json:"expires_at"`
+	CreatedAt   time.Time         `json:"created_at"`
+	Items       []ProductPurchase `json:"items"`
+}
+
+// MovementType представляет тип движения товара
+type MovementType string
+
+// Типы движения товара
+const (
+	MovementReceipt    MovementType = "receipt"
+	MovementSale       MovementType = "sale"
+	MovementAdjustment MovementType = "adjustment"
+	MovementTransfer   MovementType = "transfer"
+	MovementReturn     MovementType = "return"
+	MovementWriteOff   MovementType = "write_off"
+)
+
+// Valid проверяет, что тип движения известен
+func (t MovementType) Valid() bool {
+	switch t {
+	case MovementReceipt, MovementSale, MovementAdjustment, MovementTransfer, MovementReturn, MovementWriteOff:
+		return true
+	}
+	return false
+}
+
+// StockMovement представляет запись журнала движения товара
+type StockMovement struct {
+	ID            uuid.UUID    `json:"id"`
+	WarehouseID   uuid.UUID    `json:"warehouse_id"`
+	ProductID     uuid.UUID    `json:"product_id"`
+	Type          MovementType `json:"type"`
+	QuantityDelta int          `json:"quantity_delta"`
+	QuantityAfter int          `json:"quantity_after"`
+	Reason        string       `json:"reason"`
+	Actor         string       `json:"actor"`
+	RequestID     string       `json:"request_id"`
+	ReferenceID   *uuid.UUID   `json:"reference_id,omitempty"` // например, ID заказа
+	CreatedAt     time.Time    `json:"created_at"`
+}
+
+// MovementInfo описывает, кто и почему изменяет остаток
+type MovementInfo struct {
+	Type      MovementType
+	Reason    string
+	Actor     string
+	RequestID string
+}
+
+// MovementFilter представляет параметры выборки журнала движения
+type MovementFilter struct {
+	From  *time.Time
+	To    *time.Time
+	Page  int
+	Limit int
+}
+
+// LedgerDiscrepancy представляет расхождение остатка с суммой журнала движения
+type LedgerDiscrepancy struct {
+	WarehouseID    uuid.UUID `json:"warehouse_id"`
+	ProductID      uuid.UUID `json:"product_id"`
+	Quantity       int       `json:"quantity"`
+	LedgerQuantity int       `json:"ledger_quantity"`
+	Reserved       int       `json:"reserved"`
+	// Conflict означает, что остаток не исправлен: значение из журнала меньше резерва
+	Conflict bool `json:"conflict"`
+}
+
+// Fixable сообщает, можно ли привести остаток к значению из журнала
+func (d LedgerDiscrepancy) Fixable() bool {
+	return !d.Conflict
+}
+
+// AuditAction представляет действие, записанное в журнал аудита
+type AuditAction string
+
+// Действия журнала аудита
+const (
+	AuditCreate   AuditAction = "create"
+	AuditUpdate   AuditAction = "update"
+	AuditDispatch AuditAction = "dispatch"
+	AuditReceive  AuditAction = "receive"
+	AuditCancel   AuditAction = "cancel"
+	AuditRelease  AuditAction = "release"
+	AuditRevoke   AuditAction = "revoke"
+	AuditRebuild  AuditAction = "rebuild"
+)
+
+// AuditEntry представляет запись журнала аудита: кто, в рамках какого запроса
+// и как изменил сущность. Before и After содержат состояние сущности до и после изменения
+type AuditEntry struct {
+	ID        uuid.UUID       `json:"id"`
+	Actor     string          `json:"actor"`
+	RequestID string          `json:"request_id"`
+	Entity    string          `json:"entity"`
+	EntityID  uuid.UUID       `json:"entity_id"`
+	Action    AuditAction     `json:"action"`
+	Before    json.RawMessage `json:"before,omitempty"`
+	After     json.RawMessage `json:"after,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// AuditFilter представляет параметры выборки журнала аудита
+type AuditFilter struct {
+	Entity    string
+	EntityID  *uuid.UUID
+	Action    AuditAction
+	Actor     string
+	RequestID string
+	From      *time.Time
+	To        *time.Time
+	Page      int
+	Limit     int
+}
+
+// IdempotencyRecord представляет сохраненный ответ на запрос с заголовком Idempotency-Key.
+// Ключ уникален в пределах клиента; Status равен 0, пока запрос обрабатывается
+type IdempotencyRecord struct {
+	Subject     string
+	Key         string
+	RequestHash string
+	Status      int
+	Response    []byte
+	// Headers заголовки ответа, которые возвращаются при повторе запроса
+	Headers   map[string]string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// Completed сообщает, сохранен ли ответ на запрос
+func (r IdempotencyRecord) Completed() bool {
+	return r.Status != 0
+}
+
+// TransferStatus представляет статус перемещения товаров между складами
+type TransferStatus string
+
+// Статусы перемещения
+const (
+	TransferPending   TransferStatus = "pending"
+	TransferInTransit TransferStatus = "in_transit"
+	TransferReceived  TransferStatus = "received"
+	TransferCancelled TransferStatus = "cancelled"
+)
+
+// Transfer представляет перемещение товаров между складами
+type Transfer struct {
+	ID                     uuid.UUID         `json:"id"`
+	SourceWarehouseID      uuid.UUID         `json:"source_warehouse_id"`
+	DestinationWarehouseID uuid.UUID         `json:"destination_warehouse_id"`
+	Status                 TransferStatus    `json:"status"`
+	Items                  []ProductPurchase `json:"items"`
+	CreatedAt              time.Time         `json:"created_at"`
+	DispatchedAt           *time.Time        `json:"dispatched_at,omitempty"`
+	ReceivedAt             *time.Time        `json:"received_at,omitempty"`
+	CancelledAt            *time.Time        `json:"cancelled_at,omitempty"`
+}
+
+// TransferRequest представляет запрос на создание перемещения
+type TransferRequest struct {
+	SourceWarehouseID      uuid.UUID         `json:"source_warehouse_id" validate:"required"`
+	DestinationWarehouseID uuid.UUID         `json:"destination_warehouse_id" validate:"required,nefield=SourceWarehouseID"`
+	Items                  []ProductPurchase `json:"items" validate:"required,min=1,dive"`
+	// Immediate выполняет отгрузку и приемку в одной транзакции
+	Immediate bool `json:"immediate,omitempty"`
+}
+
+// SalesGranularity представляет шаг группировки продаж по времени
+type SalesGranularity string
+
+// Шаги группировки продаж
+const (
+	GranularityDay   SalesGranularity = "day"
+	GranularityWeek  SalesGranularity = "week"
+	GranularityMonth SalesGranularity = "month"
+)
+
+// SalesQuery представляет параметры выборки продаж за период.
+// Период задается полуинтервалом [From, To)
+type SalesQuery struct {
+	WarehouseID *uuid.UUID
+	ProductID   *uuid.UUID
+	From        time.Time
+	To          time.Time
+	Granularity SalesGranularity
+}
+
+// SalesBucket представляет продажи за один интервал времени
+type SalesBucket struct {
+	PeriodStart time.Time `json:"period_start"`
+	Orders      int       `json:"orders"`
+	Units       int       `json:"units"`
+	Revenue     float64   `json:"revenue"`
+}
+
+// SalesSeries представляет временной ряд продаж
+type SalesSeries struct {
+	Granularity  SalesGranularity `json:"granularity"`
+	From         time.Time        `json:"from"`
+	To           time.Time        `json:"to"`
+	TotalUnits   int              `json:"total_units"`
+	TotalRevenue float64          `json:"total_revenue"`
+	Buckets      []SalesBucket    `json:"buckets"`
+}
+
+// User представляет пользователя API, которому принадлежат API ключи
+type User struct {
+	ID         uuid.UUID   `json:"id"`
+	Username   string      `json:"username"`
+	Role       Role        `json:"role"`
+	Warehouses []uuid.UUID `json:"warehouses"`
+	CreatedAt  time.Time   `json:"created_at"`
+}
+
+// APIKey представляет долгоживущий ключ доступа для машинных клиентов.
+// Сам ключ не хранится, хранится только его хеш; Prefix позволяет узнать ключ в списке
+type APIKey struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	CreatedAt time.Time  `json:"created_at"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APIKeyRequest представляет запрос на выпуск API ключа
+type APIKeyRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+	// ExpiresIn время жизни ключа в секундах; 0 - бессрочный ключ
+	ExpiresIn int `json:"expires_in,omitempty" validate:"gte=0"`
+}
+
+// IssuedAPIKey представляет только что выпущенный API ключ.
+// Key возвращается один раз и больше не может быть получен
+type IssuedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+// AuthMethod представляет способ аутентификации
+type AuthMethod string
+
+// Способы аутентификации
+const (
+	AuthMethodNone   AuthMethod = "none"
+	AuthMethodJWT    AuthMethod = "jwt"
+	AuthMethodAPIKey AuthMethod = "api_key"
+)
+
+// Principal представляет аутентифицированного клиента
+type Principal struct {
+	// Subject имя клиента: claim sub токена или имя владельца API ключа
+	Subject string     `json:"subject"`
+	Method  AuthMethod `json:"method"`
+	Role    Role       `json:"role"`
+	// Warehouses склады, к которым ограничен доступ роли с областью действия склада
+	Warehouses []uuid.UUID `json:"warehouses,omitempty"`
+	// APIKeyID заполняется при аутентификации API ключом
+	APIKeyID *uuid.UUID `json:"api_key_id,omitempty"`
+}
+
+// Can проверяет, есть ли у клиента разрешение
+func (p Principal) Can(permission Permission) bool {
+	return p.Role.Has(permission)
+}
+
+// Scoped сообщает, ограничен ли доступ клиента его складами
+func (p Principal) Scoped() bool {
+	return p.Role.Scoped()
+}
+
+// CanAccessWarehouse проверяет, может ли клиент работать со складом
+func (p Principal) CanAccessWarehouse(warehouseID uuid.UUID) bool {
+	return !p.Scoped() || slices.Contains(p.Warehouses, warehouseID)
+}
+
+// Role представляет роль пользователя
+type Role string
+
+// Роли пользователей
+const (
+	// RoleAdmin имеет все разрешения на всех складах
+	RoleAdmin Role = "admin"
+	// RoleMerchandiser управляет каталогом товаров и скидками
+	RoleMerchandiser Role = "merchandiser"
+	// RoleClerk продает товары и просматривает остатки только на своих складах
+	RoleClerk Role = "clerk"
+)
+
+// Permission представляет разрешение на группу операций API
+type Permission string
+
+// Разрешения
+const (
+	PermissionWarehousesRead  Permission = "warehouses:read"
+	PermissionWarehousesWrite Permission = "warehouses:write"
+	PermissionProductsRead    Permission = "products:read"
+	PermissionProductsWrite   Permission = "products:write"
+	PermissionInventoryRead   Permission = "inventory:read"
+	PermissionInventoryWrite  Permission = "inventory:write"
+	PermissionDiscountsWrite  Permission = "discounts:write"
+	PermissionSalesRead       Permission = "sales:read"
+	PermissionSalesWrite      Permission = "sales:write"
+	PermissionTransfersRead   Permission = "transfers:read"
+	PermissionTransfersWrite  Permission = "transfers:write"
+	PermissionAnalyticsRead   Permission = "analytics:read"
+	PermissionAuditRead       Permission = "audit:read"
+)
+
+// roleDefinition описывает разрешения роли и область ее действия
+type roleDefinition struct {
+	permissions []Permission
+	// scoped ограничивает доступ складами пользователя
+	scoped bool
+}
+
+// roles разрешения ролей. Пользователь без роли аутентифицирован,
+// но может работать только со своими API ключами
+var roles = map[Role]roleDefinition{
+	RoleAdmin: {
+		permissions: []Permission{
+			PermissionWarehousesRead, PermissionWarehousesWrite,
+			PermissionProductsRead, PermissionProductsWrite,
+			PermissionInventoryRead, PermissionInventoryWrite,
+			PermissionDiscountsWrite,
+			PermissionSalesRead, PermissionSalesWrite,
+			PermissionTransfersRead, PermissionTransfersWrite,
+			PermissionAnalyticsRead,
+			PermissionAuditRead,
+		},
+	},
+	RoleMerchandiser: {
+		permissions: []Permission{
+			PermissionWarehousesRead,
+			PermissionProductsRead, PermissionProductsWrite,
+			PermissionInventoryRead,
+			PermissionDiscountsWrite,
+			PermissionSalesRead,
+			PermissionAnalyticsRead,
+		},
+	},
+	RoleClerk: {
+		permissions: []Permission{
+			PermissionWarehousesRead,
+			PermissionProductsRead,
+			PermissionInventoryRead,
+			PermissionSalesRead, PermissionSalesWrite,
+		},
+		scoped: true,
+	},
+}
+
+// Valid проверяет, известна ли роль. Пустая роль не считается известной:
+// вызывающий код сам решает, допустим ли пользователь без роли
+func (r Role) Valid() bool {
+	_, ok := roles[r]
+	return ok
+}
+
+// Has проверяет, входит ли разрешение в роль
+func (r Role) Has(permission Permission) bool {
+	return slices.Contains(roles[r].permissions, permission)
+}
+
+// Scoped сообщает, ограничена ли роль складами пользователя
+func (r Role) Scoped() bool {
+	return roles[r].scoped
+}
+
+// HealthStatus результат проверки зависимости
+type HealthStatus string
+
+// Результаты проверки зависимости
+const (
+	HealthOK   HealthStatus = "ok"
+	HealthFail HealthStatus = "fail"
+)
+
+// HealthCheck результат проверки одной зависимости сервиса
+type HealthCheck struct {
+	Name    string       `json:"name"`
+	Status  HealthStatus `json:"status"`
+	Error   string       `json:"error,omitempty"`
+	Details interface{}  `json:"details,omitempty"`
+}
+
+// ReadinessStatus готовность сервиса принимать запросы
+type ReadinessStatus string
+
+// Состояния готовности
+const (
+	Ready    ReadinessStatus = "ready"
+	NotReady ReadinessStatus = "not_ready"
+)
+
+// Readiness представляет результат проверки готовности: сервис готов, если пройдены все проверки
+type Readiness struct {
+	Status ReadinessStatus `json:"status"`
+	Checks []HealthCheck   `json:"checks"`
 }
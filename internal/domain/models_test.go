@@ -0,0 +1,101 @@
+package domain
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// allPermissions все разрешения API
+var allPermissions = []Permission{
+	PermissionWarehousesRead, PermissionWarehousesWrite,
+	PermissionProductsRead, PermissionProductsWrite,
+	PermissionInventoryRead, PermissionInventoryWrite,
+	PermissionDiscountsWrite,
+	PermissionSalesRead, PermissionSalesWrite,
+	PermissionTransfersRead, PermissionTransfersWrite,
+	PermissionAnalyticsRead,
+	PermissionAuditRead,
+}
+
+func TestRolePermissions(t *testing.T) {
+	// Таблица повторяет матрицу ролей из README: изменение прав роли
+	// должно сопровождаться изменением документации
+	tests := []struct {
+		role    Role
+		granted []Permission
+	}{
+		{RoleAdmin, allPermissions},
+		{RoleMerchandiser, []Permission{
+			PermissionWarehousesRead,
+			PermissionProductsRead, PermissionProductsWrite,
+			PermissionInventoryRead,
+			PermissionDiscountsWrite,
+			PermissionSalesRead,
+			PermissionAnalyticsRead,
+		}},
+		{RoleClerk, []Permission{
+			PermissionWarehousesRead,
+			PermissionProductsRead,
+			PermissionInventoryRead,
+			PermissionSalesRead, PermissionSalesWrite,
+		}},
+		{"", nil},
+		{"root", nil},
+	}
+	for _, tt := range tests {
+		granted := make(map[Permission]bool, len(tt.granted))
+		for _, p := range tt.granted {
+			granted[p] = true
+		}
+		for _, p := range allPermissions {
+			if got := tt.role.Has(p); got != granted[p] {
+				t.Errorf("роль %q, разрешение %s: %v, ожидалось %v", tt.role, p, got, granted[p])
+			}
+		}
+	}
+}
+
+func TestRoleValid(t *testing.T) {
+	tests := []struct {
+		role Role
+		want bool
+	}{
+		{RoleAdmin, true},
+		{RoleMerchandiser, true},
+		{RoleClerk, true},
+		{"", false},
+		{"Admin", false},
+		{"root", false},
+	}
+	for _, tt := range tests {
+		if got := tt.role.Valid(); got != tt.want {
+			t.Errorf("Role(%q).Valid() = %v, ожидалось %v", tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestPrincipalCanAccessWarehouse(t *testing.T) {
+	own, other := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		principal Principal
+		want      bool
+	}{
+		{"admin без складов", Principal{Role: RoleAdmin}, true},
+		{"merchandiser без складов", Principal{Role: RoleMerchandiser}, true},
+		{"clerk с другим складом", Principal{Role: RoleClerk, Warehouses: []uuid.UUID{own}}, false},
+		{"clerk без складов", Principal{Role: RoleClerk}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.principal.CanAccessWarehouse(other); got != tt.want {
+			t.Errorf("%s: %v, ожидалось %v", tt.name, got, tt.want)
+		}
+	}
+
+	clerk := Principal{Role: RoleClerk, Warehouses: []uuid.UUID{own}}
+	if !clerk.CanAccessWarehouse(own) {
+		t.Error("clerk не имеет доступа к своему складу")
+	}
+}
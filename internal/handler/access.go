@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// require пропускает запрос, только если у клиента есть разрешение permission
+func (h *Handler) require(permission domain.Permission, next http.HandlerFunc) http.Handler {
+	return h.requireWarehouse(permission, "", next)
+}
+
+// requireWarehouse пропускает запрос, только если у клиента есть разрешение permission
+// и доступ к складу из параметра пути param. Некорректный ID склада не проверяется:
+// его отклонит обработчик с ошибкой 400
+func (h *Handler) requireWarehouse(permission domain.Permission, param string, next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal := principalFrom(r.Context())
+		if !principal.Can(permission) {
+			h.forbid(w, r, "Недостаточно прав для выполнения операции", map[string]interface{}{
+				"role":       principal.Role,
+				"permission": permission,
+			})
+			return
+		}
+
+		if param != "" {
+			if warehouseID, err := uuid.Parse(r.PathValue(param)); err == nil && !h.authorizeWarehouse(w, r, warehouseID) {
+				return
+			}
+		}
+
+		next(w, r)
+	})
+}
+
+// authorizeWarehouse проверяет, что клиент может работать хотя бы с одним из складов.
+// При отказе записывает ответ 403 и возвращает false
+func (h *Handler) authorizeWarehouse(w http.ResponseWriter, r *http.Request, warehouseIDs ...uuid.UUID) bool {
+	principal := principalFrom(r.Context())
+	for _, id := range warehouseIDs {
+		if principal.CanAccessWarehouse(id) {
+			return true
+		}
+	}
+
+	h.forbid(w, r, "Нет доступа к складу", map[string]interface{}{
+		"warehouse_ids": warehouseIDs,
+	})
+	return false
+}
+
+// authorizeWarehouseFilter проверяет доступ к складу из необязательного фильтра.
+// Без фильтра операция затрагивает все склады и доступна только клиентам без ограничения по складам
+func (h *Handler) authorizeWarehouseFilter(w http.ResponseWriter, r *http.Request, warehouseID *uuid.UUID) bool {
+	if warehouseID == nil {
+		return h.authorizeAllWarehouses(w, r)
+	}
+	return h.authorizeWarehouse(w, r, *warehouseID)
+}
+
+// authorizeAllWarehouses проверяет, что доступ клиента не ограничен складами.
+// Используется для операций, затрагивающих все склады сразу
+func (h *Handler) authorizeAllWarehouses(w http.ResponseWriter, r *http.Request) bool {
+	if !principalFrom(r.Context()).Scoped() {
+		return true
+	}
+
+	h.forbid(w, r, "Операция доступна только без ограничения по складам", nil)
+	return false
+}
+
+// forbid записывает ответ 403
+func (h *Handler) forbid(w http.ResponseWriter, r *http.Request, message string, details interface{}) {
+	principal := principalFrom(r.Context())
+	h.logger.WithRequestID(r.Context()).Warn("Доступ запрещен",
+		zap.String("subject", principal.Subject),
+		zap.String("role", string(principal.Role)),
+		zap.String("method", r.Method),
+		zap.String("path", r.URL.Path),
+	)
+
+	writeErrorDetails(w, r, http.StatusForbidden, CodeForbidden, message, details)
+}
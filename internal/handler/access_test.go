@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+func TestWarehouseScope(t *testing.T) {
+	a := newTestAPI(t)
+	own, other := a.seedWarehouse(), a.seedWarehouse()
+	product := a.seedProduct(`{}`)
+	a.seedInventory(own, product, 10)
+	a.seedInventory(other, product, 10)
+
+	// Заказ и резерв на чужом складе создает администратор
+	purchase := fmt.Sprintf(`{"warehouse_id": %q, "products": [{"product_id": %q, "quantity": 1}]}`, other.ID, product.ID)
+	w := a.do(http.MethodPost, "/api/warehouses/purchase", purchase, nil)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("покупка: статус %d: %s", w.Code, w.Body.String())
+	}
+	order := decodeBody[domain.Order](t, w)
+
+	reserve := fmt.Sprintf(`{"warehouse_id": %q, "products": [{"product_id": %q, "quantity": 1}], "reserve": true}`, other.ID, product.ID)
+	w = a.do(http.MethodPost, "/api/warehouses/calculate", reserve, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("резервирование: статус %d: %s", w.Code, w.Body.String())
+	}
+	reservation := decodeBody[domain.CalculationResult](t, w).Reservation
+	if reservation == nil {
+		t.Fatal("резерв не создан")
+	}
+
+	clerk := a.as(domain.RoleClerk, own.ID)
+	tests := []struct {
+		name, method, target, body string
+	}{
+		{"товары склада", http.MethodGet, fmt.Sprintf("/api/warehouses/%s/products", other.ID), ""},
+		{"товар на складе", http.MethodGet, fmt.Sprintf("/api/warehouses/%s/products/%s", other.ID, product.ID), ""},
+		{"движения товара", http.MethodGet, fmt.Sprintf("/api/warehouses/%s/products/%s/movements", other.ID, product.ID), ""},
+		{"расчет стоимости", http.MethodPost, "/api/warehouses/calculate", purchase},
+		{"покупка", http.MethodPost, "/api/warehouses/purchase", purchase},
+		{"заказы склада", http.MethodGet, "/api/orders?warehouse_id=" + other.ID.String(), ""},
+		{"заказы всех складов", http.MethodGet, "/api/orders", ""},
+		{"заказ", http.MethodGet, "/api/orders/" + order.ID.String(), ""},
+		{"резерв", http.MethodGet, "/api/reservations/" + reservation.ID.String(), ""},
+		{"снятие резерва", http.MethodDelete, "/api/reservations/" + reservation.ID.String(), ""},
+		{"перемещения склада", http.MethodGet, fmt.Sprintf("/api/warehouses/%s/transfers", other.ID), ""},
+		{"аналитика склада", http.MethodGet, "/api/analytics/warehouses/" + other.ID.String(), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertError(t, a.do(tt.method, tt.target, tt.body, clerk), http.StatusForbidden, CodeForbidden)
+		})
+	}
+
+	// Свой склад остается доступен
+	for _, target := range []string{
+		fmt.Sprintf("/api/warehouses/%s/products", own.ID),
+		"/api/orders?warehouse_id=" + own.ID.String(),
+	} {
+		if w := a.do(http.MethodGet, target, "", clerk); w.Code != http.StatusOK {
+			t.Errorf("%s: статус %d, ожидался 200: %s", target, w.Code, w.Body.String())
+		}
+	}
+
+	// Резерв на чужом складе не снят
+	if w := a.do(http.MethodGet, "/api/reservations/"+reservation.ID.String(), "", nil); decodeBody[domain.Reservation](t, w).Status != domain.ReservationActive {
+		t.Errorf("резерв изменен после отказа в доступе: %s", w.Body.String())
+	}
+}
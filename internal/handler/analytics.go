@@ -1,8 +1,10 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/danya1733/practiceGO/internal/domain"
 	"github.com/google/uuid"
@@ -18,14 +20,14 @@ func (h *Handler) GetWarehouseAnalytics(w http.ResponseWriter, r *http.Request)
 	id, err := uuid.Parse(idStr)
 	if err != nil {
 		logger.Error("Некорректный формат ID склада", zap.Error(err))
-		writeError(w, "Некорректный формат ID склада", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID склада")
 		return
 	}
 
-	analytics, totalSum, err := h.analyticsRepo.GetWarehouseAnalytics(ctx, id)
+	analytics, totalSum, err := h.analytics.WarehouseAnalytics(ctx, id)
 	if err != nil {
 		logger.Error("Ошибка при получении аналитики по складу", zap.Error(err))
-		writeError(w, "Ошибка при получении аналитики по складу", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при получении аналитики по складу")
 		return
 	}
 
@@ -40,7 +42,7 @@ func (h *Handler) GetWarehouseAnalytics(w http.ResponseWriter, r *http.Request)
 	writeJSON(w, http.StatusOK, result)
 }
 
-// GetTopWarehouses возвращает топ складов по выручке
+// GetTopWarehouses возвращает топ складов по выручке за все время или за период from/to
 func (h *Handler) GetTopWarehouses(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := h.logger.WithRequestID(ctx)
@@ -54,12 +56,154 @@ func (h *Handler) GetTopWarehouses(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	warehouses, err := h.analyticsRepo.GetTopWarehouses(ctx, limit)
+	from, to, err := parsePeriod(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	if !h.authorizeAllWarehouses(w, r) {
+		return
+	}
+
+	warehouses, err := h.analytics.TopWarehouses(ctx, limit, from, to)
 	if err != nil {
 		logger.Error("Ошибка при получении топ складов", zap.Error(err))
-		writeError(w, "Ошибка при получении топ складов", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при получении топ складов")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, warehouses)
 }
+
+// maxSalesBuckets ограничивает количество интервалов во временном ряду продаж
+const maxSalesBuckets = 1000
+
+// GetSalesSeries возвращает временной ряд продаж
+// @Summary Получить продажи по периодам
+// @Description Возвращает выручку, количество проданных единиц и заказов, сгруппированные по дням, неделям или месяцам (UTC)
+// @Tags analytics
+// @Produce json
+// @Param from query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода, не включительно (RFC3339 или YYYY-MM-DD); по умолчанию текущее время"
+// @Param granularity query string false "Шаг группировки: day, week, month" default(day)
+// @Param warehouse_id query string false "ID склада"
+// @Param product_id query string false "ID товара"
+// @Success 200 {object} domain.SalesSeries
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/analytics/sales [get]
+func (h *Handler) GetSalesSeries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	query := r.URL.Query()
+	q := domain.SalesQuery{
+		Granularity: domain.GranularityDay,
+	}
+
+	if granularity := query.Get("granularity"); granularity != "" {
+		q.Granularity = domain.SalesGranularity(granularity)
+	}
+
+	// Период по умолчанию: 30 дней, 12 недель или 12 месяцев до текущего момента
+	var defaultSpan time.Duration
+	switch q.Granularity {
+	case domain.GranularityDay:
+		defaultSpan = 30 * 24 * time.Hour
+	case domain.GranularityWeek:
+		defaultSpan = 12 * 7 * 24 * time.Hour
+	case domain.GranularityMonth:
+		defaultSpan = 365 * 24 * time.Hour
+	default:
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Параметр granularity должен быть day, week или month")
+		return
+	}
+
+	from, to, err := parsePeriod(r)
+	if err != nil {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, err.Error())
+		return
+	}
+
+	q.To = time.Now().UTC()
+	if to != nil {
+		q.To = *to
+	}
+	q.From = q.To.Add(-defaultSpan)
+	if from != nil {
+		q.From = *from
+	}
+
+	if !q.From.Before(q.To) {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Параметр from должен быть меньше to")
+		return
+	}
+
+	// Недели и месяцы оцениваем снизу, этого достаточно для защиты от слишком длинных рядов
+	bucketSize := 24 * time.Hour
+	switch q.Granularity {
+	case domain.GranularityWeek:
+		bucketSize = 7 * 24 * time.Hour
+	case domain.GranularityMonth:
+		bucketSize = 28 * 24 * time.Hour
+	}
+	if q.To.Sub(q.From)/bucketSize > maxSalesBuckets {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Слишком большой период для выбранного шага группировки")
+		return
+	}
+
+	if warehouseIDStr := query.Get("warehouse_id"); warehouseIDStr != "" {
+		id, err := uuid.Parse(warehouseIDStr)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID склада")
+			return
+		}
+		q.WarehouseID = &id
+	}
+
+	if productIDStr := query.Get("product_id"); productIDStr != "" {
+		id, err := uuid.Parse(productIDStr)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID товара")
+			return
+		}
+		q.ProductID = &id
+	}
+
+	if !h.authorizeWarehouseFilter(w, r, q.WarehouseID) {
+		return
+	}
+
+	series, err := h.analytics.SalesSeries(ctx, q)
+	if err != nil {
+		logger.Error("Ошибка при получении продаж по периодам", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при получении продаж по периодам")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, series)
+}
+
+// parsePeriod читает необязательные параметры from и to запроса
+func parsePeriod(r *http.Request) (from, to *time.Time, err error) {
+	query := r.URL.Query()
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		t, err := parseTime(fromStr)
+		if err != nil {
+			return nil, nil, errors.New("Некорректный формат параметра from")
+		}
+		from = &t
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		t, err := parseTime(toStr)
+		if err != nil {
+			return nil, nil, errors.New("Некорректный формат параметра to")
+		}
+		to = &t
+	}
+
+	return from, to, nil
+}
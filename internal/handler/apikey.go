@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetCurrentPrincipal возвращает клиента, выполняющего запрос
+// @Summary Текущий клиент
+// @Description Возвращает субъект и способ аутентификации текущего запроса
+// @Tags auth
+// @Produce json
+// @Success 200 {object} domain.Principal
+// @Failure 401 {object} ErrorResponse
+// @Router /api/auth/me [get]
+func (h *Handler) GetCurrentPrincipal(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, principalFrom(r.Context()))
+}
+
+// GetAPIKeys возвращает API ключи текущего клиента
+// @Summary Получить API ключи
+// @Description Возвращает API ключи текущего клиента, включая отозванные. Сами ключи не возвращаются, только их префиксы
+// @Tags auth
+// @Produce json
+// @Success 200 {array} domain.APIKey
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/api-keys [get]
+func (h *Handler) GetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	keys, err := h.auth.ListAPIKeys(ctx, principalFrom(ctx))
+	if err != nil {
+		logger.Error("Ошибка при получении API ключей", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при получении API ключей")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, keys)
+}
+
+// CreateAPIKey выпускает API ключ для текущего клиента
+// @Summary Выпустить API ключ
+// @Description Выпускает долгоживущий API ключ для машинных клиентов. Ключ возвращается только в этом ответе, в базе хранится его хеш
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body domain.APIKeyRequest true "Параметры ключа"
+// @Success 201 {object} domain.IssuedAPIKey
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/api-keys [post]
+func (h *Handler) CreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	var request domain.APIKeyRequest
+	if !h.decodeRequest(w, r, &request) {
+		return
+	}
+
+	issued, err := h.auth.IssueAPIKey(ctx, principalFrom(ctx), request)
+	if err != nil {
+		logger.Error("Ошибка при выпуске API ключа", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при выпуске API ключа")
+		return
+	}
+
+	logger.Info("Выпущен API ключ",
+		zap.String("api_key_id", issued.ID.String()),
+		zap.String("prefix", issued.Prefix),
+	)
+
+	writeJSON(w, http.StatusCreated, issued)
+}
+
+// RevokeAPIKey отзывает API ключ текущего клиента
+// @Summary Отозвать API ключ
+// @Description Отзывает API ключ; запросы с ним перестают проходить аутентификацию. Повторный отзыв не меняет время отзыва
+// @Tags auth
+// @Produce json
+// @Param id path string true "ID API ключа"
+// @Success 200 {object} domain.APIKey
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/api-keys/{id} [delete]
+func (h *Handler) RevokeAPIKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		logger.Error("Некорректный формат ID API ключа", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID API ключа")
+		return
+	}
+
+	key, err := h.auth.RevokeAPIKey(ctx, principalFrom(ctx), id)
+	if err != nil {
+		logger.Error("Ошибка при отзыве API ключа", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при отзыве API ключа")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, key)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetAuditLog возвращает журнал аудита
+// @Summary Получить журнал аудита
+// @Description Возвращает записи об изменениях сущностей (кто, в рамках какого запроса, состояние до и после), начиная с последних
+// @Tags audit
+// @Produce json
+// @Param entity query string false "Сущность: warehouses, products, inventory, orders, reservations, transfers, api_keys"
+// @Param entity_id query string false "ID сущности"
+// @Param action query string false "Действие: create, update, dispatch, receive, cancel, release, revoke, rebuild"
+// @Param actor query string false "Клиент, выполнивший изменение"
+// @Param request_id query string false "ID запроса"
+// @Param from query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода, не включительно (RFC3339 или YYYY-MM-DD)"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество записей на странице" default(50)
+// @Success 200 {array} domain.AuditEntry
+// @Failure 400 {object} ErrorResponse
+// @Failure 401 {object} ErrorResponse
+// @Failure 403 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/audit [get]
+func (h *Handler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	query := r.URL.Query()
+	filter := domain.AuditFilter{
+		Entity:    query.Get("entity"),
+		Actor:     query.Get("actor"),
+		RequestID: query.Get("request_id"),
+		Page:      1,
+		Limit:     50,
+	}
+
+	if entityIDStr := query.Get("entity_id"); entityIDStr != "" {
+		entityID, err := uuid.Parse(entityIDStr)
+		if err != nil {
+			logger.Error("Некорректный формат ID сущности", zap.Error(err))
+			writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID сущности")
+			return
+		}
+		filter.EntityID = &entityID
+	}
+
+	if actionStr := query.Get("action"); actionStr != "" {
+		action := domain.AuditAction(actionStr)
+		switch action {
+		case domain.AuditCreate, domain.AuditUpdate, domain.AuditDispatch, domain.AuditReceive,
+			domain.AuditCancel, domain.AuditRelease, domain.AuditRevoke, domain.AuditRebuild:
+			filter.Action = action
+		default:
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректное действие: "+actionStr)
+			return
+		}
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := parseTime(fromStr)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный формат параметра from")
+			return
+		}
+		filter.From = &from
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := parseTime(toStr)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный формат параметра to")
+			return
+		}
+		filter.To = &to
+	}
+
+	if pageStr := query.Get("page"); pageStr != "" {
+		pageVal, err := strconv.Atoi(pageStr)
+		if err == nil && pageVal > 0 {
+			filter.Page = pageVal
+		}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limitVal, err := strconv.Atoi(limitStr)
+		if err == nil && limitVal > 0 {
+			filter.Limit = limitVal
+		}
+	}
+
+	entries, err := h.audit.List(ctx, filter)
+	if err != nil {
+		logger.Error("Ошибка при получении журнала аудита", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при получении журнала аудита")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, entries)
+}
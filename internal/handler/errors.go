@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/danya1733/practiceGO/internal/service"
+	"github.com/danya1733/practiceGO/pkg/requestctx"
+)
+
+// ErrorCode машиночитаемый код ошибки API.
+// Коды стабильны: клиенты могут опираться на них вместо текста сообщения
+type ErrorCode string
+
+// Каталог кодов ошибок
+const (
+	// Ошибки запроса
+	CodeInvalidJSON      ErrorCode = "INVALID_JSON"
+	CodeInvalidID        ErrorCode = "INVALID_ID"
+	CodeInvalidParameter ErrorCode = "INVALID_PARAMETER"
+	CodeValidationFailed ErrorCode = "VALIDATION_FAILED"
+	CodeUnsupportedMedia ErrorCode = "UNSUPPORTED_MEDIA_TYPE"
+	CodeRequestTooLarge  ErrorCode = "REQUEST_TOO_LARGE"
+
+	// Ошибки условных запросов
+	CodePreconditionRequired ErrorCode = "PRECONDITION_REQUIRED"
+	CodeVersionMismatch      ErrorCode = "VERSION_MISMATCH"
+
+	// Ошибки доступа
+	CodeUnauthorized ErrorCode = "UNAUTHORIZED"
+	CodeForbidden    ErrorCode = "FORBIDDEN"
+
+	// Отсутствующие сущности
+	CodeNotFound            ErrorCode = "NOT_FOUND"
+	CodeWarehouseNotFound   ErrorCode = "WAREHOUSE_NOT_FOUND"
+	CodeProductNotFound     ErrorCode = "PRODUCT_NOT_FOUND"
+	CodeInventoryNotFound   ErrorCode = "INVENTORY_NOT_FOUND"
+	CodeOrderNotFound       ErrorCode = "ORDER_NOT_FOUND"
+	CodeReservationNotFound ErrorCode = "RESERVATION_NOT_FOUND"
+	CodeTransferNotFound    ErrorCode = "TRANSFER_NOT_FOUND"
+	CodeAPIKeyNotFound      ErrorCode = "API_KEY_NOT_FOUND"
+
+	// Конфликты и недопустимые состояния
+	CodeConflict             ErrorCode = "CONFLICT"
+	CodeInvalidReference     ErrorCode = "INVALID_REFERENCE"
+	CodeInsufficientStock    ErrorCode = "INSUFFICIENT_STOCK"
+	CodeBarcodeConflict      ErrorCode = "BARCODE_CONFLICT"
+	CodeInventoryConflict    ErrorCode = "INVENTORY_CONFLICT"
+	CodeReservationNotActive ErrorCode = "RESERVATION_NOT_ACTIVE"
+	CodeReservationMismatch  ErrorCode = "RESERVATION_MISMATCH"
+	CodeTransferInvalidState ErrorCode = "TRANSFER_INVALID_STATE"
+	CodeAuthDisabled         ErrorCode = "AUTH_DISABLED"
+
+	// Ошибки ключей идемпотентности
+	CodeIdempotencyKeyMismatch ErrorCode = "IDEMPOTENCY_KEY_MISMATCH"
+	CodeIdempotencyInProgress  ErrorCode = "IDEMPOTENCY_IN_PROGRESS"
+
+	// Внутренние ошибки
+	CodeInternal ErrorCode = "INTERNAL_ERROR"
+)
+
+// ErrorResponse единый формат ответа с ошибкой
+type ErrorResponse struct {
+	Code      ErrorCode   `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// writeError записывает ошибку в ответ в формате ErrorResponse
+func writeError(w http.ResponseWriter, r *http.Request, status int, code ErrorCode, message string) {
+	writeErrorDetails(w, r, status, code, message, nil)
+}
+
+// writeErrorDetails записывает ошибку с дополнительными данными
+func writeErrorDetails(w http.ResponseWriter, r *http.Request, status int, code ErrorCode, message string, details interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Code:      code,
+		Message:   message,
+		Details:   details,
+		RequestID: requestctx.RequestID(r.Context()),
+	})
+}
+
+// notFoundErrors коды и сообщения для отсутствующих сущностей
+var notFoundErrors = map[string]struct {
+	code    ErrorCode
+	message string
+}{
+	repository.EntityWarehouse:   {CodeWarehouseNotFound, "Склад не найден"},
+	repository.EntityProduct:     {CodeProductNotFound, "Товар не найден"},
+	repository.EntityInventory:   {CodeInventoryNotFound, "Товар не найден на складе"},
+	repository.EntityOrder:       {CodeOrderNotFound, "Заказ не найден"},
+	repository.EntityReservation: {CodeReservationNotFound, "Резерв не найден"},
+	repository.EntityTransfer:    {CodeTransferNotFound, "Перемещение не найдено"},
+	repository.EntityAPIKey:      {CodeAPIKeyNotFound, "API ключ не найден"},
+}
+
+// referenceCodes коды ошибок для ссылок на несуществующие записи по имени поля
+var referenceCodes = map[string]ErrorCode{
+	"warehouse_id":             CodeWarehouseNotFound,
+	"source_warehouse_id":      CodeWarehouseNotFound,
+	"destination_warehouse_id": CodeWarehouseNotFound,
+	"product_id":               CodeProductNotFound,
+}
+
+// writeServiceError записывает ошибку, полученную от сервиса.
+// Известные ошибки сопоставляются со своими статусами и кодами:
+// отсутствующая запись - 404, нарушение уникальности - 409,
+// устаревшая версия записи - 412, ссылка на несуществующую запись - 422. Остальные ошибки считаются
+// внутренними и отдаются с сообщением fallback
+func writeServiceError(w http.ResponseWriter, r *http.Request, err error, fallback string) {
+	var repoErr *repository.Error
+	var stockErr *repository.InsufficientStockError
+	var validationErr *service.ValidationError
+	switch {
+	case errors.Is(err, service.ErrUnauthenticated):
+		writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Требуется аутентификация")
+	case errors.Is(err, service.ErrAuthDisabled):
+		writeError(w, r, http.StatusConflict, CodeAuthDisabled, "Аутентификация отключена, API ключи недоступны")
+	case errors.As(err, &validationErr):
+		writeError(w, r, http.StatusBadRequest, CodeValidationFailed, validationErr.Message)
+	case errors.As(err, &stockErr):
+		writeErrorDetails(w, r, http.StatusBadRequest, CodeInsufficientStock, "Недостаточное количество товара на складе",
+			map[string]interface{}{
+				"warehouse_id": stockErr.WarehouseID,
+				"product_id":   stockErr.ProductID,
+				"available":    stockErr.Available,
+				"requested":    stockErr.Requested,
+			})
+	case errors.Is(err, repository.ErrInventoryNotFound):
+		writeError(w, r, http.StatusNotFound, CodeInventoryNotFound, err.Error())
+	case errors.Is(err, repository.ErrReservationNotFound):
+		writeError(w, r, http.StatusNotFound, CodeReservationNotFound, err.Error())
+	case errors.Is(err, repository.ErrReservationMismatch):
+		writeError(w, r, http.StatusBadRequest, CodeReservationMismatch, err.Error())
+	case errors.Is(err, repository.ErrReservationNotActive):
+		writeError(w, r, http.StatusConflict, CodeReservationNotActive, err.Error())
+	case errors.Is(err, repository.ErrTransferState):
+		writeError(w, r, http.StatusConflict, CodeTransferInvalidState, err.Error())
+	case errors.As(err, &repoErr) && errors.Is(err, repository.ErrNotFound):
+		nf, ok := notFoundErrors[repoErr.Entity]
+		if !ok {
+			nf.code, nf.message = CodeNotFound, "Запись не найдена"
+		}
+		writeError(w, r, http.StatusNotFound, nf.code, nf.message)
+	case errors.As(err, &repoErr) && errors.Is(err, repository.ErrVersionMismatch):
+		writeErrorDetails(w, r, http.StatusPreconditionFailed, CodeVersionMismatch,
+			"Запись изменилась после получения, получите актуальную версию",
+			map[string]string{"entity": repoErr.Entity})
+	case errors.As(err, &repoErr) && errors.Is(err, repository.ErrConflict):
+		code := CodeConflict
+		switch {
+		case repoErr.Entity == repository.EntityProduct && repoErr.Field == "barcode":
+			code = CodeBarcodeConflict
+		case repoErr.Entity == repository.EntityInventory:
+			code = CodeInventoryConflict
+		}
+		writeErrorDetails(w, r, http.StatusConflict, code, "Запись с такими данными уже существует",
+			map[string]string{"field": repoErr.Field})
+	case errors.As(err, &repoErr) && errors.Is(err, repository.ErrInvalidReference):
+		code, ok := referenceCodes[repoErr.Field]
+		if !ok {
+			code = CodeInvalidReference
+		}
+		writeErrorDetails(w, r, http.StatusUnprocessableEntity, code, "Ссылка на несуществующую запись",
+			map[string]string{"field": repoErr.Field})
+	default:
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, fallback)
+	}
+}
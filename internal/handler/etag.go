@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// setETag возвращает версию записи в заголовке ETag, например "3"
+func setETag(w http.ResponseWriter, version int) {
+	w.Header().Set("ETag", `"`+strconv.Itoa(version)+`"`)
+}
+
+// ifMatch возвращает версию записи из заголовка If-Match.
+// Заголовок обязателен для изменения записи: без него записывается ошибка 428,
+// а клиент должен сначала получить запись и ее ETag. Значение * разрешает изменение
+// любой версии, для него возвращается 0. При ошибке ответ уже записан и возвращается false
+func ifMatch(w http.ResponseWriter, r *http.Request) (int, bool) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if value == "" {
+		writeError(w, r, http.StatusPreconditionRequired, CodePreconditionRequired,
+			"Требуется заголовок If-Match с ETag записи")
+		return 0, false
+	}
+	if value == "*" {
+		return 0, true
+	}
+
+	// If-Match сравнивает ETag строго (RFC 9110, 13.1.1): слабый ETag W/"..." не совпадает
+	// ни с одной версией, поэтому запрос отклоняется как устаревший, а не как некорректный
+	if weak, ok := strings.CutPrefix(value, "W/"); ok && isQuoted(weak) {
+		writeErrorDetails(w, r, http.StatusPreconditionFailed, CodeVersionMismatch,
+			"Слабый ETag не подходит для If-Match, передайте ETag записи без W/",
+			map[string]string{"header": "If-Match"})
+		return 0, false
+	}
+
+	// Принимается один сильный ETag: списки не поддерживаются
+	tag, ok := strings.CutPrefix(value, `"`)
+	if ok {
+		tag, ok = strings.CutSuffix(tag, `"`)
+	}
+	version, err := strconv.Atoi(tag)
+	if !ok || err != nil || version <= 0 {
+		writeErrorDetails(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный заголовок If-Match",
+			map[string]string{"header": "If-Match"})
+		return 0, false
+	}
+
+	return version, true
+}
+
+// isQuoted проверяет, что значение - строка в кавычках без кавычек внутри
+func isQuoted(value string) bool {
+	return len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' &&
+		!strings.Contains(value[1:len(value)-1], `"`)
+}
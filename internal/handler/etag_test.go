@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		version int
+		status  int
+		code    ErrorCode
+	}{
+		{"версия", `"3"`, 3, http.StatusOK, ""},
+		{"версия с пробелами", ` "3" `, 3, http.StatusOK, ""},
+		{"любая версия", "*", 0, http.StatusOK, ""},
+		{"нет заголовка", "", 0, http.StatusPreconditionRequired, CodePreconditionRequired},
+		{"слабый ETag", `W/"3"`, 0, http.StatusPreconditionFailed, CodeVersionMismatch},
+		{"слабый ETag без кавычек", `W/3`, 0, http.StatusBadRequest, CodeInvalidParameter},
+		{"без кавычек", "3", 0, http.StatusBadRequest, CodeInvalidParameter},
+		{"не число", `"abc"`, 0, http.StatusBadRequest, CodeInvalidParameter},
+		{"нулевая версия", `"0"`, 0, http.StatusBadRequest, CodeInvalidParameter},
+		{"список", `"3", "4"`, 0, http.StatusBadRequest, CodeInvalidParameter},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/api/products/1", nil)
+			if tt.value != "" {
+				r.Header.Set("If-Match", tt.value)
+			}
+			w := httptest.NewRecorder()
+
+			version, ok := ifMatch(w, r)
+			if ok != (tt.status == http.StatusOK) || version != tt.version {
+				t.Fatalf("ifMatch(%q) = %d, %v; ожидалось %d", tt.value, version, ok, tt.version)
+			}
+			if !ok {
+				assertError(t, w, tt.status, tt.code)
+			}
+		})
+	}
+}
@@ -3,33 +3,61 @@ package handler
 import (
 	"net/http"
 
-	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/danya1733/practiceGO/internal/config"
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/metrics"
+	"github.com/danya1733/practiceGO/internal/service"
 	"github.com/danya1733/practiceGO/pkg/logger"
 )
 
-// Handler представляет обработчик HTTP запросов
+// Handler представляет обработчик HTTP запросов.
+// Обработчик только разбирает запросы и формирует ответы, бизнес-логика находится в сервисах
 type Handler struct {
-	warehouseRepo *repository.WarehouseRepository
-	productRepo   *repository.ProductRepository
-	inventoryRepo *repository.InventoryRepository
-	analyticsRepo *repository.AnalyticsRepository
-	logger        *logger.Logger
+	warehouses  *service.WarehouseService
+	products    *service.ProductService
+	inventory   *service.InventoryService
+	sales       *service.SalesService
+	transfers   *service.TransferService
+	analytics   *service.AnalyticsService
+	auth        *service.AuthService
+	audit       *service.AuditService
+	health      *service.HealthService
+	idempotency *service.IdempotencyService
+	metrics     *metrics.Metrics
+	logConfig   config.LogConfig
+	logger      *logger.Logger
 }
 
 // NewHandler создает новый обработчик HTTP запросов
 func NewHandler(
-	warehouseRepo *repository.WarehouseRepository,
-	productRepo *repository.ProductRepository,
-	inventoryRepo *repository.InventoryRepository,
-	analyticsRepo *repository.AnalyticsRepository,
+	warehouses *service.WarehouseService,
+	products *service.ProductService,
+	inventory *service.InventoryService,
+	sales *service.SalesService,
+	transfers *service.TransferService,
+	analytics *service.AnalyticsService,
+	auth *service.AuthService,
+	audit *service.AuditService,
+	health *service.HealthService,
+	idempotency *service.IdempotencyService,
+	metrics *metrics.Metrics,
+	logConfig config.LogConfig,
 	logger *logger.Logger,
 ) *Handler {
 	return &Handler{
-		warehouseRepo: warehouseRepo,
-		productRepo:   productRepo,
-		inventoryRepo: inventoryRepo,
-		analyticsRepo: analyticsRepo,
-		logger:        logger,
+		warehouses:  warehouses,
+		products:    products,
+		inventory:   inventory,
+		sales:       sales,
+		transfers:   transfers,
+		analytics:   analytics,
+		auth:        auth,
+		audit:       audit,
+		health:      health,
+		idempotency: idempotency,
+		metrics:     metrics,
+		logConfig:   logConfig,
+		logger:      logger,
 	}
 }
 
@@ -54,31 +82,68 @@ func (h *Handler) RegisterRoutes() http.Handler {
 		http.Redirect(w, r, "/swagger/", http.StatusMovedPermanently)
 	})
 
-	// Обработчик для проверки здоровья
-	mux.HandleFunc("GET /api/health", h.HealthCheck)
+	// Проверки живости и готовности; /api/health оставлен для совместимости и проверяет живость
+	mux.HandleFunc("GET /api/health", h.LivenessCheck)
+	mux.HandleFunc("GET /api/health/live", h.LivenessCheck)
+	mux.HandleFunc("GET /api/health/ready", h.ReadinessCheck)
+
+	// Маршруты API доступны клиентам с разрешениями, указанными при регистрации.
+	// Доступ к складам, переданным в теле или параметрах запроса, проверяют обработчики.
+	// Маршруты, обернутые в idempotent, поддерживают заголовок Idempotency-Key
 
 	// Маршруты для работы со складами
-	mux.HandleFunc("GET /api/warehouses", h.GetWarehouses)
-	mux.HandleFunc("POST /api/warehouses", h.CreateWarehouse)
+	mux.Handle("GET /api/warehouses", h.require(domain.PermissionWarehousesRead, h.GetWarehouses))
+	mux.Handle("POST /api/warehouses", h.require(domain.PermissionWarehousesWrite, h.idempotent(h.CreateWarehouse)))
 
 	// Маршруты для работы с товарами
-	mux.HandleFunc("GET /api/products", h.GetProducts)
-	mux.HandleFunc("POST /api/products", h.CreateProduct)
-	mux.HandleFunc("PUT /api/products/{id}", h.UpdateProduct)
+	mux.Handle("GET /api/products", h.require(domain.PermissionProductsRead, h.GetProducts))
+	mux.Handle("POST /api/products", h.require(domain.PermissionProductsWrite, h.idempotent(h.CreateProduct)))
+	mux.Handle("GET /api/products/facets", h.require(domain.PermissionProductsRead, h.GetProductFacets))
+	mux.Handle("GET /api/products/{id}", h.require(domain.PermissionProductsRead, h.GetProduct))
+	mux.Handle("PUT /api/products/{id}", h.require(domain.PermissionProductsWrite, h.UpdateProduct))
+	mux.Handle("PATCH /api/products/{id}", h.require(domain.PermissionProductsWrite, h.PatchProduct))
 
 	// Маршруты для работы с инвентаризацией
-	mux.HandleFunc("POST /api/inventory", h.CreateInventory)
-	mux.HandleFunc("PUT /api/inventory/quantity", h.UpdateInventoryQuantity)
-	mux.HandleFunc("PUT /api/inventory/discount", h.UpdateInventoryDiscount)
-	mux.HandleFunc("GET /api/warehouses/{id}/products", h.GetWarehouseProducts)
-	mux.HandleFunc("GET /api/warehouses/{warehouse_id}/products/{product_id}", h.GetWarehouseProduct)
-	mux.HandleFunc("POST /api/warehouses/calculate", h.CalculateProductsPrice)
-	mux.HandleFunc("POST /api/warehouses/purchase", h.PurchaseProducts)
+	mux.Handle("POST /api/inventory", h.require(domain.PermissionInventoryWrite, h.idempotent(h.CreateInventory)))
+	mux.Handle("PUT /api/inventory/quantity", h.require(domain.PermissionInventoryWrite, h.UpdateInventoryQuantity))
+	mux.Handle("PUT /api/inventory/discount", h.require(domain.PermissionDiscountsWrite, h.UpdateInventoryDiscount))
+	mux.Handle("GET /api/warehouses/{id}/products", h.requireWarehouse(domain.PermissionInventoryRead, "id", h.GetWarehouseProducts))
+	mux.Handle("GET /api/warehouses/{warehouse_id}/products/{product_id}", h.requireWarehouse(domain.PermissionInventoryRead, "warehouse_id", h.GetWarehouseProduct))
+	mux.Handle("GET /api/warehouses/{warehouse_id}/products/{product_id}/movements", h.requireWarehouse(domain.PermissionInventoryRead, "warehouse_id", h.GetInventoryMovements))
+	mux.Handle("POST /api/inventory/rebuild", h.require(domain.PermissionInventoryWrite, h.RebuildInventory))
+	mux.Handle("POST /api/warehouses/calculate", h.require(domain.PermissionSalesWrite, h.CalculateProductsPrice))
+	mux.Handle("POST /api/warehouses/purchase", h.require(domain.PermissionSalesWrite, h.idempotent(h.PurchaseProducts)))
+
+	// Маршруты для работы с перемещениями между складами
+	mux.Handle("POST /api/transfers", h.require(domain.PermissionTransfersWrite, h.CreateTransfer))
+	mux.Handle("GET /api/transfers/{id}", h.require(domain.PermissionTransfersRead, h.GetTransfer))
+	mux.Handle("POST /api/transfers/{id}/dispatch", h.require(domain.PermissionTransfersWrite, h.DispatchTransfer))
+	mux.Handle("POST /api/transfers/{id}/receive", h.require(domain.PermissionTransfersWrite, h.ReceiveTransfer))
+	mux.Handle("POST /api/transfers/{id}/cancel", h.require(domain.PermissionTransfersWrite, h.CancelTransfer))
+	mux.Handle("GET /api/warehouses/{id}/transfers", h.requireWarehouse(domain.PermissionTransfersRead, "id", h.GetWarehouseTransfers))
+
+	// Маршруты для работы с резервами
+	mux.Handle("GET /api/reservations/{id}", h.require(domain.PermissionSalesRead, h.GetReservation))
+	mux.Handle("DELETE /api/reservations/{id}", h.require(domain.PermissionSalesWrite, h.ReleaseReservation))
+
+	// Маршруты для работы с заказами
+	mux.Handle("GET /api/orders", h.require(domain.PermissionSalesRead, h.GetOrders))
+	mux.Handle("GET /api/orders/{id}", h.require(domain.PermissionSalesRead, h.GetOrder))
 
 	// Маршруты для работы с аналитикой
-	mux.HandleFunc("GET /api/analytics/warehouses/{id}", h.GetWarehouseAnalytics)
-	mux.HandleFunc("GET /api/analytics/warehouses/top", h.GetTopWarehouses)
+	mux.Handle("GET /api/analytics/warehouses/{id}", h.requireWarehouse(domain.PermissionAnalyticsRead, "id", h.GetWarehouseAnalytics))
+	mux.Handle("GET /api/analytics/warehouses/top", h.require(domain.PermissionAnalyticsRead, h.GetTopWarehouses))
+	mux.Handle("GET /api/analytics/sales", h.require(domain.PermissionAnalyticsRead, h.GetSalesSeries))
+
+	// Маршруты для работы с журналом аудита
+	mux.Handle("GET /api/audit", h.require(domain.PermissionAuditRead, h.GetAuditLog))
+
+	// Маршруты для работы с API ключами доступны любому аутентифицированному клиенту
+	mux.HandleFunc("GET /api/auth/me", h.GetCurrentPrincipal)
+	mux.HandleFunc("GET /api/api-keys", h.GetAPIKeys)
+	mux.HandleFunc("POST /api/api-keys", h.CreateAPIKey)
+	mux.HandleFunc("DELETE /api/api-keys/{id}", h.RevokeAPIKey)
 
-	// Применение middleware для логирования и обработки request_id
-	return h.requestIDMiddleware(h.loggingMiddleware(mux))
+	// Применение middleware для обработки request_id, трассировки, логирования, метрик и аутентификации
+	return h.requestIDMiddleware(h.tracingMiddleware(mux, h.loggingMiddleware(h.metricsMiddleware(mux, h.authMiddleware(mux)))))
 }
@@ -0,0 +1,192 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/auth"
+	"github.com/danya1733/practiceGO/internal/config"
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/metrics"
+	"github.com/danya1733/practiceGO/internal/repository/memory"
+	"github.com/danya1733/practiceGO/internal/service"
+	"github.com/danya1733/practiceGO/pkg/logger"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// testAuthConfig настройки аутентификации тестового API
+var testAuthConfig = config.AuthConfig{
+	Enabled:      true,
+	JWTAlgorithm: config.JWTAlgorithmHS256,
+	JWTSecret:    "test-secret",
+}
+
+// testAPI обработчик HTTP запросов поверх хранилища в памяти
+type testAPI struct {
+	t       *testing.T
+	handler http.Handler
+	store   *memory.Store
+}
+
+// newTestAPI собирает обработчик HTTP запросов поверх хранилища в памяти
+// с включенной аутентификацией по JWT
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+
+	return newTestAPIWithAuth(t, testAuthConfig)
+}
+
+// newTestAPIWithAuth собирает обработчик HTTP запросов поверх хранилища в памяти
+// с настройками аутентификации cfg
+func newTestAPIWithAuth(t *testing.T, cfg config.AuthConfig) *testAPI {
+	t.Helper()
+
+	verifier, err := auth.NewVerifier(cfg)
+	if err != nil {
+		t.Fatalf("создание проверки JWT: %v", err)
+	}
+
+	store := memory.NewStore()
+	var (
+		warehouses   = memory.NewWarehouseRepository(store)
+		products     = memory.NewProductRepository(store)
+		inventory    = memory.NewInventoryRepository(store)
+		reservations = memory.NewReservationRepository(store)
+	)
+	l := &logger.Logger{Logger: zap.NewNop()}
+	m := metrics.New()
+
+	h := NewHandler(
+		service.NewWarehouseService(warehouses),
+		service.NewProductService(products),
+		service.NewInventoryService(inventory, products, memory.NewStockMovementRepository(store)),
+		service.NewSalesService(inventory, products, reservations, memory.NewOrderRepository(store), m, config.ReservationConfig{
+			DefaultTTL: time.Minute,
+			MaxTTL:     time.Hour,
+		}),
+		service.NewTransferService(memory.NewTransferRepository(store)),
+		service.NewAnalyticsService(memory.NewAnalyticsRepository(store)),
+		service.NewAuthService(memory.NewAuthRepository(store), verifier, cfg.Enabled),
+		service.NewAuditService(memory.NewAuditRepository(store)),
+		service.NewHealthService(memory.NewHealthRepository(store)),
+		service.NewIdempotencyService(memory.NewIdempotencyRepository(store), time.Hour),
+		m,
+		config.LogConfig{Level: "error"},
+		l,
+	)
+
+	return &testAPI{t: t, handler: h.RegisterRoutes(), store: store}
+}
+
+// token выпускает JWT пользователя с ролью role и складами warehouses
+func (a *testAPI) token(role domain.Role, warehouses ...uuid.UUID) string {
+	a.t.Helper()
+
+	claims := auth.Claims{Role: role, Warehouses: warehouses}
+	claims.Subject = "user"
+	if role != "" {
+		claims.Subject = string(role)
+	}
+	token, err := auth.SignHS256(testAuthConfig, claims, time.Hour)
+	if err != nil {
+		a.t.Fatalf("выпуск JWT: %v", err)
+	}
+	return token
+}
+
+// do выполняет запрос с JWT администратора. Заголовки header дополняют
+// и заменяют заголовки по умолчанию, в том числе Authorization
+func (a *testAPI) do(method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	a.t.Helper()
+
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Authorization", "Bearer "+a.token(domain.RoleAdmin))
+	if body != "" {
+		r.Header.Set("Content-Type", "application/json")
+	}
+	for name, values := range header {
+		r.Header[name] = values
+	}
+
+	w := httptest.NewRecorder()
+	a.handler.ServeHTTP(w, r)
+	return w
+}
+
+// as возвращает заголовок Authorization с JWT пользователя с ролью role и складами warehouses
+func (a *testAPI) as(role domain.Role, warehouses ...uuid.UUID) http.Header {
+	return http.Header{"Authorization": {"Bearer " + a.token(role, warehouses...)}}
+}
+
+// seedWarehouse создает склад
+func (a *testAPI) seedWarehouse() domain.Warehouse {
+	a.t.Helper()
+
+	warehouse, err := memory.NewWarehouseRepository(a.store).Create(context.Background(),
+		domain.Warehouse{Address: "test " + uuid.NewString()})
+	if err != nil {
+		a.t.Fatalf("создание склада: %v", err)
+	}
+	return warehouse
+}
+
+// seedProduct создает товар с характеристиками characteristics
+func (a *testAPI) seedProduct(characteristics string) domain.Product {
+	a.t.Helper()
+
+	product, err := memory.NewProductRepository(a.store).Create(context.Background(), domain.Product{
+		Name:            "test",
+		Characteristics: json.RawMessage(characteristics),
+		Weight:          1,
+		Barcode:         uuid.NewString(),
+	})
+	if err != nil {
+		a.t.Fatalf("создание товара: %v", err)
+	}
+	return product
+}
+
+// seedInventory создает товар на складе с остатком quantity
+func (a *testAPI) seedInventory(warehouse domain.Warehouse, product domain.Product, quantity int) domain.Inventory {
+	a.t.Helper()
+
+	inventory, err := memory.NewInventoryRepository(a.store).Create(context.Background(), domain.Inventory{
+		WarehouseID: warehouse.ID,
+		ProductID:   product.ID,
+		Quantity:    quantity,
+		Price:       100,
+	}, domain.MovementInfo{Type: domain.MovementReceipt})
+	if err != nil {
+		a.t.Fatalf("создание остатка: %v", err)
+	}
+	return inventory
+}
+
+// decodeBody разбирает JSON тело ответа
+func decodeBody[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("разбор ответа %s: %v", w.Body.String(), err)
+	}
+	return v
+}
+
+// assertError проверяет статус и код ответа с ошибкой
+func assertError(t *testing.T, w *httptest.ResponseRecorder, status int, code ErrorCode) {
+	t.Helper()
+
+	if w.Code != status {
+		t.Fatalf("статус %d, ожидался %d: %s", w.Code, status, w.Body.String())
+	}
+	if got := decodeBody[ErrorResponse](t, w).Code; got != code {
+		t.Errorf("код ошибки %s, ожидался %s", got, code)
+	}
+}
@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"go.uber.org/zap"
+)
+
+// LivenessCheck проверяет, что процесс работает
+// @Summary Проверка живости
+// @Description Возвращает 200 OK, пока процесс обрабатывает запросы. Зависимости не проверяются: перезапуск из-за недоступной базы данных не поможет
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /api/health/live [get]
+func (h *Handler) LivenessCheck(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ReadinessCheck проверяет готовность сервиса принимать запросы
+// @Summary Проверка готовности
+// @Description Проверяет доступность базы данных, совпадение версии схемы с версией приложения и сообщает загрузку пула соединений. Во время остановки сервиса возвращает 503
+// @Tags health
+// @Produce json
+// @Success 200 {object} domain.Readiness
+// @Failure 503 {object} domain.Readiness
+// @Router /api/health/ready [get]
+func (h *Handler) ReadinessCheck(w http.ResponseWriter, r *http.Request) {
+	readiness := h.health.Ready(r.Context())
+	if readiness.Status != domain.Ready {
+		h.logger.WithRequestID(r.Context()).Warn("Сервис не готов принимать запросы", zap.Any("checks", readiness.Checks))
+		writeJSON(w, http.StatusServiceUnavailable, readiness)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, readiness)
+}
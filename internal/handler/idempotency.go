@@ -0,0 +1,172 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/service"
+	"go.uber.org/zap"
+)
+
+// Заголовки идемпотентности
+const (
+	// idempotencyKeyHeader ключ, по которому повтор запроса возвращает сохраненный ответ
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader отмечает ответ, возвращенный из сохраненных
+	idempotentReplayedHeader = "Idempotent-Replayed"
+)
+
+// replayedHeaders заголовки ответа, которые сохраняются по ключу идемпотентности
+// и возвращаются при повторе запроса. Заголовки, относящиеся к конкретному запросу
+// (например, X-Request-ID), не сохраняются
+var replayedHeaders = []string{"Content-Type", "ETag", "Location", "Last-Modified"}
+
+// maxIdempotencyKeyLength ограничивает длину ключа идемпотентности
+const maxIdempotencyKeyLength = 255
+
+// idempotencyStoreTimeout ограничивает сохранение ответа, которое выполняется
+// и после отмены контекста запроса клиентом
+const idempotencyStoreTimeout = 5 * time.Second
+
+// idempotent выполняет запрос с заголовком Idempotency-Key один раз.
+// Ответ сохраняется по ключу, клиенту и хешу запроса: повтор с тем же ключом и телом
+// возвращает сохраненный ответ, с другим телом - ошибку 422. Ответы 5xx не сохраняются,
+// чтобы клиент мог повторить запрос; при панике обработчика ключ также освобождается.
+// Запросы без заголовка выполняются как обычно
+func (h *Handler) idempotent(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(idempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+
+		ctx := r.Context()
+		logger := h.logger.WithRequestID(ctx)
+
+		if !validIdempotencyKey(key) {
+			writeErrorDetails(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный ключ идемпотентности",
+				map[string]string{"header": idempotencyKeyHeader})
+			return
+		}
+
+		body, ok := h.readBody(w, r)
+		if !ok {
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		subject := principalFrom(ctx).Subject
+		record, replay, err := h.idempotency.Begin(ctx, subject, key, requestHash(r, body))
+		switch {
+		case errors.Is(err, service.ErrIdempotencyKeyMismatch):
+			writeError(w, r, http.StatusUnprocessableEntity, CodeIdempotencyKeyMismatch, err.Error())
+			return
+		case errors.Is(err, service.ErrIdempotencyInProgress):
+			w.Header().Set("Retry-After", "1")
+			writeError(w, r, http.StatusConflict, CodeIdempotencyInProgress, err.Error())
+			return
+		case err != nil:
+			logger.Error("Ошибка при проверке ключа идемпотентности", zap.Error(err))
+			writeServiceError(w, r, err, "Ошибка при проверке ключа идемпотентности")
+			return
+		}
+
+		if replay {
+			logger.Info("Возвращен сохраненный ответ", zap.String("idempotency_key", key))
+			// Записи, сохраненные без заголовков, содержат JSON
+			w.Header().Set("Content-Type", "application/json")
+			for name, value := range record.Headers {
+				w.Header().Set(name, value)
+			}
+			w.Header().Set(idempotentReplayedHeader, "true")
+			w.WriteHeader(record.Status)
+			w.Write(record.Response)
+			return
+		}
+
+		// Ответ сохраняется и при отмене запроса: операция уже выполнена
+		storeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), idempotencyStoreTimeout)
+		defer cancel()
+
+		// Паника обработчика освобождает ключ, иначе повторы до истечения ключа
+		// получали бы IDEMPOTENCY_IN_PROGRESS; паника передается дальше
+		defer func() {
+			if p := recover(); p != nil {
+				if err := h.idempotency.Release(storeCtx, subject, key); err != nil {
+					logger.Error("Ошибка при освобождении ключа идемпотентности",
+						zap.String("idempotency_key", key), zap.Error(err))
+				}
+				panic(p)
+			}
+		}()
+
+		rec := &bufferedRecorder{responseRecorder: responseRecorder{ResponseWriter: w}}
+		next(rec, r)
+
+		if status := rec.Status(); status >= http.StatusInternalServerError {
+			err = h.idempotency.Release(storeCtx, subject, key)
+		} else {
+			err = h.idempotency.Complete(storeCtx, subject, key, status, rec.body.Bytes(), responseHeaders(rec.Header()))
+		}
+		if err != nil {
+			logger.Error("Ошибка при сохранении ответа по ключу идемпотентности",
+				zap.String("idempotency_key", key), zap.Error(err))
+		}
+	}
+}
+
+// responseHeaders выбирает из заголовков ответа те, что возвращаются при повторе запроса
+func responseHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(replayedHeaders))
+	for _, name := range replayedHeaders {
+		if value := header.Get(name); value != "" {
+			headers[name] = value
+		}
+	}
+	return headers
+}
+
+// validIdempotencyKey проверяет ключ идемпотентности: от 1 до 255 печатных символов ASCII
+func validIdempotencyKey(key string) bool {
+	if key == "" || len(key) > maxIdempotencyKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestHash вычисляет хеш запроса по методу, шаблону маршрута и телу,
+// чтобы ключ нельзя было использовать для другого запроса
+func requestHash(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, r.Method)
+	hash.Write([]byte{0})
+	io.WriteString(hash, r.Pattern)
+	hash.Write([]byte{0})
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// bufferedRecorder передает ответ клиенту и одновременно копирует тело,
+// чтобы сохранить его по ключу идемпотентности
+type bufferedRecorder struct {
+	responseRecorder
+	body bytes.Buffer
+}
+
+// Write копирует тело ответа и передает его дальше
+func (rec *bufferedRecorder) Write(b []byte) (int, error) {
+	rec.body.Write(b)
+	return rec.responseRecorder.Write(b)
+}
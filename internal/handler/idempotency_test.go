@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/repository/memory"
+	"github.com/danya1733/practiceGO/internal/service"
+	"github.com/danya1733/practiceGO/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// newIdempotencyHandler создает обработчик с ключами идемпотентности в памяти
+func newIdempotencyHandler() *Handler {
+	return &Handler{
+		idempotency: service.NewIdempotencyService(memory.NewIdempotencyRepository(memory.NewStore()), time.Hour),
+		logger:      &logger.Logger{Logger: zap.NewNop()},
+	}
+}
+
+// serveIdempotent выполняет POST запрос с ключом идемпотентности
+func serveIdempotent(handler http.HandlerFunc, key, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/products", strings.NewReader(body))
+	r.Header.Set(idempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	handler(w, r)
+	return w
+}
+
+func TestIdempotentReplaysHeaders(t *testing.T) {
+	h := newIdempotencyHandler()
+	calls := 0
+	handler := h.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		setETag(w, 7)
+		w.Header().Set("Location", "/api/products/1")
+		w.Header().Set("X-Request-ID", "first")
+		writeJSON(w, http.StatusCreated, map[string]string{"id": "1"})
+	})
+
+	first := serveIdempotent(handler, "key", `{"name":"test"}`)
+	replay := serveIdempotent(handler, "key", `{"name":"test"}`)
+
+	if calls != 1 {
+		t.Fatalf("обработчик вызван %d раз, ожидался 1", calls)
+	}
+	if replay.Code != http.StatusCreated {
+		t.Errorf("статус повтора %d, ожидался %d", replay.Code, http.StatusCreated)
+	}
+	if replay.Body.String() != first.Body.String() {
+		t.Errorf("тело повтора %q, ожидалось %q", replay.Body.String(), first.Body.String())
+	}
+	for _, name := range []string{"Content-Type", "ETag", "Location"} {
+		if got, want := replay.Header().Get(name), first.Header().Get(name); got != want {
+			t.Errorf("заголовок %s повтора %q, ожидался %q", name, got, want)
+		}
+	}
+	if got := replay.Header().Get("X-Request-ID"); got != "" {
+		t.Errorf("заголовок X-Request-ID первого запроса сохранен: %q", got)
+	}
+	if got := replay.Header().Get(idempotentReplayedHeader); got != "true" {
+		t.Errorf("заголовок %s = %q, ожидалось true", idempotentReplayedHeader, got)
+	}
+}
+
+func TestIdempotentReleasesKeyOnPanic(t *testing.T) {
+	h := newIdempotencyHandler()
+	panicking := h.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		panic("сбой обработчика")
+	})
+
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("паника обработчика не передана дальше")
+			}
+		}()
+		serveIdempotent(panicking, "key", `{}`)
+	}()
+
+	// Ключ освобожден, поэтому повтор выполняется, а не получает IDEMPOTENCY_IN_PROGRESS
+	retry := h.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusCreated, map[string]string{"id": "1"})
+	})
+	if w := serveIdempotent(retry, "key", `{}`); w.Code != http.StatusCreated {
+		t.Errorf("статус повтора %d, ожидался %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+}
+
+func TestIdempotentRejectsLargeBody(t *testing.T) {
+	h := newIdempotencyHandler()
+	calls := 0
+	handler := h.idempotent(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	})
+
+	body := `{"name":"` + strings.Repeat("x", maxRequestBodySize) + `"}`
+	w := serveIdempotent(handler, "key", body)
+
+	assertError(t, w, http.StatusRequestEntityTooLarge, CodeRequestTooLarge)
+	if calls != 0 {
+		t.Errorf("обработчик вызван %d раз, ожидалось 0", calls)
+	}
+}
@@ -1,11 +1,11 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
 	"strconv"
 
 	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/pkg/requestctx"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
@@ -16,101 +16,115 @@ func (h *Handler) CreateInventory(w http.ResponseWriter, r *http.Request) {
 	logger := h.logger.WithRequestID(ctx)
 
 	var inventory domain.Inventory
-	if err := json.NewDecoder(r.Body).Decode(&inventory); err != nil {
-		logger.Error("Ошибка при декодировании запроса", zap.Error(err))
-		writeError(w, "Некорректный формат запроса", http.StatusBadRequest)
+	if !h.decodeRequest(w, r, &inventory) {
+		return
+	}
+	if !h.authorizeWarehouse(w, r, inventory.WarehouseID) {
 		return
 	}
 
-	createdInventory, err := h.inventoryRepo.Create(ctx, inventory)
+	createdInventory, err := h.inventory.Create(ctx, inventory, movementInfo(r, domain.MovementReceipt, "inventory_created"))
 	if err != nil {
 		logger.Error("Ошибка при создании записи инвентаризации", zap.Error(err))
-		writeError(w, "Ошибка при создании записи инвентаризации", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при создании записи инвентаризации")
 		return
 	}
 
+	setETag(w, createdInventory.Version)
 	writeJSON(w, http.StatusCreated, createdInventory)
 }
 
-// UpdateInventoryQuantity обновляет количество товара на складе
+// UpdateInventoryQuantity изменяет количество товара на складе на указанную величину
+// и записывает движение с типом и причиной изменения.
+// Заголовок If-Match с ETag остатка обязателен: если остаток успел измениться, возвращается 412
 func (h *Handler) UpdateInventoryQuantity(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := h.logger.WithRequestID(ctx)
 
-	var data struct {
-		WarehouseID string `json:"warehouse_id"`
-		ProductID   string `json:"product_id"`
-		Quantity    int    `json:"quantity"`
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		logger.Error("Ошибка при декодировании запроса", zap.Error(err))
-		writeError(w, "Некорректный формат запроса", http.StatusBadRequest)
+	var data domain.InventoryQuantityUpdate
+
+	if !h.decodeRequest(w, r, &data) {
 		return
 	}
 
 	warehouseID, err := uuid.Parse(data.WarehouseID)
 	if err != nil {
 		logger.Error("Некорректный формат ID склада", zap.Error(err))
-		writeError(w, "Некорректный формат ID склада", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID склада")
 		return
 	}
 
 	productID, err := uuid.Parse(data.ProductID)
 	if err != nil {
 		logger.Error("Некорректный формат ID товара", zap.Error(err))
-		writeError(w, "Некорректный формат ID товара", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID товара")
+		return
+	}
+
+	if !h.authorizeWarehouse(w, r, warehouseID) {
 		return
 	}
 
-	updatedInventory, err := h.inventoryRepo.UpdateQuantity(ctx, warehouseID, productID, data.Quantity)
+	info := movementInfo(r, data.Type, data.Reason)
+	updatedInventory, err := h.inventory.UpdateQuantity(ctx, warehouseID, productID, data.Quantity, version, info)
 	if err != nil {
 		logger.Error("Ошибка при обновлении количества товара", zap.Error(err))
-		writeError(w, "Ошибка при обновлении количества товара", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при обновлении количества товара")
 		return
 	}
 
+	setETag(w, updatedInventory.Version)
 	writeJSON(w, http.StatusOK, updatedInventory)
 }
 
-// UpdateInventoryDiscount обновляет скидку на товар
+// UpdateInventoryDiscount обновляет скидку на товар.
+// Заголовок If-Match с ETag остатка обязателен: если остаток успел измениться, возвращается 412
 func (h *Handler) UpdateInventoryDiscount(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := h.logger.WithRequestID(ctx)
 
-	var data struct {
-		WarehouseID string  `json:"warehouse_id"`
-		ProductID   string  `json:"product_id"`
-		Discount    float64 `json:"discount"`
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-		logger.Error("Ошибка при декодировании запроса", zap.Error(err))
-		writeError(w, "Некорректный формат запроса", http.StatusBadRequest)
+	var data domain.InventoryDiscountUpdate
+
+	if !h.decodeRequest(w, r, &data) {
 		return
 	}
 
 	warehouseID, err := uuid.Parse(data.WarehouseID)
 	if err != nil {
 		logger.Error("Некорректный формат ID склада", zap.Error(err))
-		writeError(w, "Некорректный формат ID склада", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID склада")
 		return
 	}
 
 	productID, err := uuid.Parse(data.ProductID)
 	if err != nil {
 		logger.Error("Некорректный формат ID товара", zap.Error(err))
-		writeError(w, "Некорректный формат ID товара", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID товара")
 		return
 	}
 
-	updatedInventory, err := h.inventoryRepo.UpdateDiscount(ctx, warehouseID, productID, data.Discount)
+	if !h.authorizeWarehouse(w, r, warehouseID) {
+		return
+	}
+
+	updatedInventory, err := h.inventory.UpdateDiscount(ctx, warehouseID, productID, data.Discount, version)
 	if err != nil {
 		logger.Error("Ошибка при обновлении скидки", zap.Error(err))
-		writeError(w, "Ошибка при обновлении скидки", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при обновлении скидки")
 		return
 	}
 
+	setETag(w, updatedInventory.Version)
 	writeJSON(w, http.StatusOK, updatedInventory)
 }
 
@@ -123,40 +137,46 @@ func (h *Handler) GetWarehouseProducts(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(idStr)
 	if err != nil {
 		logger.Error("Некорректный формат ID склада", zap.Error(err))
-		writeError(w, "Некорректный формат ID склада", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID склада")
 		return
 	}
 
-	page := 1
-	limit := 10
+	attributes, ok := parseAttributeFilters(w, r)
+	if !ok {
+		return
+	}
+	filter := domain.WarehouseProductFilter{
+		Attributes: attributes,
+		Page:       1,
+		Limit:      10,
+	}
 
-	pageStr := r.URL.Query().Get("page")
-	if pageStr != "" {
+	if pageStr := r.URL.Query().Get("page"); pageStr != "" {
 		pageVal, err := strconv.Atoi(pageStr)
 		if err == nil && pageVal > 0 {
-			page = pageVal
+			filter.Page = pageVal
 		}
 	}
 
-	limitStr := r.URL.Query().Get("limit")
-	if limitStr != "" {
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		limitVal, err := strconv.Atoi(limitStr)
 		if err == nil && limitVal > 0 {
-			limit = limitVal
+			filter.Limit = limitVal
 		}
 	}
 
-	products, err := h.inventoryRepo.GetProductsByWarehouse(ctx, id, page, limit)
+	products, err := h.inventory.ListByWarehouse(ctx, id, filter)
 	if err != nil {
 		logger.Error("Ошибка при получении списка товаров на складе", zap.Error(err))
-		writeError(w, "Ошибка при получении списка товаров на складе", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при получении списка товаров на складе")
 		return
 	}
 
 	writeJSON(w, http.StatusOK, products)
 }
 
-// GetWarehouseProduct возвращает конкретный товар на складе
+// GetWarehouseProduct возвращает конкретный товар на складе;
+// версия остатка возвращается в заголовке ETag
 func (h *Handler) GetWarehouseProduct(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := h.logger.WithRequestID(ctx)
@@ -165,7 +185,7 @@ func (h *Handler) GetWarehouseProduct(w http.ResponseWriter, r *http.Request) {
 	warehouseID, err := uuid.Parse(warehouseIDStr)
 	if err != nil {
 		logger.Error("Некорректный формат ID склада", zap.Error(err))
-		writeError(w, "Некорректный формат ID склада", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID склада")
 		return
 	}
 
@@ -173,135 +193,74 @@ func (h *Handler) GetWarehouseProduct(w http.ResponseWriter, r *http.Request) {
 	productID, err := uuid.Parse(productIDStr)
 	if err != nil {
 		logger.Error("Некорректный формат ID товара", zap.Error(err))
-		writeError(w, "Некорректный формат ID товара", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID товара")
 		return
 	}
 
-	inventory, err := h.inventoryRepo.GetByWarehouseAndProduct(ctx, warehouseID, productID)
+	result, err := h.inventory.Get(ctx, warehouseID, productID)
 	if err != nil {
 		logger.Error("Ошибка при получении товара на складе", zap.Error(err))
-		writeError(w, "Ошибка при получении товара на складе", http.StatusInternalServerError)
-		return
-	}
-
-	// Получаем информацию о товаре
-	product, err := h.productRepo.GetByID(ctx, productID)
-	if err != nil {
-		logger.Error("Ошибка при получении информации о товаре", zap.Error(err))
-		writeError(w, "Ошибка при получении информации о товаре", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при получении товара на складе")
 		return
 	}
 
-	result := domain.InventoryWithProduct{
-		Inventory: inventory,
-		Product:   product,
-	}
-
+	setETag(w, result.Version)
 	writeJSON(w, http.StatusOK, result)
 }
 
 // CalculateProductsPrice рассчитывает стоимость товаров с учетом скидок
+// и при необходимости резервирует их до покупки
 func (h *Handler) CalculateProductsPrice(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := h.logger.WithRequestID(ctx)
 
 	var request domain.PurchaseRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		logger.Error("Ошибка при декодировании запроса")
-		writeError(w, "Некорректный формат запроса", http.StatusBadRequest)
+	if !h.decodeRequest(w, r, &request) {
 		return
 	}
-
-	result := domain.CalculationResult{
-		TotalSum: 0,
-		Items: []struct {
-			ProductID         uuid.UUID `json:"product_id"`
-			Name              string    `json:"name"`
-			Quantity          int       `json:"quantity"`
-			Price             float64   `json:"price"`
-			PriceWithDiscount float64   `json:"price_with_discount"`
-			TotalPrice        float64   `json:"total_price"`
-		}{},
+	if !h.authorizeWarehouse(w, r, request.WarehouseID) {
+		return
 	}
 
-	for _, p := range request.Products {
-		// Получаем информацию о товаре на складе
-		inventory, err := h.inventoryRepo.GetByWarehouseAndProduct(ctx, request.WarehouseID, p.ProductID)
-		if err != nil {
-			logger.Error("Ошибка при получении информации о товаре на складе",
-				zap.Error(err),
-				zap.String("product_id", p.ProductID.String()))
-			writeError(w, "Ошибка при расчете стоимости: товар не найден на складе", http.StatusBadRequest)
-			return
-		}
-
-		// Получаем информацию о товаре
-		product, err := h.productRepo.GetByID(ctx, p.ProductID)
-		if err != nil {
-			logger.Error("Ошибка при получении информации о товаре",
-				zap.Error(err),
-				zap.String("product_id", p.ProductID.String()))
-			writeError(w, "Ошибка при расчете стоимости: товар не найден", http.StatusBadRequest)
-			return
-		}
-
-		// Проверяем наличие достаточного количества товара
-		if inventory.Quantity < p.Quantity {
-			logger.Error("Недостаточное количество товара на складе",
-				zap.String("product_id", p.ProductID.String()),
-				zap.Int("available", inventory.Quantity),
-				zap.Int("requested", p.Quantity))
-			writeError(w, "Недостаточное количество товара на складе", http.StatusBadRequest)
-			return
-		}
-
-		// Рассчитываем цену с учетом скидки
-		priceWithDiscount := inventory.Price * (1 - inventory.Discount/100)
-		totalPrice := priceWithDiscount * float64(p.Quantity)
-
-		item := struct {
-			ProductID         uuid.UUID `json:"product_id"`
-			Name              string    `json:"name"`
-			Quantity          int       `json:"quantity"`
-			Price             float64   `json:"price"`
-			PriceWithDiscount float64   `json:"price_with_discount"`
-			TotalPrice        float64   `json:"total_price"`
-		}{
-			ProductID:         p.ProductID,
-			Name:              product.Name,
-			Quantity:          p.Quantity,
-			Price:             inventory.Price,
-			PriceWithDiscount: priceWithDiscount,
-			TotalPrice:        totalPrice,
-		}
-
-		result.Items = append(result.Items, item)
-		result.TotalSum += totalPrice
+	result, err := h.sales.Calculate(ctx, request)
+	if err != nil {
+		logger.Error("Ошибка при расчете стоимости", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при расчете стоимости")
+		return
 	}
 
 	writeJSON(w, http.StatusOK, result)
 }
 
-// PurchaseProducts обрабатывает покупку товаров
+// PurchaseProducts обрабатывает покупку товаров и возвращает созданный заказ
 func (h *Handler) PurchaseProducts(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := h.logger.WithRequestID(ctx)
 
 	var request domain.PurchaseRequest
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		logger.Error("Ошибка при декодировании запроса", zap.Error(err))
-		writeError(w, "Некорректный формат запроса", http.StatusBadRequest)
+	if !h.decodeRequest(w, r, &request) {
+		return
+	}
+	if !h.authorizeWarehouse(w, r, request.WarehouseID) {
 		return
 	}
 
-	err := h.inventoryRepo.PurchaseProducts(ctx, request.WarehouseID, request.Products)
+	order, err := h.sales.Purchase(ctx, request, movementInfo(r, domain.MovementSale, "purchase"))
 	if err != nil {
 		logger.Error("Ошибка при обработке покупки", zap.Error(err))
-		writeError(w, "Ошибка при обработке покупки: "+err.Error(), http.StatusBadRequest)
+		writeServiceError(w, r, err, "Ошибка при обработке покупки")
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status":"success"}`))
+	writeJSON(w, http.StatusCreated, order)
+}
+
+// movementInfo собирает описание изменения остатка из запроса
+func movementInfo(r *http.Request, t domain.MovementType, reason string) domain.MovementInfo {
+	return domain.MovementInfo{
+		Type:      t,
+		Reason:    reason,
+		Actor:     principalFrom(r.Context()).Subject,
+		RequestID: requestctx.RequestID(r.Context()),
+	}
 }
@@ -0,0 +1,113 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+func TestInventoryErrors(t *testing.T) {
+	a := newTestAPI(t)
+	warehouse := a.seedWarehouse()
+	product := a.seedProduct(`{}`)
+	inventory := a.seedInventory(warehouse, product, 5)
+	stranger := a.seedProduct(`{}`)
+
+	ifMatchVersion := func(version int) http.Header {
+		return http.Header{"If-Match": {fmt.Sprintf(`"%d"`, version)}}
+	}
+	create := func(warehouseID, productID uuid.UUID) string {
+		return fmt.Sprintf(`{"warehouse_id": %q, "product_id": %q, "quantity": 1, "price": 10}`, warehouseID, productID)
+	}
+	quantity := func(productID uuid.UUID, delta int) string {
+		return fmt.Sprintf(`{"warehouse_id": %q, "product_id": %q, "quantity": %d}`, warehouse.ID, productID, delta)
+	}
+
+	tests := []struct {
+		name                 string
+		method, target, body string
+		header               http.Header
+		status               int
+		code                 ErrorCode
+	}{
+		{"товара нет на складе", http.MethodGet,
+			fmt.Sprintf("/api/warehouses/%s/products/%s", warehouse.ID, stranger.ID), "", nil,
+			http.StatusNotFound, CodeInventoryNotFound},
+		{"изменение отсутствующего остатка", http.MethodPut, "/api/inventory/quantity",
+			quantity(stranger.ID, 1), ifMatchVersion(1),
+			http.StatusNotFound, CodeInventoryNotFound},
+		{"повторное добавление товара на склад", http.MethodPost, "/api/inventory",
+			create(warehouse.ID, product.ID), nil,
+			http.StatusConflict, CodeInventoryConflict},
+		{"устаревшая версия остатка", http.MethodPut, "/api/inventory/quantity",
+			quantity(product.ID, 1), ifMatchVersion(inventory.Version + 1),
+			http.StatusPreconditionFailed, CodeVersionMismatch},
+		{"устаревшая версия скидки", http.MethodPut, "/api/inventory/discount",
+			fmt.Sprintf(`{"warehouse_id": %q, "product_id": %q, "discount": 10}`, warehouse.ID, product.ID), ifMatchVersion(inventory.Version + 1),
+			http.StatusPreconditionFailed, CodeVersionMismatch},
+		{"несуществующий склад", http.MethodPost, "/api/inventory",
+			create(uuid.New(), product.ID), nil,
+			http.StatusUnprocessableEntity, CodeWarehouseNotFound},
+		{"несуществующий товар", http.MethodPost, "/api/inventory",
+			create(warehouse.ID, uuid.New()), nil,
+			http.StatusUnprocessableEntity, CodeProductNotFound},
+		{"списание больше остатка", http.MethodPut, "/api/inventory/quantity",
+			quantity(product.ID, -6), ifMatchVersion(inventory.Version),
+			http.StatusBadRequest, CodeInsufficientStock},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertError(t, a.do(tt.method, tt.target, tt.body, tt.header), tt.status, tt.code)
+		})
+	}
+}
+
+func TestPurchaseErrors(t *testing.T) {
+	a := newTestAPI(t)
+	warehouse := a.seedWarehouse()
+	product := a.seedProduct(`{}`)
+	a.seedInventory(warehouse, product, 5)
+	stranger := a.seedProduct(`{}`)
+
+	purchase := func(productID uuid.UUID, quantity int) string {
+		return fmt.Sprintf(`{"warehouse_id": %q, "products": [{"product_id": %q, "quantity": %d}]}`, warehouse.ID, productID, quantity)
+	}
+	reserve := fmt.Sprintf(`{"warehouse_id": %q, "products": [{"product_id": %q, "quantity": 1}], "reserve": true}`, warehouse.ID, product.ID)
+
+	w := a.do(http.MethodPost, "/api/warehouses/calculate", reserve, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("резервирование: статус %d: %s", w.Code, w.Body.String())
+	}
+	reservation := decodeBody[domain.CalculationResult](t, w).Reservation
+	if reservation == nil {
+		t.Fatal("резерв не создан")
+	}
+	byReservation := func(id uuid.UUID) string {
+		return fmt.Sprintf(`{"warehouse_id": %q, "reservation_id": %q}`, warehouse.ID, id)
+	}
+	if w := a.do(http.MethodPost, "/api/warehouses/purchase", byReservation(reservation.ID), nil); w.Code != http.StatusCreated {
+		t.Fatalf("покупка по резерву: статус %d: %s", w.Code, w.Body.String())
+	}
+
+	tests := []struct {
+		name   string
+		body   string
+		status int
+		code   ErrorCode
+	}{
+		{"товара нет на складе", purchase(stranger.ID, 1), http.StatusNotFound, CodeInventoryNotFound},
+		{"несуществующий склад", fmt.Sprintf(`{"warehouse_id": %q, "products": [{"product_id": %q, "quantity": 1}]}`, uuid.New(), product.ID),
+			http.StatusNotFound, CodeInventoryNotFound},
+		{"недостаточно товара", purchase(product.ID, 10), http.StatusBadRequest, CodeInsufficientStock},
+		{"несуществующий резерв", byReservation(uuid.New()), http.StatusNotFound, CodeReservationNotFound},
+		{"резерв уже использован", byReservation(reservation.ID), http.StatusConflict, CodeReservationNotActive},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertError(t, a.do(http.MethodPost, "/api/warehouses/purchase", tt.body, nil), tt.status, tt.code)
+		})
+	}
+}
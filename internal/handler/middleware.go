@@ -2,9 +2,23 @@ package handler
 
 import (
 	"context"
+	"errors"
 	"net/http"
+	"slices"
+	"strings"
+	"time"
 
-	"github.com/google/uuid"
+	"github.com/danya1733/practiceGO/internal/metrics"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/service"
+	"github.com/danya1733/practiceGO/pkg/requestctx"
 	"go.uber.org/zap"
 )
 
@@ -13,37 +27,253 @@ type contextKey string
 
 // Ключи контекста
 const (
-	requestIDContextKey contextKey = "request_id"
+	principalContextKey contextKey = "principal"
 )
 
-// requestIDMiddleware добавляет заголовок request_id если его нет
+// requestIDMiddleware сохраняет идентификатор запроса в контексте и возвращает его
+// в заголовке ответа X-Request-ID. Идентификатор берется из одноименного заголовка запроса,
+// а если клиент его не передал или передал некорректный, создается новый
 func (h *Handler) requestIDMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		requestID := r.Header.Get("X-Request-ID")
-		if requestID == "" {
-			requestID = uuid.New().String()
-			r.Header.Set("X-Request-ID", requestID)
-		}
+		requestID := requestctx.FromHeader(r.Header.Get(requestctx.Header))
+		r.Header.Set(requestctx.Header, requestID)
+		w.Header().Set(requestctx.Header, requestID)
 
-		ctx := r.Context()
-		ctx = context.WithValue(ctx, requestIDContextKey, requestID)
-
-		next.ServeHTTP(w, r.WithContext(ctx))
+		next.ServeHTTP(w, r.WithContext(requestctx.WithRequestID(r.Context(), requestID)))
 	})
 }
 
-// loggingMiddleware логирует информацию о запросе
+// loggingMiddleware записывает access log после обработки запроса: статус, время обработки,
+// размер ответа, адрес и user agent клиента. Запросы дольше порога LOG_SLOW_REQUEST_THRESHOLD
+// записываются с уровнем warn. Пути из LOG_ACCESS_EXCLUDE не логируются
 func (h *Handler) loggingMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx := r.Context()
-		requestID, _ := ctx.Value(requestIDContextKey).(string)
+		if hasPathPrefix(r.URL.Path, h.logConfig.AccessExclude) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+		duration := time.Since(start)
 
-		h.logger.Info("HTTP запрос получен",
+		fields := []zap.Field{
 			zap.String("method", r.Method),
 			zap.String("path", r.URL.Path),
-			zap.String("request_id", requestID),
+			zap.Int("status", rec.Status()),
+			zap.Duration("duration", duration),
+			zap.Int64("bytes", rec.bytes),
+			zap.String("remote_addr", r.RemoteAddr),
+			zap.String("user_agent", r.UserAgent()),
+		}
+
+		logger := h.logger.WithRequestID(r.Context())
+		threshold := h.logConfig.SlowRequestThreshold
+		if threshold > 0 && duration >= threshold {
+			logger.Warn("Медленный HTTP запрос", append(fields, zap.Duration("threshold", threshold))...)
+			return
+		}
+		logger.Info("HTTP запрос обработан", fields...)
+	})
+}
+
+// responseRecorder запоминает статус и размер ответа для middleware
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader запоминает статус и передает его дальше
+func (rec *responseRecorder) WriteHeader(status int) {
+	if rec.status == 0 {
+		rec.status = status
+	}
+	rec.ResponseWriter.WriteHeader(status)
+}
+
+// Write запоминает статус 200, если обработчик не задал его явно, и считает записанные байты
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap возвращает исходный ResponseWriter для http.ResponseController
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// Status возвращает статус ответа; если обработчик ничего не записал, статус 200
+func (rec *responseRecorder) Status() int {
+	if rec.status == 0 {
+		return http.StatusOK
+	}
+	return rec.status
+}
+
+// routeOf возвращает шаблон маршрута запроса без метода, например /api/warehouses/{id}.
+// Для запросов, не совпавших ни с одним маршрутом, возвращается пустая строка
+func routeOf(mux *http.ServeMux, r *http.Request) string {
+	_, pattern := mux.Handler(r)
+	if _, path, found := strings.Cut(pattern, " "); found {
+		return path
+	}
+	return pattern
+}
+
+// knownMethods HTTP методы, которые попадают в метки метрик и имена спанов как есть
+var knownMethods = []string{
+	http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodHead, http.MethodOptions,
+}
+
+// methodOf возвращает метод запроса для меток метрик и имен спанов.
+// Остальные методы заменяются на OTHER, чтобы число рядов не зависело от клиентов
+func methodOf(r *http.Request) string {
+	if slices.Contains(knownMethods, r.Method) {
+		return r.Method
+	}
+	return metrics.OtherMethod
+}
+
+// tracerName имя трассировщика HTTP запросов
+const tracerName = "github.com/danya1733/practiceGO/internal/handler"
+
+// tracingMiddleware создает спан на каждый запрос. Если клиент передал заголовок
+// traceparent, спан продолжает его трассировку. Спаны запросов к базе данных
+// становятся дочерними, так как создаются из контекста запроса
+func (h *Handler) tracingMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	tracer := otel.Tracer(tracerName)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+		// Имя спана содержит шаблон маршрута, а не путь, чтобы спаны группировались по маршрутам
+		method := methodOf(r)
+		name := method
+		route := routeOf(mux, r)
+		if route != "" {
+			name += " " + route
+		}
+
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(method),
+				semconv.URLPath(r.URL.Path),
+				semconv.HTTPRoute(route),
+				semconv.UserAgentOriginal(r.UserAgent()),
+				attribute.String("request_id", requestctx.RequestID(ctx)),
+			),
 		)
+		defer span.End()
+		if method != r.Method {
+			span.SetAttributes(semconv.HTTPRequestMethodOriginal(r.Method))
+		}
+
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r.WithContext(ctx))
 
-		next.ServeHTTP(w, r)
+		status := rec.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
 	})
 }
+
+// metricsMiddleware учитывает количество и время обработки запросов.
+// Маршрут определяется по шаблону из mux, а не по пути запроса,
+// чтобы ID в пути не создавали отдельные ряды метрик
+func (h *Handler) metricsMiddleware(mux *http.ServeMux, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeOf(mux, r)
+		if route == "" {
+			route = metrics.UnmatchedRoute
+		}
+
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		h.metrics.ObserveRequest(methodOf(r), route, rec.Status(), time.Since(start))
+	})
+}
+
+// publicPaths пути, доступные без аутентификации
+var publicPaths = []string{"/api/health", "/swagger"}
+
+// isPublic проверяет, доступен ли путь без аутентификации
+func isPublic(path string) bool {
+	return hasPathPrefix(path, publicPaths)
+}
+
+// hasPathPrefix проверяет, совпадает ли путь с одним из префиксов
+// или вложен в него: префикс /swagger покрывает /swagger/ и /swagger.json
+func hasPathPrefix(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if path == p || strings.HasPrefix(path, p+"/") || strings.HasPrefix(path, p+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// authMiddleware проверяет учетные данные и сохраняет клиента в контексте запроса.
+// Поддерживаются заголовки Authorization: Bearer <JWT или API ключ> и X-API-Key
+func (h *Handler) authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublic(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !h.auth.Enabled() {
+			next.ServeHTTP(w, r.WithContext(withPrincipal(r.Context(), h.auth.Anonymous())))
+			return
+		}
+
+		ctx := r.Context()
+		principal, err := h.auth.Authenticate(ctx, credential(r))
+		if err != nil {
+			h.logger.WithRequestID(ctx).Warn("Запрос отклонен: ошибка аутентификации", zap.Error(err))
+			if !errors.Is(err, service.ErrUnauthenticated) {
+				writeServiceError(w, r, err, "Ошибка при проверке учетных данных")
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+			writeError(w, r, http.StatusUnauthorized, CodeUnauthorized, "Требуется аутентификация")
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(withPrincipal(ctx, principal)))
+	})
+}
+
+// credential извлекает учетные данные из заголовков запроса
+func credential(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
+
+// withPrincipal сохраняет клиента в контексте запроса.
+// Имя клиента также передается репозиториям для журнала аудита
+func withPrincipal(ctx context.Context, principal domain.Principal) context.Context {
+	ctx = requestctx.WithActor(ctx, principal.Subject)
+	return context.WithValue(ctx, principalContextKey, principal)
+}
+
+// principalFrom возвращает клиента, выполняющего запрос
+func principalFrom(ctx context.Context) domain.Principal {
+	principal, _ := ctx.Value(principalContextKey).(domain.Principal)
+	return principal
+}
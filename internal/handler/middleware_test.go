@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	a := newTestAPI(t)
+	const body = `{"address": "Москва"}`
+
+	tests := []struct {
+		name   string
+		header http.Header
+		status int
+		code   ErrorCode
+	}{
+		{"без учетных данных", http.Header{"Authorization": nil}, http.StatusUnauthorized, CodeUnauthorized},
+		{"не Bearer схема", http.Header{"Authorization": {"Basic YWRtaW46YWRtaW4="}}, http.StatusUnauthorized, CodeUnauthorized},
+		{"неверный JWT", http.Header{"Authorization": {"Bearer not-a-token"}}, http.StatusUnauthorized, CodeUnauthorized},
+		{"неизвестный API ключ", http.Header{"Authorization": nil, "X-Api-Key": {"wms_unknown"}}, http.StatusUnauthorized, CodeUnauthorized},
+		{"роль без разрешения", a.as(domain.RoleClerk), http.StatusForbidden, CodeForbidden},
+		{"пользователь без роли", a.as(""), http.StatusForbidden, CodeForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := a.do(http.MethodPost, "/api/warehouses", body, tt.header)
+			assertError(t, w, tt.status, tt.code)
+
+			// WWW-Authenticate сообщает клиенту схему только при отсутствии аутентификации
+			challenge := w.Header().Get("WWW-Authenticate")
+			if (tt.status == http.StatusUnauthorized) != (challenge != "") {
+				t.Errorf("статус %d, заголовок WWW-Authenticate %q", w.Code, challenge)
+			}
+		})
+	}
+
+	if w := a.do(http.MethodPost, "/api/warehouses", body, nil); w.Code != http.StatusCreated {
+		t.Errorf("администратор: статус %d, ожидался 201: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestAuthMiddlewarePublicPaths(t *testing.T) {
+	a := newTestAPI(t)
+
+	if w := a.do(http.MethodGet, "/api/health/live", "", http.Header{"Authorization": nil}); w.Code != http.StatusOK {
+		t.Errorf("проверка работоспособности без учетных данных: статус %d, ожидался 200", w.Code)
+	}
+}
+
+func TestAPIKeysWithAuthDisabled(t *testing.T) {
+	cfg := testAuthConfig
+	cfg.Enabled = false
+	a := newTestAPIWithAuth(t, cfg)
+
+	// Анонимный администратор работает с API, но не может выпускать API ключи
+	if w := a.do(http.MethodGet, "/api/warehouses", "", http.Header{"Authorization": nil}); w.Code != http.StatusOK {
+		t.Errorf("список складов: статус %d, ожидался 200: %s", w.Code, w.Body.String())
+	}
+	w := a.do(http.MethodPost, "/api/api-keys", `{"name": "ci"}`, http.Header{"Authorization": nil})
+	assertError(t, w, http.StatusConflict, CodeAuthDisabled)
+	w = a.do(http.MethodGet, "/api/api-keys", "", http.Header{"Authorization": nil})
+	assertError(t, w, http.StatusConflict, CodeAuthDisabled)
+}
+
+func TestMethodOf(t *testing.T) {
+	tests := []struct {
+		method, want string
+	}{
+		{http.MethodGet, "GET"},
+		{http.MethodPatch, "PATCH"},
+		{http.MethodOptions, "OPTIONS"},
+		{"PROPFIND", "OTHER"},
+		{"get", "OTHER"},
+		{"X-" + strings.Repeat("A", 100), "OTHER"},
+	}
+	for _, tt := range tests {
+		if got := methodOf(httptest.NewRequest(tt.method, "/api/products", nil)); got != tt.want {
+			t.Errorf("methodOf(%.10q) = %s, ожидалось %s", tt.method, got, tt.want)
+		}
+	}
+}
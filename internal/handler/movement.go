@@ -0,0 +1,148 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetInventoryMovements возвращает журнал движения товара на складе
+// @Summary Получить журнал движения товара
+// @Description Возвращает движения товара на складе (поступления, продажи, корректировки и т.д.), начиная с последних
+// @Tags inventory
+// @Produce json
+// @Param warehouse_id path string true "ID склада"
+// @Param product_id path string true "ID товара"
+// @Param from query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода, не включительно (RFC3339 или YYYY-MM-DD)"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество записей на странице" default(50)
+// @Success 200 {array} domain.StockMovement
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/warehouses/{warehouse_id}/products/{product_id}/movements [get]
+func (h *Handler) GetInventoryMovements(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	warehouseID, err := uuid.Parse(r.PathValue("warehouse_id"))
+	if err != nil {
+		logger.Error("Некорректный формат ID склада", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID склада")
+		return
+	}
+
+	productID, err := uuid.Parse(r.PathValue("product_id"))
+	if err != nil {
+		logger.Error("Некорректный формат ID товара", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID товара")
+		return
+	}
+
+	query := r.URL.Query()
+	filter := domain.MovementFilter{
+		Page:  1,
+		Limit: 50,
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := parseTime(fromStr)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный формат параметра from")
+			return
+		}
+		filter.From = &from
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := parseTime(toStr)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный формат параметра to")
+			return
+		}
+		filter.To = &to
+	}
+
+	if pageStr := query.Get("page"); pageStr != "" {
+		pageVal, err := strconv.Atoi(pageStr)
+		if err == nil && pageVal > 0 {
+			filter.Page = pageVal
+		}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limitVal, err := strconv.Atoi(limitStr)
+		if err == nil && limitVal > 0 {
+			filter.Limit = limitVal
+		}
+	}
+
+	movements, err := h.inventory.Movements(ctx, warehouseID, productID, filter)
+	if err != nil {
+		logger.Error("Ошибка при получении журнала движения", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при получении журнала движения")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, movements)
+}
+
+// RebuildInventory сверяет остатки с журналом движения и при необходимости восстанавливает их
+// @Summary Восстановить остатки по журналу движения
+// @Description Сравнивает количество товара с суммой журнала движения. Без dry_run расходящиеся остатки заменяются значением из журнала.
+// @Description Остатки, для которых значение из журнала меньше резерва, не изменяются и возвращаются с conflict=true
+// @Tags inventory
+// @Produce json
+// @Param warehouse_id query string false "ID склада; по умолчанию все склады"
+// @Param dry_run query bool false "Только показать расхождения"
+// @Success 200 {array} domain.LedgerDiscrepancy
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/inventory/rebuild [post]
+func (h *Handler) RebuildInventory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	query := r.URL.Query()
+
+	var warehouseID *uuid.UUID
+	if warehouseIDStr := query.Get("warehouse_id"); warehouseIDStr != "" {
+		id, err := uuid.Parse(warehouseIDStr)
+		if err != nil {
+			logger.Error("Некорректный формат ID склада", zap.Error(err))
+			writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID склада")
+			return
+		}
+		warehouseID = &id
+	}
+
+	dryRun := false
+	if dryRunStr := query.Get("dry_run"); dryRunStr != "" {
+		val, err := strconv.ParseBool(dryRunStr)
+		if err != nil {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный формат параметра dry_run")
+			return
+		}
+		dryRun = val
+	}
+
+	if !h.authorizeWarehouseFilter(w, r, warehouseID) {
+		return
+	}
+
+	discrepancies, err := h.inventory.RebuildFromLedger(ctx, warehouseID, dryRun)
+	if err != nil {
+		logger.Error("Ошибка при восстановлении остатков по журналу", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при восстановлении остатков по журналу")
+		return
+	}
+
+	if !dryRun && len(discrepancies) > 0 {
+		logger.Info("Остатки восстановлены по журналу движения", zap.Int("count", len(discrepancies)))
+	}
+
+	writeJSON(w, http.StatusOK, discrepancies)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetOrders возвращает список заказов
+// @Summary Получить список заказов
+// @Description Возвращает заказы с фильтрацией по складу и периоду, с пагинацией
+// @Tags orders
+// @Produce json
+// @Param warehouse_id query string false "ID склада"
+// @Param from query string false "Начало периода (RFC3339 или YYYY-MM-DD)"
+// @Param to query string false "Конец периода, не включительно (RFC3339 или YYYY-MM-DD)"
+// @Param page query int false "Номер страницы" default(1)
+// @Param limit query int false "Количество заказов на странице" default(10)
+// @Success 200 {array} domain.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/orders [get]
+func (h *Handler) GetOrders(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	query := r.URL.Query()
+	filter := domain.OrderFilter{
+		Page:  1,
+		Limit: 10,
+	}
+
+	if warehouseIDStr := query.Get("warehouse_id"); warehouseIDStr != "" {
+		warehouseID, err := uuid.Parse(warehouseIDStr)
+		if err != nil {
+			logger.Error("Некорректный формат ID склада", zap.Error(err))
+			writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID склада")
+			return
+		}
+		filter.WarehouseID = &warehouseID
+	}
+
+	if fromStr := query.Get("from"); fromStr != "" {
+		from, err := parseTime(fromStr)
+		if err != nil {
+			logger.Error("Некорректный формат параметра from", zap.Error(err))
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный формат параметра from")
+			return
+		}
+		filter.From = &from
+	}
+
+	if toStr := query.Get("to"); toStr != "" {
+		to, err := parseTime(toStr)
+		if err != nil {
+			logger.Error("Некорректный формат параметра to", zap.Error(err))
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный формат параметра to")
+			return
+		}
+		filter.To = &to
+	}
+
+	if pageStr := query.Get("page"); pageStr != "" {
+		pageVal, err := strconv.Atoi(pageStr)
+		if err == nil && pageVal > 0 {
+			filter.Page = pageVal
+		}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limitVal, err := strconv.Atoi(limitStr)
+		if err == nil && limitVal > 0 {
+			filter.Limit = limitVal
+		}
+	}
+
+	if !h.authorizeWarehouseFilter(w, r, filter.WarehouseID) {
+		return
+	}
+
+	orders, err := h.sales.ListOrders(ctx, filter)
+	if err != nil {
+		logger.Error("Ошибка при получении списка заказов", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при получении списка заказов")
+		return
+	}
+
+	if orders == nil {
+		orders = []domain.Order{}
+	}
+
+	writeJSON(w, http.StatusOK, orders)
+}
+
+// GetOrder возвращает заказ по ID
+// @Summary Получить заказ
+// @Description Возвращает заказ с позициями, ценами и скидками, примененными при покупке
+// @Tags orders
+// @Produce json
+// @Param id path string true "ID заказа"
+// @Success 200 {object} domain.Order
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/orders/{id} [get]
+func (h *Handler) GetOrder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		logger.Error("Некорректный формат ID заказа", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID заказа")
+		return
+	}
+
+	order, err := h.sales.GetOrder(ctx, id)
+	if err != nil {
+		logger.Error("Ошибка при получении заказа", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при получении заказа")
+		return
+	}
+	if !h.authorizeWarehouse(w, r, order.WarehouseID) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, order)
+}
+
+// parseTime парсит время в формате RFC3339 или дату в формате YYYY-MM-DD
+func parseTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, s)
+}
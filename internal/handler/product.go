@@ -1,34 +1,107 @@
 package handler
 
 import (
+	"bytes"
 	"encoding/json"
+	"mime"
 	"net/http"
+	"strconv"
 
 	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/pkg/mergepatch"
 	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
-// GetProducts возвращает список всех товаров
-// @Summary Получить список всех товаров
-// @Description Возвращает список всех товаров в системе
+// mergePatchMediaType тип содержимого JSON Merge Patch (RFC 7396)
+const mergePatchMediaType = "application/merge-patch+json"
+
+// GetProducts возвращает страницу каталога товаров
+// @Summary Получить список товаров
+// @Description Возвращает товары с полнотекстовым поиском, фильтрами и keyset-пагинацией. Для следующей страницы передайте next_cursor в параметре cursor с теми же фильтрами и сортировкой. Ответ - объект со страницей товаров, а не массив всех товаров, как в предыдущих версиях API
 // @Tags products
 // @Produce json
-// @Success 200 {array} domain.Product
-// @Failure 500 {object} map[string]string
+// @Param q query string false "Полнотекстовый поиск по названию и описанию (русский и английский)"
+// @Param barcode query string false "Штрихкод"
+// @Param min_weight query number false "Минимальный вес, включительно"
+// @Param max_weight query number false "Максимальный вес, включительно"
+// @Param sort query string false "Сортировка: name, -name, weight, -weight, barcode, -barcode" default(name)
+// @Param limit query int false "Количество товаров на странице, не больше 200" default(50)
+// @Param cursor query string false "Позиция следующей страницы из next_cursor"
+// @Success 200 {object} domain.ProductPage
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /api/products [get]
 func (h *Handler) GetProducts(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := h.logger.WithRequestID(ctx)
 
-	products, err := h.productRepo.GetAll(ctx)
+	filter, ok := parseProductFilter(w, r)
+	if !ok {
+		return
+	}
+
+	products, next, err := h.products.List(ctx, filter)
 	if err != nil {
 		logger.Error("Ошибка при получении списка товаров", zap.Error(err))
-		writeError(w, "Ошибка при получении списка товаров", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при получении списка товаров")
 		return
 	}
 
-	writeJSON(w, http.StatusOK, products)
+	page := domain.ProductPage{Items: products}
+	if page.Items == nil {
+		page.Items = []domain.Product{}
+	}
+	if next != nil {
+		page.NextCursor = encodeProductCursor(*next)
+	}
+
+	writeJSON(w, http.StatusOK, page)
+}
+
+// GetProductFacets возвращает значения характеристик товаров для построения фильтров
+// @Summary Получить фасеты характеристик товаров
+// @Description Возвращает характеристики товаров, подходящих под фильтр каталога, и число товаров с каждым значением. Вложенные характеристики возвращаются путями через точку, которые можно передать в параметре attr.<путь>
+// @Tags products
+// @Produce json
+// @Param q query string false "Полнотекстовый поиск по названию и описанию (русский и английский)"
+// @Param barcode query string false "Штрихкод"
+// @Param min_weight query number false "Минимальный вес, включительно"
+// @Param max_weight query number false "Максимальный вес, включительно"
+// @Param limit query int false "Количество самых частых значений каждой характеристики, не больше 100" default(20)
+// @Success 200 {array} domain.AttributeFacet
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/products/facets [get]
+func (h *Handler) GetProductFacets(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	filter, ok := parseProductConditions(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultFacetLimit
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+		limitVal, err := strconv.Atoi(limitStr)
+		if err == nil && limitVal > 0 {
+			limit = min(limitVal, maxFacetLimit)
+		}
+	}
+
+	facets, err := h.products.Facets(ctx, filter, limit)
+	if err != nil {
+		logger.Error("Ошибка при получении фасетов товаров", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при получении фасетов товаров")
+		return
+	}
+
+	if facets == nil {
+		facets = []domain.AttributeFacet{}
+	}
+
+	writeJSON(w, http.StatusOK, facets)
 }
 
 // CreateProduct создает новый товар
@@ -39,31 +112,56 @@ func (h *Handler) GetProducts(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param product body domain.Product true "Информация о товаре"
 // @Success 201 {object} domain.Product
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /api/products [post]
 func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := h.logger.WithRequestID(ctx)
 
 	var product domain.Product
-	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
-		logger.Error("Ошибка при декодировании запроса", zap.Error(err))
-		writeError(w, "Некорректный формат запроса", http.StatusBadRequest)
+	if !h.decodeRequest(w, r, &product) {
 		return
 	}
 
-	createdProduct, err := h.productRepo.Create(ctx, product)
+	createdProduct, err := h.products.Create(ctx, product)
 	if err != nil {
 		logger.Error("Ошибка при создании товара", zap.Error(err))
-		writeError(w, "Ошибка при создании товара", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при создании товара")
 		return
 	}
 
+	setETag(w, createdProduct.Version)
 	writeJSON(w, http.StatusCreated, createdProduct)
 }
 
-// UpdateProduct обновляет существующий товар
+// GetProduct возвращает товар по ID; версия товара возвращается в заголовке ETag
+func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	idStr := r.PathValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		logger.Error("Некорректный формат ID", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID")
+		return
+	}
+
+	product, err := h.products.Get(ctx, id)
+	if err != nil {
+		logger.Error("Ошибка при получении товара", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при получении товара")
+		return
+	}
+
+	setETag(w, product.Version)
+	writeJSON(w, http.StatusOK, product)
+}
+
+// UpdateProduct обновляет существующий товар.
+// Заголовок If-Match с ETag товара обязателен: если товар успел измениться, возвращается 412
 func (h *Handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := h.logger.WithRequestID(ctx)
@@ -72,24 +170,120 @@ func (h *Handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	id, err := uuid.Parse(idStr)
 	if err != nil {
 		logger.Error("Некорректный формат ID", zap.Error(err))
-		writeError(w, "Некорректный формат ID", http.StatusBadRequest)
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID")
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	var product domain.Product
+	if !h.decodeRequest(w, r, &product) {
+		return
+	}
+
+	product.ID = id
+	updatedProduct, err := h.products.Update(ctx, product, version)
+	if err != nil {
+		logger.Error("Ошибка при обновлении товара", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при обновлении товара")
+		return
+	}
+
+	setETag(w, updatedProduct.Version)
+	writeJSON(w, http.StatusOK, updatedProduct)
+}
+
+// PatchProduct частично обновляет товар по JSON Merge Patch (RFC 7396): переданные поля
+// заменяются, вложенные объекты в characteristics объединяются, а null удаляет поле.
+// Результат проверяется по тем же правилам, что и при создании товара.
+// Заголовок If-Match с ETag товара обязателен: если товар успел измениться, возвращается 412
+func (h *Handler) PatchProduct(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	idStr := r.PathValue("id")
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		logger.Error("Некорректный формат ID", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID")
+		return
+	}
+
+	if !acceptsMergePatch(r) {
+		w.Header().Set("Accept-Patch", mergePatchMediaType)
+		writeErrorDetails(w, r, http.StatusUnsupportedMediaType, CodeUnsupportedMedia, "Поддерживается только JSON Merge Patch",
+			map[string]string{"content_type": mergePatchMediaType})
+		return
+	}
+
+	version, ok := ifMatch(w, r)
+	if !ok {
+		return
+	}
+
+	patch, ok := h.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	current, err := h.products.Get(ctx, id)
+	if err != nil {
+		logger.Error("Ошибка при получении товара", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при обновлении товара")
+		return
+	}
+	// Патч применяется к прочитанной версии, поэтому и при If-Match: * товар
+	// обновляется, только если он не изменился после чтения
+	if version == 0 {
+		version = current.Version
+	}
+
+	doc, err := json.Marshal(current)
+	if err != nil {
+		logger.Error("Ошибка при сериализации товара", zap.Error(err))
+		writeError(w, r, http.StatusInternalServerError, CodeInternal, "Ошибка при обновлении товара")
+		return
+	}
+	merged, err := mergepatch.Apply(doc, patch)
+	if err != nil {
+		logger.Error("Ошибка при применении патча", zap.Error(err))
+		writeErrorDetails(w, r, http.StatusBadRequest, CodeInvalidJSON, "Некорректный формат запроса",
+			map[string]string{"error": decodeErrorMessage(err)})
 		return
 	}
 
 	var product domain.Product
-	if err := json.NewDecoder(r.Body).Decode(&product); err != nil {
-		logger.Error("Ошибка при декодировании запроса", zap.Error(err))
-		writeError(w, "Некорректный формат запроса", http.StatusBadRequest)
+	if !h.decodeJSON(w, r, bytes.NewReader(merged), &product) {
 		return
 	}
 
 	product.ID = id
-	updatedProduct, err := h.productRepo.Update(ctx, product)
+	updatedProduct, err := h.products.Update(ctx, product, version)
 	if err != nil {
 		logger.Error("Ошибка при обновлении товара", zap.Error(err))
-		writeError(w, "Ошибка при обновлении товара", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при обновлении товара")
 		return
 	}
 
+	setETag(w, updatedProduct.Version)
 	writeJSON(w, http.StatusOK, updatedProduct)
 }
+
+// acceptsMergePatch проверяет тип содержимого запроса PATCH.
+// Кроме application/merge-patch+json принимается application/json и запрос без типа,
+// чтобы клиентам без поддержки RFC 7396 не приходилось задавать заголовок
+func acceptsMergePatch(r *http.Request) bool {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == mergePatchMediaType || mediaType == "application/json"
+}
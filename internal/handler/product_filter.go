@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+// Размер страницы каталога товаров
+const (
+	defaultProductLimit = 50
+	maxProductLimit     = 200
+)
+
+// Число значений каждой характеристики в ответе GetProductFacets
+const (
+	defaultFacetLimit = 20
+	maxFacetLimit     = 100
+)
+
+// attributeParamPrefix начинает параметры фильтров по характеристикам товара
+const attributeParamPrefix = "attr."
+
+// maxAttributeFilters ограничивает число условий на характеристики в одном запросе
+const maxAttributeFilters = 20
+
+// maxAttributeValues ограничивает число значений одного параметра attr:
+// каждое значение eq или ne добавляет в SQL запрос до двух условий вхождения
+const maxAttributeValues = 20
+
+// attributeOps содержит операции, которые можно указать последним сегментом параметра attr
+var attributeOps = map[domain.AttributeOp]bool{
+	domain.AttributeEq:  true,
+	domain.AttributeNe:  true,
+	domain.AttributeGt:  true,
+	domain.AttributeGte: true,
+	domain.AttributeLt:  true,
+	domain.AttributeLte: true,
+}
+
+// errInvalidCursor возвращается для курсора без ID товара
+var errInvalidCursor = errors.New("некорректный курсор")
+
+// parseProductFilter разбирает параметры выборки каталога товаров с сортировкой и пагинацией.
+// При некорректном параметре записывает ответ 400 и возвращает false
+func parseProductFilter(w http.ResponseWriter, r *http.Request) (domain.ProductFilter, bool) {
+	filter, ok := parseProductConditions(w, r)
+	if !ok {
+		return domain.ProductFilter{}, false
+	}
+	filter.Sort = domain.ProductSortName
+	filter.Limit = defaultProductLimit
+
+	query := r.URL.Query()
+
+	if sortStr := query.Get("sort"); sortStr != "" {
+		sort := domain.ProductSort(sortStr)
+		switch sort {
+		case domain.ProductSortName, domain.ProductSortNameDesc,
+			domain.ProductSortWeight, domain.ProductSortWeightDesc,
+			domain.ProductSortBarcode, domain.ProductSortBarcodeDesc:
+			filter.Sort = sort
+		default:
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный порядок сортировки: "+sortStr)
+			return domain.ProductFilter{}, false
+		}
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limitVal, err := strconv.Atoi(limitStr)
+		if err == nil && limitVal > 0 {
+			filter.Limit = min(limitVal, maxProductLimit)
+		}
+	}
+
+	if cursorStr := query.Get("cursor"); cursorStr != "" {
+		cursor, err := decodeProductCursor(cursorStr)
+		if err != nil || cursor.Sort != filter.Sort {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный параметр cursor")
+			return domain.ProductFilter{}, false
+		}
+		filter.After = &cursor
+	}
+
+	return filter, true
+}
+
+// parseProductConditions разбирает условия отбора каталога товаров: поиск, штрихкод,
+// диапазон веса и характеристики. При некорректном параметре записывает ответ 400 и возвращает false
+func parseProductConditions(w http.ResponseWriter, r *http.Request) (domain.ProductFilter, bool) {
+	query := r.URL.Query()
+	filter := domain.ProductFilter{
+		Search:  query.Get("q"),
+		Barcode: query.Get("barcode"),
+	}
+
+	for _, p := range []struct {
+		name string
+		dst  **float64
+	}{
+		{"min_weight", &filter.MinWeight},
+		{"max_weight", &filter.MaxWeight},
+	} {
+		s := query.Get(p.name)
+		if s == "" {
+			continue
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный формат параметра "+p.name)
+			return domain.ProductFilter{}, false
+		}
+		*p.dst = &v
+	}
+	if filter.MinWeight != nil && filter.MaxWeight != nil && *filter.MinWeight > *filter.MaxWeight {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Параметр min_weight больше max_weight")
+		return domain.ProductFilter{}, false
+	}
+
+	attributes, ok := parseAttributeFilters(w, r)
+	if !ok {
+		return domain.ProductFilter{}, false
+	}
+	filter.Attributes = attributes
+
+	return filter, true
+}
+
+// parseAttributeFilters разбирает фильтры по характеристикам товара вида attr.<путь>[.<операция>]=<значение>,
+// например attr.color=red или attr.size.gte=42. Путь к вложенной характеристике записывается через точку.
+// Повторенный параметр eq или ne задает несколько допустимых значений, повторенное сравнение - несколько условий.
+// Число значений одного параметра и общее число условий ограничены.
+// При некорректном параметре записывает ответ 400 и возвращает false
+func parseAttributeFilters(w http.ResponseWriter, r *http.Request) ([]domain.AttributeFilter, bool) {
+	query := r.URL.Query()
+
+	var keys []string
+	for key := range query {
+		if strings.HasPrefix(key, attributeParamPrefix) {
+			keys = append(keys, key)
+		}
+	}
+	// Порядок условий не зависит от порядка параметров, чтобы запросы совпадали
+	slices.Sort(keys)
+
+	var filters []domain.AttributeFilter
+	for _, key := range keys {
+		path := strings.Split(strings.TrimPrefix(key, attributeParamPrefix), ".")
+		op := domain.AttributeEq
+		if n := len(path); n > 1 {
+			if candidate := domain.AttributeOp(path[n-1]); attributeOps[candidate] {
+				op, path = candidate, path[:n-1]
+			}
+		}
+		if slices.Contains(path, "") {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный путь к характеристике: "+key)
+			return nil, false
+		}
+
+		values := query[key]
+		if len(values) > maxAttributeValues {
+			writeError(w, r, http.StatusBadRequest, CodeInvalidParameter,
+				fmt.Sprintf("Слишком много значений параметра %s, допустимо не больше %d", key, maxAttributeValues))
+			return nil, false
+		}
+		if !op.Ordered() {
+			filters = append(filters, domain.AttributeFilter{Path: path, Op: op, Values: values})
+			continue
+		}
+		for _, s := range values {
+			v, err := strconv.ParseFloat(s, 64)
+			if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+				writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный формат параметра "+key+": ожидается число")
+				return nil, false
+			}
+			filters = append(filters, domain.AttributeFilter{Path: path, Op: op, Number: v})
+		}
+	}
+
+	if len(filters) > maxAttributeFilters {
+		writeError(w, r, http.StatusBadRequest, CodeInvalidParameter,
+			fmt.Sprintf("Слишком много фильтров по характеристикам, допустимо не больше %d", maxAttributeFilters))
+		return nil, false
+	}
+
+	return filters, true
+}
+
+// encodeProductCursor кодирует позицию в каталоге в непрозрачную строку для параметра cursor
+func encodeProductCursor(cursor domain.ProductCursor) string {
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeProductCursor разбирает строку, полученную от encodeProductCursor
+func decodeProductCursor(s string) (domain.ProductCursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return domain.ProductCursor{}, err
+	}
+
+	var cursor domain.ProductCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return domain.ProductCursor{}, err
+	}
+	if cursor.ID == uuid.Nil {
+		return domain.ProductCursor{}, errInvalidCursor
+	}
+
+	return cursor, nil
+}
@@ -0,0 +1,191 @@
+package handler
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+// parseAttributes разбирает фильтры по характеристикам из строки запроса query
+func parseAttributes(t *testing.T, query string) ([]domain.AttributeFilter, *httptest.ResponseRecorder) {
+	t.Helper()
+
+	w := httptest.NewRecorder()
+	filters, ok := parseAttributeFilters(w, httptest.NewRequest(http.MethodGet, "/api/products?"+query, nil))
+	if ok != (w.Code == http.StatusOK) {
+		t.Fatalf("результат %v, статус %d", ok, w.Code)
+	}
+	return filters, w
+}
+
+func TestParseAttributeFilters(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []domain.AttributeFilter
+	}{
+		{"без фильтров", "q=shoe", nil},
+		{"равенство", "attr.color=red",
+			[]domain.AttributeFilter{{Path: []string{"color"}, Op: domain.AttributeEq, Values: []string{"red"}}}},
+		{"несколько значений", "attr.color=red&attr.color=blue",
+			[]domain.AttributeFilter{{Path: []string{"color"}, Op: domain.AttributeEq, Values: []string{"red", "blue"}}}},
+		{"неравенство вложенной характеристики", "attr.size.eu.ne=42",
+			[]domain.AttributeFilter{{Path: []string{"size", "eu"}, Op: domain.AttributeNe, Values: []string{"42"}}}},
+		{"повторенное сравнение", "attr.weight.gte=1&attr.weight.gte=2.5",
+			[]domain.AttributeFilter{
+				{Path: []string{"weight"}, Op: domain.AttributeGte, Number: 1},
+				{Path: []string{"weight"}, Op: domain.AttributeGte, Number: 2.5},
+			}},
+		{"неизвестная операция входит в путь", "attr.size.max=42",
+			[]domain.AttributeFilter{{Path: []string{"size", "max"}, Op: domain.AttributeEq, Values: []string{"42"}}}},
+		{"имя операции без пути - характеристика", "attr.gte=1",
+			[]domain.AttributeFilter{{Path: []string{"gte"}, Op: domain.AttributeEq, Values: []string{"1"}}}},
+		{"условия упорядочены по имени параметра", "attr.size.lt=50&attr.color=red",
+			[]domain.AttributeFilter{
+				{Path: []string{"color"}, Op: domain.AttributeEq, Values: []string{"red"}},
+				{Path: []string{"size"}, Op: domain.AttributeLt, Number: 50},
+			}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := parseAttributes(t, tt.query)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("фильтры %+v, ожидалось %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAttributeFiltersRejectsInvalid(t *testing.T) {
+	tooManyValues := strings.Repeat("attr.color=red&", maxAttributeValues+1)
+
+	tooManyFilters := url.Values{}
+	for i := 0; i <= maxAttributeFilters; i++ {
+		tooManyFilters.Add("attr.size"+strconv.Itoa(i), "1")
+	}
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"пустой путь", "attr.=red"},
+		{"пустой сегмент пути", "attr.size..eu=42"},
+		{"сравнение не с числом", "attr.size.gte=big"},
+		{"сравнение с NaN", "attr.size.gte=NaN"},
+		{"слишком много значений одного параметра", tooManyValues},
+		{"слишком много условий", tooManyFilters.Encode()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, w := parseAttributes(t, tt.query)
+			assertError(t, w, http.StatusBadRequest, CodeInvalidParameter)
+		})
+	}
+}
+
+func TestProductCursorRoundTrip(t *testing.T) {
+	cursor := domain.ProductCursor{Sort: domain.ProductSortWeightDesc, Weight: 1.5, ID: uuid.New()}
+
+	encoded := encodeProductCursor(cursor)
+	if strings.ContainsAny(encoded, "+/=") {
+		t.Errorf("курсор %q нельзя передать в URL без экранирования", encoded)
+	}
+	decoded, err := decodeProductCursor(encoded)
+	if err != nil {
+		t.Fatalf("разбор курсора: %v", err)
+	}
+	if decoded != cursor {
+		t.Errorf("курсор %+v, ожидался %+v", decoded, cursor)
+	}
+}
+
+func TestGetProductsRejectsInvalidCursor(t *testing.T) {
+	a := newTestAPI(t)
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+	}{
+		{"не base64", "not a cursor!"},
+		{"не JSON", encode("name:Ноутбук")},
+		{"без ID товара", encode(`{"sort":"name","name":"Ноутбук"}`)},
+		{"ID не UUID", encode(`{"sort":"name","name":"Ноутбук","id":"42"}`)},
+		{"другая сортировка", encodeProductCursor(domain.ProductCursor{Sort: domain.ProductSortWeight, ID: uuid.New()})},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := a.do(http.MethodGet, "/api/products?sort=name&cursor="+url.QueryEscape(tt.cursor), "", nil)
+			assertError(t, w, http.StatusBadRequest, CodeInvalidParameter)
+		})
+	}
+}
+
+func TestGetProductsPagination(t *testing.T) {
+	a := newTestAPI(t)
+
+	// Одинаковые названия проверяют упорядочивание по ID внутри значения сортировки
+	var want []uuid.UUID
+	for range 5 {
+		want = append(want, a.seedProduct(`{}`).ID)
+	}
+	slices.SortFunc(want, func(x, y uuid.UUID) int { return strings.Compare(x.String(), y.String()) })
+
+	for _, sort := range []domain.ProductSort{domain.ProductSortName, domain.ProductSortNameDesc} {
+		t.Run(string(sort), func(t *testing.T) {
+			var got []uuid.UUID
+			target := "/api/products?limit=2&sort=" + url.QueryEscape(string(sort))
+			for pages := 1; ; pages++ {
+				w := a.do(http.MethodGet, target, "", nil)
+				if w.Code != http.StatusOK {
+					t.Fatalf("страница %d: статус %d: %s", pages, w.Code, w.Body.String())
+				}
+				page := decodeBody[domain.ProductPage](t, w)
+				for _, p := range page.Items {
+					got = append(got, p.ID)
+				}
+				if page.NextCursor == "" {
+					// Последняя страница неполная: 5 товаров по 2 на странице
+					if pages != 3 || len(page.Items) != 1 {
+						t.Errorf("последняя страница %d содержит %d товаров, ожидалась 3-я с 1 товаром", pages, len(page.Items))
+					}
+					break
+				}
+				if pages > len(want) {
+					t.Fatal("пагинация не завершилась")
+				}
+				target = "/api/products?limit=2&sort=" + url.QueryEscape(string(sort)) + "&cursor=" + page.NextCursor
+			}
+
+			expected := slices.Clone(want)
+			if sort.Desc() {
+				slices.Reverse(expected)
+			}
+			if !slices.Equal(got, expected) {
+				t.Errorf("товары %v, ожидалось %v", got, expected)
+			}
+		})
+	}
+}
+
+func TestGetProductsEmptyPage(t *testing.T) {
+	a := newTestAPI(t)
+
+	w := a.do(http.MethodGet, "/api/products?q=nothing", "", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("статус %d: %s", w.Code, w.Body.String())
+	}
+	// Пустой каталог возвращается пустым массивом items без next_cursor
+	if body := strings.TrimSpace(w.Body.String()); body != `{"items":[]}` {
+		t.Errorf("ответ %s, ожидалось {\"items\":[]}", body)
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+// ifMatchHeader возвращает заголовок If-Match со значением value
+func ifMatchHeader(value string) http.Header {
+	return http.Header{"If-Match": {value}}
+}
+
+func TestPatchProductMergesCharacteristics(t *testing.T) {
+	api := newTestAPI(t)
+	product := api.seedProduct(`{"color":"red","size":{"eu":42,"us":9},"tags":["a","b"]}`)
+
+	w := api.do(http.MethodPatch, "/api/products/"+product.ID.String(),
+		`{"description":"new","characteristics":{"color":null,"size":{"us":10},"tags":["c"]}}`,
+		ifMatchHeader(`"1"`))
+	if w.Code != http.StatusOK {
+		t.Fatalf("статус %d, ожидался 200: %s", w.Code, w.Body.String())
+	}
+	if got := w.Header().Get("ETag"); got != `"2"` {
+		t.Errorf("ETag %s, ожидался \"2\"", got)
+	}
+
+	updated := decodeBody[domain.Product](t, w)
+	if updated.Name != product.Name || updated.Description != "new" {
+		t.Errorf("название %q и описание %q, ожидалось %q и new", updated.Name, updated.Description, product.Name)
+	}
+	var got, want interface{}
+	if err := json.Unmarshal(updated.Characteristics, &got); err != nil {
+		t.Fatalf("разбор характеристик: %v", err)
+	}
+	json.Unmarshal([]byte(`{"size":{"eu":42,"us":10},"tags":["c"]}`), &want)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("характеристики %s, ожидалось объединение с удалением color", updated.Characteristics)
+	}
+}
+
+func TestPatchProductPreconditions(t *testing.T) {
+	api := newTestAPI(t)
+	product := api.seedProduct(`{}`)
+	target := "/api/products/" + product.ID.String()
+
+	t.Run("без If-Match", func(t *testing.T) {
+		w := api.do(http.MethodPatch, target, `{"name":"new"}`, nil)
+		assertError(t, w, http.StatusPreconditionRequired, CodePreconditionRequired)
+	})
+
+	t.Run("устаревшая версия", func(t *testing.T) {
+		w := api.do(http.MethodPatch, target, `{"name":"new"}`, ifMatchHeader(`"5"`))
+		assertError(t, w, http.StatusPreconditionFailed, CodeVersionMismatch)
+	})
+
+	t.Run("повтор после изменения", func(t *testing.T) {
+		if w := api.do(http.MethodPatch, target, `{"name":"first"}`, ifMatchHeader(`"1"`)); w.Code != http.StatusOK {
+			t.Fatalf("первое изменение: статус %d: %s", w.Code, w.Body.String())
+		}
+		w := api.do(http.MethodPatch, target, `{"name":"second"}`, ifMatchHeader(`"1"`))
+		assertError(t, w, http.StatusPreconditionFailed, CodeVersionMismatch)
+	})
+
+	t.Run("неподдерживаемый тип содержимого", func(t *testing.T) {
+		header := ifMatchHeader(`"2"`)
+		header.Set("Content-Type", "application/json-patch+json")
+		w := api.do(http.MethodPatch, target, `[]`, header)
+		assertError(t, w, http.StatusUnsupportedMediaType, CodeUnsupportedMedia)
+	})
+}
+
+func TestPatchProductRevalidatesMergedDocument(t *testing.T) {
+	api := newTestAPI(t)
+	product := api.seedProduct(`{}`)
+	target := "/api/products/" + product.ID.String()
+
+	tests := []struct {
+		name  string
+		patch string
+		code  ErrorCode
+	}{
+		{"null удаляет обязательное поле", `{"name":null}`, CodeValidationFailed},
+		{"недопустимый вес", `{"weight":-1}`, CodeValidationFailed},
+		{"неизвестное поле", `{"unknown":1}`, CodeInvalidJSON},
+		{"некорректный JSON", `{"name":`, CodeInvalidJSON},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := api.do(http.MethodPatch, target, tt.patch, ifMatchHeader(`"1"`))
+			assertError(t, w, http.StatusBadRequest, tt.code)
+		})
+	}
+
+	// Отклоненные патчи не изменяют товар
+	w := api.do(http.MethodGet, target, "", nil)
+	if got := w.Header().Get("ETag"); got != `"1"` {
+		t.Errorf("ETag после отклоненных патчей %s, ожидался \"1\"", got)
+	}
+}
+
+func TestCreateProductRejectsLargeBody(t *testing.T) {
+	a := newTestAPI(t)
+
+	body := `{"name":"test","description":"` + strings.Repeat("x", maxRequestBodySize) + `","weight":1,"barcode":"1"}`
+	w := a.do(http.MethodPost, "/api/products", body, nil)
+
+	assertError(t, w, http.StatusRequestEntityTooLarge, CodeRequestTooLarge)
+}
+
+func TestCreateProductValidatesBody(t *testing.T) {
+	a := newTestAPI(t)
+
+	tests := []struct {
+		name  string
+		body  string
+		code  ErrorCode
+		field string
+	}{
+		{"два документа подряд", `{"name":"test","characteristics":{},"weight":1,"barcode":"1"}{"name":"other"}`, CodeInvalidJSON, ""},
+		{"данные после документа", `{"name":"test","characteristics":{},"weight":1,"barcode":"1"} x`, CodeInvalidJSON, ""},
+		{"характеристики массивом", `{"name":"test","characteristics":["red"],"weight":1,"barcode":"1"}`, CodeValidationFailed, "characteristics"},
+		{"характеристики строкой", `{"name":"test","characteristics":"red","weight":1,"barcode":"1"}`, CodeValidationFailed, "characteristics"},
+		{"характеристики null", `{"name":"test","characteristics":null,"weight":1,"barcode":"1"}`, CodeValidationFailed, "characteristics"},
+		{"без характеристик", `{"name":"test","weight":1,"barcode":"1"}`, CodeValidationFailed, "characteristics"},
+		{"длинное описание", `{"name":"test","description":"` + strings.Repeat("x", 2001) + `","characteristics":{},"weight":1,"barcode":"1"}`,
+			CodeValidationFailed, "description"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := a.do(http.MethodPost, "/api/products", tt.body, nil)
+			assertError(t, w, http.StatusBadRequest, tt.code)
+			if tt.field == "" {
+				return
+			}
+
+			type validationDetails struct {
+				Details struct {
+					Fields []FieldError `json:"fields"`
+				} `json:"details"`
+			}
+			if fields := decodeBody[validationDetails](t, w).Details.Fields; len(fields) != 1 || fields[0].Field != tt.field {
+				t.Errorf("ошибки полей %+v, ожидалась ошибка поля %s", fields, tt.field)
+			}
+		})
+	}
+
+	// Пробельные символы после документа допустимы
+	w := a.do(http.MethodPost, "/api/products", "{\"name\":\"test\",\"characteristics\":{\"color\":\"red\"},\"weight\":1,\"barcode\":\"1\"}\n", nil)
+	if w.Code != http.StatusCreated {
+		t.Errorf("статус %d, ожидался 201: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// GetReservation возвращает резерв по ID
+// @Summary Получить резерв
+// @Description Возвращает резерв товаров, созданный при расчете стоимости
+// @Tags reservations
+// @Produce json
+// @Param id path string true "ID резерва"
+// @Success 200 {object} domain.Reservation
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/reservations/{id} [get]
+func (h *Handler) GetReservation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		logger.Error("Некорректный формат ID резерва", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID резерва")
+		return
+	}
+
+	reservation, err := h.sales.GetReservation(ctx, id)
+	if err != nil {
+		logger.Error("Ошибка при получении резерва", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при получении резерва")
+		return
+	}
+	if !h.authorizeWarehouse(w, r, reservation.WarehouseID) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
+
+// ReleaseReservation досрочно снимает резерв
+// @Summary Снять резерв
+// @Description Снимает активный резерв и возвращает товары в доступный остаток
+// @Tags reservations
+// @Produce json
+// @Param id path string true "ID резерва"
+// @Success 200 {object} domain.Reservation
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/reservations/{id} [delete]
+func (h *Handler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		logger.Error("Некорректный формат ID резерва", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID резерва")
+		return
+	}
+
+	// Склад резерва проверяется до снятия, чтобы не изменить чужой резерв
+	reservation, err := h.sales.GetReservation(ctx, id)
+	if err != nil {
+		logger.Error("Ошибка при снятии резерва", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при снятии резерва")
+		return
+	}
+	if !h.authorizeWarehouse(w, r, reservation.WarehouseID) {
+		return
+	}
+
+	reservation, err = h.sales.ReleaseReservation(ctx, id)
+	if err != nil {
+		logger.Error("Ошибка при снятии резерва", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при снятии резерва")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, reservation)
+}
@@ -15,11 +15,6 @@ func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	json.NewEncoder(w).Encode(data)
 }
 
-// writeError записывает ошибку в ответ
-func writeError(w http.ResponseWriter, message string, status int) {
-	http.Error(w, message, status)
-}
-
 // parseUUID парсит UUID из строки и логирует ошибку если не удалось
 func parseUUID(s string, logger *zap.Logger, fieldName string) (uuid.UUID, bool) {
 	id, err := uuid.Parse(s)
@@ -29,15 +24,3 @@ func parseUUID(s string, logger *zap.Logger, fieldName string) (uuid.UUID, bool)
 	}
 	return id, true
 }
-
-// HealthCheck проверяет работоспособность сервиса
-// @Summary Проверка работоспособности сервиса
-// @Description Возвращает статус 200 OK если сервис работает
-// @Tags health
-// @Produce json
-// @Success 200 {object} map[string]string
-// @Router /api/health [get]
-func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte(`{"status":"ok"}`))
-}
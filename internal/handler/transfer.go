@@ -0,0 +1,219 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// CreateTransfer создает перемещение товаров между складами
+// @Summary Создать перемещение
+// @Description Создает перемещение товаров между складами. При immediate=true товары списываются и зачисляются атомарно
+// @Tags transfers
+// @Accept json
+// @Produce json
+// @Param transfer body domain.TransferRequest true "Информация о перемещении"
+// @Success 201 {object} domain.Transfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 422 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/transfers [post]
+func (h *Handler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	var request domain.TransferRequest
+	if !h.decodeRequest(w, r, &request) {
+		return
+	}
+	if !h.authorizeWarehouse(w, r, request.SourceWarehouseID) {
+		return
+	}
+
+	transfer, err := h.transfers.Create(ctx, request, movementInfo(r, domain.MovementTransfer, ""))
+	if err != nil {
+		logger.Error("Ошибка при создании перемещения", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при создании перемещения")
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, transfer)
+}
+
+// GetTransfer возвращает перемещение по ID
+// @Summary Получить перемещение
+// @Tags transfers
+// @Produce json
+// @Param id path string true "ID перемещения"
+// @Success 200 {object} domain.Transfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/transfers/{id} [get]
+func (h *Handler) GetTransfer(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		logger.Error("Некорректный формат ID перемещения", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID перемещения")
+		return
+	}
+
+	transfer, err := h.transfers.Get(ctx, id)
+	if err != nil {
+		logger.Error("Ошибка при получении перемещения", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при получении перемещения")
+		return
+	}
+	if !h.authorizeWarehouse(w, r, transfer.SourceWarehouseID, transfer.DestinationWarehouseID) {
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transfer)
+}
+
+// DispatchTransfer отгружает перемещение со склада-источника
+// @Summary Отгрузить перемещение
+// @Description Списывает товары со склада-источника и переводит перемещение в статус in_transit
+// @Tags transfers
+// @Produce json
+// @Param id path string true "ID перемещения"
+// @Success 200 {object} domain.Transfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/transfers/{id}/dispatch [post]
+func (h *Handler) DispatchTransfer(w http.ResponseWriter, r *http.Request) {
+	h.changeTransfer(w, r, h.transfers.Dispatch, sourceWarehouse, "Ошибка при отгрузке перемещения")
+}
+
+// ReceiveTransfer принимает перемещение на складе назначения
+// @Summary Принять перемещение
+// @Description Зачисляет товары на склад назначения (создавая запись инвентаризации при необходимости) и переводит перемещение в статус received
+// @Tags transfers
+// @Produce json
+// @Param id path string true "ID перемещения"
+// @Success 200 {object} domain.Transfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/transfers/{id}/receive [post]
+func (h *Handler) ReceiveTransfer(w http.ResponseWriter, r *http.Request) {
+	h.changeTransfer(w, r, h.transfers.Receive, destinationWarehouse, "Ошибка при приемке перемещения")
+}
+
+// CancelTransfer отменяет перемещение
+// @Summary Отменить перемещение
+// @Description Отменяет перемещение; товары отгруженного перемещения возвращаются на склад-источник
+// @Tags transfers
+// @Produce json
+// @Param id path string true "ID перемещения"
+// @Success 200 {object} domain.Transfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 404 {object} ErrorResponse
+// @Failure 409 {object} ErrorResponse
+// @Router /api/transfers/{id}/cancel [post]
+func (h *Handler) CancelTransfer(w http.ResponseWriter, r *http.Request) {
+	h.changeTransfer(w, r, h.transfers.Cancel, sourceWarehouse, "Ошибка при отмене перемещения")
+}
+
+// sourceWarehouse возвращает склад-источник перемещения
+func sourceWarehouse(t domain.Transfer) uuid.UUID { return t.SourceWarehouseID }
+
+// destinationWarehouse возвращает склад назначения перемещения
+func destinationWarehouse(t domain.Transfer) uuid.UUID { return t.DestinationWarehouseID }
+
+// changeTransfer выполняет переход перемещения в следующий статус.
+// Клиент должен иметь доступ к складу, который возвращает warehouse
+func (h *Handler) changeTransfer(
+	w http.ResponseWriter,
+	r *http.Request,
+	change func(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error),
+	warehouse func(domain.Transfer) uuid.UUID,
+	errorMessage string,
+) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		logger.Error("Некорректный формат ID перемещения", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID перемещения")
+		return
+	}
+
+	transfer, err := h.transfers.Get(ctx, id)
+	if err != nil {
+		logger.Error(errorMessage, zap.Error(err))
+		writeServiceError(w, r, err, errorMessage)
+		return
+	}
+	if !h.authorizeWarehouse(w, r, warehouse(transfer)) {
+		return
+	}
+
+	transfer, err = change(ctx, id, movementInfo(r, domain.MovementTransfer, ""))
+	if err != nil {
+		logger.Error(errorMessage, zap.Error(err))
+		writeServiceError(w, r, err, errorMessage)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transfer)
+}
+
+// GetWarehouseTransfers возвращает перемещения склада
+// @Summary Получить перемещения склада
+// @Description Возвращает перемещения, в которых склад является источником или получателем. По умолчанию только открытые (pending, in_transit)
+// @Tags transfers
+// @Produce json
+// @Param id path string true "ID склада"
+// @Param status query string false "Статусы через запятую или all" default(pending,in_transit)
+// @Success 200 {array} domain.Transfer
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
+// @Router /api/warehouses/{id}/transfers [get]
+func (h *Handler) GetWarehouseTransfers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	logger := h.logger.WithRequestID(ctx)
+
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		logger.Error("Некорректный формат ID склада", zap.Error(err))
+		writeError(w, r, http.StatusBadRequest, CodeInvalidID, "Некорректный формат ID склада")
+		return
+	}
+
+	statuses := []domain.TransferStatus{domain.TransferPending, domain.TransferInTransit}
+	if statusStr := r.URL.Query().Get("status"); statusStr != "" {
+		statuses = nil
+		if statusStr != "all" {
+			for _, s := range strings.Split(statusStr, ",") {
+				status := domain.TransferStatus(strings.TrimSpace(s))
+				switch status {
+				case domain.TransferPending, domain.TransferInTransit, domain.TransferReceived, domain.TransferCancelled:
+					statuses = append(statuses, status)
+				default:
+					writeError(w, r, http.StatusBadRequest, CodeInvalidParameter, "Некорректный статус перемещения: "+string(status))
+					return
+				}
+			}
+		}
+	}
+
+	transfers, err := h.transfers.ListByWarehouse(ctx, id, statuses)
+	if err != nil {
+		logger.Error("Ошибка при получении перемещений склада", zap.Error(err))
+		writeServiceError(w, r, err, "Ошибка при получении перемещений склада")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, transfers)
+}
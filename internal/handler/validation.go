@@ -0,0 +1,242 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// maxRequestBodySize ограничивает размер тела запроса в байтах
+const maxRequestBodySize = 1 << 20
+
+// validate проверяет структуры запросов по тегам validate.
+// Экземпляр кэширует разобранные теги и безопасен для конкурентного использования
+var validate = newValidator()
+
+// FieldError описывает ошибку проверки одного поля запроса
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// newValidator создает валидатор, который называет поля по их JSON именам
+func newValidator() *validator.Validate {
+	v := validator.New(validator.WithRequiredStructEnabled())
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			return ""
+		}
+		return name
+	})
+	// uuid.UUID - массив байт, и правила вроде nefield сравнивали бы только его длину.
+	// Проверяем UUID как строку; нулевой UUID считается пустым значением для required
+	v.RegisterCustomTypeFunc(func(field reflect.Value) interface{} {
+		id := field.Interface().(uuid.UUID)
+		if id == uuid.Nil {
+			return ""
+		}
+		return id.String()
+	}, uuid.UUID{})
+	// json_object проверяет, что JSON документ из json.RawMessage является объектом
+	if err := v.RegisterValidation("json_object", func(fl validator.FieldLevel) bool {
+		var object map[string]json.RawMessage
+		return json.Unmarshal(fl.Field().Bytes(), &object) == nil && object != nil
+	}); err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// decodeRequest читает тело запроса размером не больше maxRequestBodySize в dst и проверяет его.
+// Неизвестные поля и данные после JSON документа считаются ошибкой. При ошибке ответ уже записан и возвращается false
+func (h *Handler) decodeRequest(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+	return h.decodeJSON(w, r, http.MaxBytesReader(w, r.Body, maxRequestBodySize), dst)
+}
+
+// readBody читает тело запроса размером не больше maxRequestBodySize.
+// При ошибке ответ уже записан и возвращается false
+func (h *Handler) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestBodySize))
+	if err != nil {
+		if !writeTooLarge(w, r, err) {
+			h.logger.WithRequestID(r.Context()).Error("Ошибка при чтении тела запроса", zap.Error(err))
+			writeError(w, r, http.StatusBadRequest, CodeInvalidJSON, "Некорректный формат запроса")
+		}
+		return nil, false
+	}
+	return body, true
+}
+
+// writeTooLarge отвечает 413, если err вызвана превышением размера тела запроса,
+// и сообщает, был ли записан ответ
+func writeTooLarge(w http.ResponseWriter, r *http.Request, err error) bool {
+	var tooLarge *http.MaxBytesError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	writeErrorDetails(w, r, http.StatusRequestEntityTooLarge, CodeRequestTooLarge, "Тело запроса слишком большое",
+		map[string]int64{"limit": tooLarge.Limit})
+	return true
+}
+
+// decodeJSON читает JSON из body в dst и проверяет его по тем же правилам, что и decodeRequest.
+// Используется, когда проверяемый документ не совпадает с телом запроса, например после merge patch
+func (h *Handler) decodeJSON(w http.ResponseWriter, r *http.Request, body io.Reader, dst interface{}) bool {
+	logger := h.logger.WithRequestID(r.Context())
+
+	decoder := json.NewDecoder(body)
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(dst); err != nil {
+		if writeTooLarge(w, r, err) {
+			return false
+		}
+		logger.Error("Ошибка при декодировании запроса", zap.Error(err))
+		writeErrorDetails(w, r, http.StatusBadRequest, CodeInvalidJSON, "Некорректный формат запроса",
+			map[string]string{"error": decodeErrorMessage(err)})
+		return false
+	}
+	// Тело должно содержать ровно один JSON документ: данные после него, например
+	// второй объект, склеенный с первым, не должны молча отбрасываться
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		if writeTooLarge(w, r, err) {
+			return false
+		}
+		logger.Error("Данные после JSON документа в запросе", zap.Error(err))
+		writeErrorDetails(w, r, http.StatusBadRequest, CodeInvalidJSON, "Некорректный формат запроса",
+			map[string]string{"error": "данные после JSON документа"})
+		return false
+	}
+
+	if err := validate.Struct(dst); err != nil {
+		var validationErrs validator.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			logger.Error("Ошибка при проверке запроса", zap.Error(err))
+			writeError(w, r, http.StatusInternalServerError, CodeInternal, "Ошибка при проверке запроса")
+			return false
+		}
+
+		fields := make([]FieldError, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			fields = append(fields, FieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Rule:    fe.Tag(),
+				Message: fieldErrorMessage(fe),
+			})
+		}
+		writeErrorDetails(w, r, http.StatusBadRequest, CodeValidationFailed, "Запрос не прошел проверку",
+			map[string]interface{}{"fields": fields})
+		return false
+	}
+
+	return true
+}
+
+// decodeErrorMessage формирует понятное описание ошибки разбора JSON
+func decodeErrorMessage(err error) string {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		return "пустое тело запроса"
+	case errors.As(err, &syntaxErr):
+		return fmt.Sprintf("синтаксическая ошибка в позиции %d", syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		return fmt.Sprintf("поле %s должно иметь тип %s", typeErr.Field, jsonType(typeErr.Type))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		return "неизвестное поле " + strings.TrimPrefix(err.Error(), "json: unknown field ")
+	default:
+		return err.Error()
+	}
+}
+
+// jsonType возвращает название типа JSON, соответствующего типу Go
+func jsonType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	default:
+		// uuid.UUID и другие типы, которые передаются строкой
+		return "string"
+	}
+}
+
+// fieldPath убирает имя корневой структуры из пути к полю:
+// PurchaseRequest.products[0].quantity -> products[0].quantity
+func fieldPath(namespace string) string {
+	_, path, found := strings.Cut(namespace, ".")
+	if !found {
+		return namespace
+	}
+	return path
+}
+
+// fieldErrorMessage возвращает описание нарушенного правила
+func fieldErrorMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "обязательное поле"
+	case "required_without":
+		return "обязательное поле, если не указано " + snakeCase(fe.Param())
+	case "gt":
+		return "значение должно быть больше " + fe.Param()
+	case "gte":
+		return "значение должно быть не меньше " + fe.Param()
+	case "lte":
+		return "значение должно быть не больше " + fe.Param()
+	case "min":
+		if fe.Kind() == reflect.Slice {
+			return "должно содержать не меньше " + fe.Param() + " элементов"
+		}
+		return "длина должна быть не меньше " + fe.Param()
+	case "max":
+		return "длина должна быть не больше " + fe.Param()
+	case "oneof":
+		return "допустимые значения: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "uuid":
+		return "значение должно быть UUID"
+	case "json_object":
+		return "значение должно быть JSON объектом"
+	case "nefield":
+		return "значение должно отличаться от поля " + snakeCase(fe.Param())
+	default:
+		return "недопустимое значение"
+	}
+}
+
+// snakeCase переводит имя поля структуры из параметра правила в JSON имя:
+// SourceWarehouseID -> source_warehouse_id
+func snakeCase(name string) string {
+	runes := []rune(name)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 {
+			prevLower := unicode.IsLower(runes[i-1])
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || (nextLower && unicode.IsUpper(runes[i-1])) {
+				b.WriteByte('_')
+			}
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
@@ -1,8 +1,8 @@
 package handler
 
 import (
-	"encoding/json"
 	"net/http"
+	"slices"
 
 	"github.com/danya1733/practiceGO/internal/domain"
 	"go.uber.org/zap"
@@ -14,19 +14,26 @@ import (
 // @Tags warehouses
 // @Produce json
 // @Success 200 {array} domain.Warehouse
-// @Failure 500 {object} map[string]string
+// @Failure 500 {object} ErrorResponse
 // @Router /api/warehouses [get]
 func (h *Handler) GetWarehouses(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := h.logger.WithRequestID(ctx)
 
-	warehouses, err := h.warehouseRepo.GetAll(ctx)
+	warehouses, err := h.warehouses.List(ctx)
 	if err != nil {
 		logger.Error("Ошибка при получении списка складов", zap.Error(err))
-		writeError(w, "Ошибка при получении списка складов", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при получении списка складов")
 		return
 	}
 
+	// Клиент, ограниченный складами, видит только свои склады
+	if principal := principalFrom(ctx); principal.Scoped() {
+		warehouses = slices.DeleteFunc(warehouses, func(warehouse domain.Warehouse) bool {
+			return !principal.CanAccessWarehouse(warehouse.ID)
+		})
+	}
+
 	writeJSON(w, http.StatusOK, warehouses)
 }
 
@@ -38,24 +45,22 @@ func (h *Handler) GetWarehouses(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param warehouse body domain.Warehouse true "Информация о складе"
 // @Success 201 {object} domain.Warehouse
-// @Failure 400 {object} map[string]string
-// @Failure 500 {object} map[string]string
+// @Failure 400 {object} ErrorResponse
+// @Failure 500 {object} ErrorResponse
 // @Router /api/warehouses [post]
 func (h *Handler) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	logger := h.logger.WithRequestID(ctx)
 
 	var warehouse domain.Warehouse
-	if err := json.NewDecoder(r.Body).Decode(&warehouse); err != nil {
-		logger.Error("Ошибка при декодировании запроса", zap.Error(err))
-		writeError(w, "Некорректный формат запроса", http.StatusBadRequest)
+	if !h.decodeRequest(w, r, &warehouse) {
 		return
 	}
 
-	createdWarehouse, err := h.warehouseRepo.Create(ctx, warehouse)
+	createdWarehouse, err := h.warehouses.Create(ctx, warehouse)
 	if err != nil {
 		logger.Error("Ошибка при создании склада", zap.Error(err))
-		writeError(w, "Ошибка при создании склада", http.StatusInternalServerError)
+		writeServiceError(w, r, err, "Ошибка при создании склада")
 		return
 	}
 
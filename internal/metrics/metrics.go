@@ -0,0 +1,132 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace префикс имен всех метрик приложения
+const namespace = "wms"
+
+// UnmatchedRoute метка маршрута для запросов, не совпавших ни с одним маршрутом.
+// Путь запроса в метку не попадает, чтобы число рядов не зависело от клиентов
+const UnmatchedRoute = "unmatched"
+
+// OtherMethod метка метода для запросов с нестандартным HTTP методом.
+// Метод задает клиент, поэтому в метку попадают только известные методы
+const OtherMethod = "OTHER"
+
+// Metrics метрики приложения в формате Prometheus.
+// Метрики регистрируются в собственном реестре, а не в глобальном
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests *prometheus.CounterVec
+	httpDuration *prometheus.HistogramVec
+
+	purchases        prometheus.Counter
+	unitsSold        prometheus.Counter
+	revenue          prometheus.Counter
+	purchaseFailures *prometheus.CounterVec
+}
+
+// New создает метрики и регистрирует их вместе с метриками среды выполнения Go и процесса
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "Количество HTTP запросов по методу, шаблону маршрута и статусу ответа",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "Время обработки HTTP запросов в секундах по методу, шаблону маршрута и статусу ответа",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		purchases: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sales",
+			Name:      "purchases_total",
+			Help:      "Количество оформленных заказов",
+		}),
+		unitsSold: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sales",
+			Name:      "units_sold_total",
+			Help:      "Количество проданных единиц товара",
+		}),
+		revenue: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sales",
+			Name:      "revenue_total",
+			Help:      "Выручка по оформленным заказам с учетом скидок",
+		}),
+		purchaseFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "sales",
+			Name:      "purchase_failures_total",
+			Help:      "Количество неудачных покупок по причине",
+		}, []string{"reason"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.purchases,
+		m.unitsSold,
+		m.revenue,
+		m.purchaseFailures,
+	)
+
+	return m
+}
+
+// Register регистрирует дополнительный сборщик метрик, например статистику пула соединений
+func (m *Metrics) Register(collector prometheus.Collector) {
+	m.registry.MustRegister(collector)
+}
+
+// Handler возвращает обработчик HTTP, отдающий метрики в текстовом формате Prometheus
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// ObserveRequest учитывает обработанный HTTP запрос
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	labels := prometheus.Labels{
+		"method": method,
+		"route":  route,
+		"status": strconv.Itoa(status),
+	}
+	m.httpRequests.With(labels).Inc()
+	m.httpDuration.With(labels).Observe(duration.Seconds())
+}
+
+// ObservePurchase учитывает оформленный заказ
+func (m *Metrics) ObservePurchase(order domain.Order) {
+	units := 0
+	for _, item := range order.Items {
+		units += item.Quantity
+	}
+
+	m.purchases.Inc()
+	m.unitsSold.Add(float64(units))
+	m.revenue.Add(order.TotalSum)
+}
+
+// ObservePurchaseFailure учитывает неудачную покупку с причиной reason
+func (m *Metrics) ObservePurchaseFailure(reason string) {
+	m.purchaseFailures.WithLabelValues(reason).Inc()
+}
@@ -0,0 +1,88 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// poolCollector собирает статистику пула соединений pgxpool в момент чтения метрик
+type poolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns        *prometheus.Desc
+	idleConns            *prometheus.Desc
+	constructingConns    *prometheus.Desc
+	totalConns           *prometheus.Desc
+	maxConns             *prometheus.Desc
+	acquireCount         *prometheus.Desc
+	acquireDuration      *prometheus.Desc
+	emptyAcquireCount    *prometheus.Desc
+	canceledAcquireCount *prometheus.Desc
+	newConnsCount        *prometheus.Desc
+	maxLifetimeDestroys  *prometheus.Desc
+	maxIdleDestroys      *prometheus.Desc
+}
+
+// NewPoolCollector создает сборщик статистики пула соединений с базой данных
+func NewPoolCollector(pool *pgxpool.Pool) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &poolCollector{
+		pool:                 pool,
+		acquiredConns:        desc("acquired_connections", "Количество соединений, выданных из пула"),
+		idleConns:            desc("idle_connections", "Количество свободных соединений в пуле"),
+		constructingConns:    desc("constructing_connections", "Количество устанавливаемых соединений"),
+		totalConns:           desc("total_connections", "Общее количество соединений в пуле"),
+		maxConns:             desc("max_connections", "Максимальный размер пула"),
+		acquireCount:         desc("acquires_total", "Количество успешных получений соединения из пула"),
+		acquireDuration:      desc("acquire_duration_seconds_total", "Суммарное время получения соединений из пула в секундах"),
+		emptyAcquireCount:    desc("empty_acquires_total", "Количество получений соединения, которым пришлось ждать из-за пустого пула"),
+		canceledAcquireCount: desc("canceled_acquires_total", "Количество получений соединения, отмененных контекстом"),
+		newConnsCount:        desc("new_connections_total", "Количество открытых соединений"),
+		maxLifetimeDestroys:  desc("max_lifetime_destroys_total", "Количество соединений, закрытых по истечении времени жизни"),
+		maxIdleDestroys:      desc("max_idle_destroys_total", "Количество соединений, закрытых из-за простоя"),
+	}
+}
+
+// Describe реализует prometheus.Collector
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.constructingConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquireCount
+	ch <- c.canceledAcquireCount
+	ch <- c.newConnsCount
+	ch <- c.maxLifetimeDestroys
+	ch <- c.maxIdleDestroys
+}
+
+// Collect реализует prometheus.Collector
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	gauge := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, value)
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+
+	gauge(c.acquiredConns, float64(stat.AcquiredConns()))
+	gauge(c.idleConns, float64(stat.IdleConns()))
+	gauge(c.constructingConns, float64(stat.ConstructingConns()))
+	gauge(c.totalConns, float64(stat.TotalConns()))
+	gauge(c.maxConns, float64(stat.MaxConns()))
+	counter(c.acquireCount, float64(stat.AcquireCount()))
+	counter(c.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(c.emptyAcquireCount, float64(stat.EmptyAcquireCount()))
+	counter(c.canceledAcquireCount, float64(stat.CanceledAcquireCount()))
+	counter(c.newConnsCount, float64(stat.NewConnsCount()))
+	counter(c.maxLifetimeDestroys, float64(stat.MaxLifetimeDestroyCount()))
+	counter(c.maxIdleDestroys, float64(stat.MaxIdleDestroyCount()))
+}
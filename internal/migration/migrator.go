@@ -0,0 +1,258 @@
+package migration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// lockID ключ advisory lock, под которым выполняются миграции.
+// Реплики, запущенные одновременно, ждут друг друга, а не применяют миграции параллельно
+const lockID int64 = 4_212_750_331
+
+// ErrDirty возвращается, если предыдущая миграция завершилась с ошибкой вне транзакции
+// (например, при запуске внешней утилитой migrate) и схему нужно исправить вручную
+var ErrDirty = errors.New("схема базы данных в незавершенном состоянии")
+
+// Status описывает миграцию и признак того, что она применена
+type Status struct {
+	Version int64
+	Name    string
+	Applied bool
+}
+
+// Migrator применяет и откатывает миграции.
+// Каждая миграция выполняется в отдельной транзакции вместе с обновлением версии схемы
+type Migrator struct {
+	pool       *pgxpool.Pool
+	migrations []Migration
+}
+
+// New создает мигратор для миграций из корня fsys
+func New(pool *pgxpool.Pool, fsys fs.FS) (*Migrator, error) {
+	migrations, err := load(fsys)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{pool: pool, migrations: migrations}, nil
+}
+
+// Up применяет все непримененные миграции и возвращает их
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		current, err := cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, migration := range m.migrations {
+			if migration.Version <= current {
+				continue
+			}
+
+			if err := apply(ctx, conn, migration.Up, migration.Version); err != nil {
+				return fmt.Errorf("ошибка применения миграции %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			applied = append(applied, migration)
+		}
+
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down откатывает не более steps последних примененных миграций и возвращает их
+func (m *Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		current, err := cleanVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			migration := m.migrations[i]
+			if migration.Version > current {
+				continue
+			}
+			if migration.Down == "" {
+				return fmt.Errorf("у миграции %d_%s нет файла down", migration.Version, migration.Name)
+			}
+
+			// После отката версией схемы становится предыдущая миграция
+			var previous int64
+			if i > 0 {
+				previous = m.migrations[i-1].Version
+			}
+
+			if err := apply(ctx, conn, migration.Down, previous); err != nil {
+				return fmt.Errorf("ошибка отката миграции %d_%s: %w", migration.Version, migration.Name, err)
+			}
+			reverted = append(reverted, migration)
+		}
+
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status возвращает все известные миграции с признаком применения
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	current, _, err := m.Version(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		statuses = append(statuses, Status{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= current,
+		})
+	}
+
+	return statuses, nil
+}
+
+// Version возвращает текущую версию схемы (0, если миграции не применялись)
+// и признак незавершенной миграции
+func (m *Migrator) Version(ctx context.Context) (int64, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := m.withLock(ctx, func(conn *pgx.Conn) error {
+		var err error
+		version, dirty, err = currentVersion(ctx, conn)
+		return err
+	})
+
+	return version, dirty, err
+}
+
+// Latest возвращает версию последней встроенной миграции, то есть версию схемы,
+// на которую рассчитано приложение (0, если миграций нет)
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// CurrentVersion возвращает текущую версию схемы и признак незавершенной миграции.
+// В отличие от Version не берет блокировку миграций и не создает таблицу версий,
+// поэтому подходит для проверок готовности, которые не должны ждать применения миграций
+func (m *Migrator) CurrentVersion(ctx context.Context) (int64, bool, error) {
+	var exists bool
+	if err := m.pool.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists); err != nil {
+		return 0, false, err
+	}
+	if !exists {
+		return 0, false, nil
+	}
+
+	var (
+		version int64
+		dirty   bool
+	)
+	err := m.pool.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+// withLock выполняет fn на отдельном соединении под advisory lock.
+// Таблица версий создается при первом запуске
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *pgx.Conn) error) error {
+	conn, err := m.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err := conn.Exec(ctx, "SELECT pg_advisory_lock($1)", lockID); err != nil {
+		return fmt.Errorf("ошибка получения блокировки миграций: %w", err)
+	}
+	defer func() {
+		// Блокировка сессионная: если снять ее не удалось, соединение закрывается,
+		// чтобы не вернуть его в пул вместе с блокировкой
+		if _, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", lockID); err != nil {
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version BIGINT NOT NULL PRIMARY KEY,
+			dirty BOOLEAN NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("ошибка создания таблицы schema_migrations: %w", err)
+	}
+
+	return fn(conn.Conn())
+}
+
+// currentVersion читает версию схемы; 0 означает, что миграции не применялись
+func currentVersion(ctx context.Context, conn *pgx.Conn) (int64, bool, error) {
+	var (
+		version int64
+		dirty   bool
+	)
+	err := conn.QueryRow(ctx, "SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&version, &dirty)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, false, nil
+	}
+
+	return version, dirty, err
+}
+
+// cleanVersion читает версию схемы и возвращает ErrDirty, если схема в незавершенном состоянии
+func cleanVersion(ctx context.Context, conn *pgx.Conn) (int64, error) {
+	version, dirty, err := currentVersion(ctx, conn)
+	if err != nil {
+		return 0, err
+	}
+	if dirty {
+		return 0, fmt.Errorf("%w: версия %d", ErrDirty, version)
+	}
+
+	return version, nil
+}
+
+// apply выполняет SQL миграции и устанавливает версию схемы в одной транзакции.
+// Версия 0 означает, что все миграции откачены
+func apply(ctx context.Context, conn *pgx.Conn, sql string, version int64) error {
+	tx, err := conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, "DELETE FROM schema_migrations"); err != nil {
+		return err
+	}
+	if version > 0 {
+		if _, err := tx.Exec(ctx, "INSERT INTO schema_migrations (version, dirty) VALUES ($1, false)", version); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit(ctx)
+}
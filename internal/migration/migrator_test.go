@@ -0,0 +1,237 @@
+package migration_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	"github.com/danya1733/practiceGO/internal/migration"
+	"github.com/danya1733/practiceGO/internal/testdb"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// testMigrations миграции, каждая из которых записывает свою версию в таблицу steps.
+// Версия 10 идет после 2, хотя по имени файла раньше: порядок задают номера, а не строки
+var testMigrations = fstest.MapFS{
+	"1_steps.up.sql":       {Data: []byte("CREATE TABLE steps (id SERIAL PRIMARY KEY, version BIGINT NOT NULL); INSERT INTO steps (version) VALUES (1);")},
+	"1_steps.down.sql":     {Data: []byte("DROP TABLE steps;")},
+	"2_second.up.sql":      {Data: []byte("INSERT INTO steps (version) VALUES (2);")},
+	"2_second.down.sql":    {Data: []byte("DELETE FROM steps WHERE version = 2;")},
+	"10_tenth.up.sql":      {Data: []byte("INSERT INTO steps (version) VALUES (10);")},
+	"10_tenth.down.sql":    {Data: []byte("DELETE FROM steps WHERE version = 10;")},
+	"README.md":            {Data: []byte("не миграция")},
+	"11_broken.up.sql.bak": {Data: []byte("не миграция")},
+}
+
+// newMigrator создает мигратор тестовых миграций в пустой схеме
+func newMigrator(t *testing.T) (*migration.Migrator, *pgxpool.Pool) {
+	t.Helper()
+
+	pool := testdb.Schema(t)
+	m, err := migration.New(pool, testMigrations)
+	if err != nil {
+		t.Fatalf("загрузка миграций: %v", err)
+	}
+	return m, pool
+}
+
+// versions возвращает версии миграций
+func versions(migrations []migration.Migration) []int64 {
+	result := make([]int64, 0, len(migrations))
+	for _, m := range migrations {
+		result = append(result, m.Version)
+	}
+	return result
+}
+
+// steps возвращает версии, записанные миграциями в таблицу steps, в порядке записи
+func steps(t *testing.T, pool *pgxpool.Pool) []int64 {
+	t.Helper()
+
+	rows, err := pool.Query(context.Background(), "SELECT version FROM steps ORDER BY id")
+	if err != nil {
+		t.Fatalf("чтение steps: %v", err)
+	}
+	var result []int64
+	for rows.Next() {
+		var v int64
+		if err := rows.Scan(&v); err != nil {
+			t.Fatalf("чтение steps: %v", err)
+		}
+		result = append(result, v)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatalf("чтение steps: %v", err)
+	}
+	return result
+}
+
+// assertVersion проверяет версию схемы и отсутствие признака незавершенной миграции
+func assertVersion(t *testing.T, m *migration.Migrator, want int64) {
+	t.Helper()
+
+	version, dirty, err := m.Version(context.Background())
+	if err != nil {
+		t.Fatalf("чтение версии схемы: %v", err)
+	}
+	if version != want || dirty {
+		t.Errorf("версия %d (dirty=%v), ожидалась %d", version, dirty, want)
+	}
+}
+
+func TestMigratorUpDown(t *testing.T) {
+	ctx := context.Background()
+	m, pool := newMigrator(t)
+
+	if m.Latest() != 10 {
+		t.Errorf("последняя миграция %d, ожидалась 10", m.Latest())
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("применение миграций: %v", err)
+	}
+	if got := versions(applied); !slices.Equal(got, []int64{1, 2, 10}) {
+		t.Errorf("применены %v, ожидалось [1 2 10]", got)
+	}
+	if got := steps(t, pool); !slices.Equal(got, []int64{1, 2, 10}) {
+		t.Errorf("миграции выполнены в порядке %v, ожидалось [1 2 10]", got)
+	}
+	assertVersion(t, m, 10)
+
+	// Повторный запуск ничего не применяет
+	if applied, err := m.Up(ctx); err != nil || len(applied) != 0 {
+		t.Errorf("повторное применение: %v, %v; ожидалось ничего", versions(applied), err)
+	}
+
+	reverted, err := m.Down(ctx, 2)
+	if err != nil {
+		t.Fatalf("откат миграций: %v", err)
+	}
+	if got := versions(reverted); !slices.Equal(got, []int64{10, 2}) {
+		t.Errorf("откачены %v, ожидалось [10 2]", got)
+	}
+	if got := steps(t, pool); !slices.Equal(got, []int64{1}) {
+		t.Errorf("после отката в steps %v, ожидалось [1]", got)
+	}
+	assertVersion(t, m, 1)
+
+	// Откат больше примененных миграций останавливается на пустой схеме
+	reverted, err = m.Down(ctx, 5)
+	if err != nil {
+		t.Fatalf("откат всех миграций: %v", err)
+	}
+	if got := versions(reverted); !slices.Equal(got, []int64{1}) {
+		t.Errorf("откачены %v, ожидалось [1]", got)
+	}
+	assertVersion(t, m, 0)
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		t.Fatalf("чтение статуса миграций: %v", err)
+	}
+	for _, s := range statuses {
+		if s.Applied {
+			t.Errorf("миграция %d_%s отмечена примененной после полного отката", s.Version, s.Name)
+		}
+	}
+}
+
+func TestMigratorRefusesDirtySchema(t *testing.T) {
+	ctx := context.Background()
+	m, pool := newMigrator(t)
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("применение миграций: %v", err)
+	}
+	// Так оставляет схему golang-migrate, если миграция завершилась с ошибкой
+	if _, err := pool.Exec(ctx, "UPDATE schema_migrations SET dirty = true"); err != nil {
+		t.Fatalf("пометка схемы: %v", err)
+	}
+
+	if _, err := m.Up(ctx); !errors.Is(err, migration.ErrDirty) {
+		t.Errorf("применение: ожидалась ErrDirty, получено %v", err)
+	}
+	if _, err := m.Down(ctx, 1); !errors.Is(err, migration.ErrDirty) {
+		t.Errorf("откат: ожидалась ErrDirty, получено %v", err)
+	}
+	if version, dirty, err := m.CurrentVersion(ctx); err != nil || version != 10 || !dirty {
+		t.Errorf("версия %d (dirty=%v, ошибка %v), ожидалась 10 с dirty", version, dirty, err)
+	}
+	if got := steps(t, pool); !slices.Equal(got, []int64{1, 2, 10}) {
+		t.Errorf("схема изменена при отказе: steps %v", got)
+	}
+}
+
+func TestMigratorContinuesGolangMigrateSchema(t *testing.T) {
+	ctx := context.Background()
+	m, pool := newMigrator(t)
+
+	// Схема, обновленная утилитой migrate до версии 2: ее таблица версий
+	// и результат первых двух миграций
+	_, err := pool.Exec(ctx, `
+		CREATE TABLE schema_migrations (version BIGINT NOT NULL PRIMARY KEY, dirty BOOLEAN NOT NULL);
+		INSERT INTO schema_migrations (version, dirty) VALUES (2, false);
+		CREATE TABLE steps (id SERIAL PRIMARY KEY, version BIGINT NOT NULL);
+		INSERT INTO steps (version) VALUES (1), (2);
+	`)
+	if err != nil {
+		t.Fatalf("подготовка схемы golang-migrate: %v", err)
+	}
+
+	if version, dirty, err := m.CurrentVersion(ctx); err != nil || version != 2 || dirty {
+		t.Fatalf("версия %d (dirty=%v, ошибка %v), ожидалась 2", version, dirty, err)
+	}
+
+	applied, err := m.Up(ctx)
+	if err != nil {
+		t.Fatalf("применение миграций: %v", err)
+	}
+	if got := versions(applied); !slices.Equal(got, []int64{10}) {
+		t.Errorf("применены %v, ожидалось [10]", got)
+	}
+	if got := steps(t, pool); !slices.Equal(got, []int64{1, 2, 10}) {
+		t.Errorf("в steps %v, ожидалось [1 2 10]", got)
+	}
+
+	// Таблица версий остается в формате golang-migrate: одна строка
+	var rows int
+	if err := pool.QueryRow(ctx, "SELECT count(*) FROM schema_migrations").Scan(&rows); err != nil {
+		t.Fatalf("чтение schema_migrations: %v", err)
+	}
+	if rows != 1 {
+		t.Errorf("строк в schema_migrations %d, ожидалась 1", rows)
+	}
+	assertVersion(t, m, 10)
+}
+
+func TestMigratorEmptySchema(t *testing.T) {
+	m, _ := newMigrator(t)
+
+	// Проверка готовности не создает таблицу версий и видит пустую схему
+	if version, dirty, err := m.CurrentVersion(context.Background()); err != nil || version != 0 || dirty {
+		t.Errorf("версия %d (dirty=%v, ошибка %v), ожидалась 0", version, dirty, err)
+	}
+}
+
+func TestNewRejectsInvalidMigrations(t *testing.T) {
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{"нет файла up", fstest.MapFS{"1_init.down.sql": {Data: []byte("SELECT 1")}}},
+		{"разные названия одной версии", fstest.MapFS{
+			"1_init.up.sql":  {Data: []byte("SELECT 1")},
+			"1_other.up.sql": {Data: []byte("SELECT 1")},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := migration.New(nil, tt.fsys); err == nil {
+				t.Error("миграции загружены, ожидалась ошибка")
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/danya1733/practiceGO/internal/domain"
 	"github.com/google/uuid"
@@ -59,8 +60,9 @@ func (r *AnalyticsRepository) GetWarehouseAnalytics(ctx context.Context, warehou
 	return analytics, totalSum, nil
 }
 
-// GetTopWarehouses возвращает топ-N складов по выручке
-func (r *AnalyticsRepository) GetTopWarehouses(ctx context.Context, limit int) ([]domain.WarehouseAnalytics, error) {
+// GetTopWarehouses возвращает топ-N складов по выручке.
+// Если задан период [from, to), выручка считается по заказам за этот период, иначе за все время
+func (r *AnalyticsRepository) GetTopWarehouses(ctx context.Context, limit int, from, to *time.Time) ([]domain.WarehouseAnalytics, error) {
 	query := `
 		SELECT w.id, w.address, COALESCE(SUM(a.total_sum), 0) as total_sum
 		FROM warehouses w
@@ -69,8 +71,23 @@ func (r *AnalyticsRepository) GetTopWarehouses(ctx context.Context, limit int) (
 		ORDER BY total_sum DESC
 		LIMIT $1
 	`
+	args := []interface{}{limit}
 
-	rows, err := r.pool.Query(ctx, query, limit)
+	if from != nil || to != nil {
+		query = `
+			SELECT w.id, w.address, COALESCE(SUM(o.total_sum), 0) as total_sum
+			FROM warehouses w
+			LEFT JOIN orders o ON w.id = o.warehouse_id
+				AND ($2::TIMESTAMPTZ IS NULL OR o.created_at >= $2)
+				AND ($3::TIMESTAMPTZ IS NULL OR o.created_at < $3)
+			GROUP BY w.id, w.address
+			ORDER BY total_sum DESC
+			LIMIT $1
+		`
+		args = append(args, from, to)
+	}
+
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -95,3 +112,61 @@ func (r *AnalyticsRepository) GetTopWarehouses(ctx context.Context, limit int) (
 
 	return warehouses, nil
 }
+
+// GetSalesSeries возвращает продажи за период, сгруппированные по дням, неделям или месяцам.
+// Интервалы без продаж присутствуют в ряду с нулевыми значениями; границы интервалов считаются в UTC
+func (r *AnalyticsRepository) GetSalesSeries(ctx context.Context, q domain.SalesQuery) (domain.SalesSeries, error) {
+	query := `
+		WITH buckets AS (
+			SELECT generate_series(
+				date_trunc($1, $2::TIMESTAMPTZ AT TIME ZONE 'UTC'),
+				($3::TIMESTAMPTZ AT TIME ZONE 'UTC') - INTERVAL '1 microsecond',
+				('1 ' || $1)::INTERVAL
+			) AS period_start
+		), sales AS (
+			SELECT date_trunc($1, o.created_at AT TIME ZONE 'UTC') AS period_start,
+				COUNT(DISTINCT o.id) AS orders,
+				SUM(oi.quantity) AS units,
+				SUM(oi.total_price) AS revenue
+			FROM orders o
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.created_at >= $2 AND o.created_at < $3
+				AND ($4::UUID IS NULL OR o.warehouse_id = $4)
+				AND ($5::UUID IS NULL OR oi.product_id = $5)
+			GROUP BY 1
+		)
+		SELECT b.period_start, COALESCE(s.orders, 0), COALESCE(s.units, 0), COALESCE(s.revenue, 0)
+		FROM buckets b
+		LEFT JOIN sales s ON s.period_start = b.period_start
+		ORDER BY b.period_start
+	`
+
+	rows, err := r.pool.Query(ctx, query, string(q.Granularity), q.From, q.To, q.WarehouseID, q.ProductID)
+	if err != nil {
+		return domain.SalesSeries{}, err
+	}
+	defer rows.Close()
+
+	series := domain.SalesSeries{
+		Granularity: q.Granularity,
+		From:        q.From,
+		To:          q.To,
+		Buckets:     []domain.SalesBucket{},
+	}
+
+	for rows.Next() {
+		var b domain.SalesBucket
+		if err := rows.Scan(&b.PeriodStart, &b.Orders, &b.Units, &b.Revenue); err != nil {
+			return domain.SalesSeries{}, err
+		}
+		series.Buckets = append(series.Buckets, b)
+		series.TotalUnits += b.Units
+		series.TotalRevenue += b.Revenue
+	}
+
+	if err := rows.Err(); err != nil {
+		return domain.SalesSeries{}, err
+	}
+
+	return series, nil
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+// attributeConditions строит SQL условия фильтров по характеристикам для JSONB колонки column.
+// Пути и значения передаются только параметрами запроса: равенство проверяется вхождением
+// JSON документа (@>), которое использует GIN индекс, сравнение - по значению пути (#>)
+func attributeConditions(column string, filters []domain.AttributeFilter, args *[]interface{}) []string {
+	conditions := make([]string, 0, len(filters))
+	for _, f := range filters {
+		if f.Op.Ordered() {
+			*args = append(*args, f.Path, f.Number)
+			path, bound := len(*args)-1, len(*args)
+			conditions = append(conditions, fmt.Sprintf(
+				"CASE WHEN jsonb_typeof(%[1]s #> $%[2]d::text[]) = 'number' THEN (%[1]s #>> $%[2]d::text[])::numeric %[3]s $%[4]d::numeric ELSE FALSE END",
+				column, path, attributeOperators[f.Op], bound))
+			continue
+		}
+
+		var matches []string
+		for _, value := range f.Values {
+			for _, candidate := range attributeCandidates(value) {
+				*args = append(*args, containmentDocument(f.Path, candidate))
+				matches = append(matches, fmt.Sprintf("%s @> $%d::jsonb", column, len(*args)))
+			}
+		}
+		condition := "(" + strings.Join(matches, " OR ") + ")"
+		if f.Op == domain.AttributeNe {
+			condition = "NOT coalesce(" + condition + ", FALSE)"
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions
+}
+
+// attributeOperators задает SQL оператор для каждой операции сравнения
+var attributeOperators = map[domain.AttributeOp]string{
+	domain.AttributeGt:  ">",
+	domain.AttributeGte: ">=",
+	domain.AttributeLt:  "<",
+	domain.AttributeLte: "<=",
+}
+
+// attributeCandidates возвращает JSON значения, с которыми совпадает значение фильтра:
+// строка, а также число или логическое значение, если строка является их записью
+func attributeCandidates(value string) []interface{} {
+	candidates := []interface{}{value}
+	if value == "true" || value == "false" {
+		candidates = append(candidates, value == "true")
+	} else if _, err := strconv.ParseFloat(value, 64); err == nil && json.Valid([]byte(value)) {
+		candidates = append(candidates, json.Number(value))
+	}
+	return candidates
+}
+
+// containmentDocument строит JSON документ, в котором value вложено по пути path
+func containmentDocument(path []string, value interface{}) string {
+	for i := len(path) - 1; i >= 0; i-- {
+		value = map[string]interface{}{path[i]: value}
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+func TestAttributeConditions(t *testing.T) {
+	tests := []struct {
+		name       string
+		filter     domain.AttributeFilter
+		condition  string
+		parameters []interface{}
+	}{
+		{
+			name:       "строковое равенство",
+			filter:     domain.AttributeFilter{Path: []string{"color"}, Op: domain.AttributeEq, Values: []string{"red"}},
+			condition:  "(p.characteristics @> $2::jsonb)",
+			parameters: []interface{}{`{"color":"red"}`},
+		},
+		{
+			name:       "число совпадает со строкой и с числом",
+			filter:     domain.AttributeFilter{Path: []string{"size", "eu"}, Op: domain.AttributeEq, Values: []string{"42"}},
+			condition:  "(p.characteristics @> $2::jsonb OR p.characteristics @> $3::jsonb)",
+			parameters: []interface{}{`{"size":{"eu":"42"}}`, `{"size":{"eu":42}}`},
+		},
+		{
+			name:       "несколько значений и логическое значение",
+			filter:     domain.AttributeFilter{Path: []string{"waterproof"}, Op: domain.AttributeEq, Values: []string{"yes", "true"}},
+			condition:  "(p.characteristics @> $2::jsonb OR p.characteristics @> $3::jsonb OR p.characteristics @> $4::jsonb)",
+			parameters: []interface{}{`{"waterproof":"yes"}`, `{"waterproof":"true"}`, `{"waterproof":true}`},
+		},
+		{
+			name:       "неравенство включает товары без характеристики",
+			filter:     domain.AttributeFilter{Path: []string{"color"}, Op: domain.AttributeNe, Values: []string{"red"}},
+			condition:  "NOT coalesce((p.characteristics @> $2::jsonb), FALSE)",
+			parameters: []interface{}{`{"color":"red"}`},
+		},
+		{
+			name:   "сравнение только с числовыми значениями",
+			filter: domain.AttributeFilter{Path: []string{"size", "eu"}, Op: domain.AttributeGte, Number: 42},
+			condition: "CASE WHEN jsonb_typeof(p.characteristics #> $2::text[]) = 'number' " +
+				"THEN (p.characteristics #>> $2::text[])::numeric >= $3::numeric ELSE FALSE END",
+			parameters: []interface{}{[]string{"size", "eu"}, 42.0},
+		},
+		{
+			name:       "значение с SQL передается параметром",
+			filter:     domain.AttributeFilter{Path: []string{"note'); DROP TABLE products; --"}, Op: domain.AttributeEq, Values: []string{"x"}},
+			condition:  "(p.characteristics @> $2::jsonb)",
+			parameters: []interface{}{`{"note'); DROP TABLE products; --":"x"}`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Первый аргумент занят другим условием запроса: номера параметров продолжают его
+			args := []interface{}{"existing"}
+			conditions := attributeConditions("p.characteristics", []domain.AttributeFilter{tt.filter}, &args)
+
+			if len(conditions) != 1 || conditions[0] != tt.condition {
+				t.Errorf("условия %q, ожидалось %q", conditions, tt.condition)
+			}
+			if want := append([]interface{}{"existing"}, tt.parameters...); !reflect.DeepEqual(args, want) {
+				t.Errorf("аргументы %#v, ожидалось %#v", args, want)
+			}
+		})
+	}
+}
+
+func TestAttributeConditionsCombined(t *testing.T) {
+	var args []interface{}
+	conditions := attributeConditions("characteristics", []domain.AttributeFilter{
+		{Path: []string{"color"}, Op: domain.AttributeEq, Values: []string{"red"}},
+		{Path: []string{"size"}, Op: domain.AttributeLt, Number: 50},
+	}, &args)
+
+	// Каждый фильтр дает отдельное условие, которые вызывающий код объединяет через AND
+	want := []string{
+		"(characteristics @> $1::jsonb)",
+		"CASE WHEN jsonb_typeof(characteristics #> $2::text[]) = 'number' THEN (characteristics #>> $2::text[])::numeric < $3::numeric ELSE FALSE END",
+	}
+	if !reflect.DeepEqual(conditions, want) {
+		t.Errorf("условия %q, ожидалось %q", conditions, want)
+	}
+	if len(args) != 3 {
+		t.Errorf("аргументов %d, ожидалось 3", len(args))
+	}
+}
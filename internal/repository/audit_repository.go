@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/pkg/requestctx"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuditRepository представляет репозиторий для работы с журналом аудита
+type AuditRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAuditRepository создает новый репозиторий для работы с журналом аудита
+func NewAuditRepository(pool *pgxpool.Pool) *AuditRepository {
+	return &AuditRepository{pool: pool}
+}
+
+// insertAudit записывает изменение сущности в журнал аудита в рамках транзакции tx,
+// поэтому запись и изменение фиксируются или откатываются вместе.
+// before и after сериализуются в JSON; nil означает, что состояния нет (например, до создания).
+// Клиент и идентификатор запроса берутся из контекста
+func insertAudit(ctx context.Context, tx pgx.Tx, entity string, entityID uuid.UUID, action domain.AuditAction, before, after interface{}) error {
+	beforeJSON, err := marshalState(before)
+	if err != nil {
+		return err
+	}
+	afterJSON, err := marshalState(after)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO audit_log (id, actor, request_id, entity, entity_id, action, before, after)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+	`,
+		uuid.New(),
+		requestctx.Actor(ctx),
+		requestctx.RequestID(ctx),
+		entity,
+		entityID,
+		action,
+		jsonOrNull(beforeJSON),
+		jsonOrNull(afterJSON),
+	)
+	if err != nil {
+		return fmt.Errorf("ошибка записи в журнал аудита: %w", err)
+	}
+
+	return nil
+}
+
+// GetAll возвращает записи журнала аудита по фильтру, начиная с последних
+func (r *AuditRepository) GetAll(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	query := `
+		SELECT id, actor, request_id, entity, entity_id, action, before, after, created_at
+		FROM audit_log
+		WHERE TRUE
+	`
+	var args []interface{}
+
+	if filter.Entity != "" {
+		args = append(args, filter.Entity)
+		query += fmt.Sprintf(" AND entity = $%d", len(args))
+	}
+	if filter.EntityID != nil {
+		args = append(args, *filter.EntityID)
+		query += fmt.Sprintf(" AND entity_id = $%d", len(args))
+	}
+	if filter.Action != "" {
+		args = append(args, filter.Action)
+		query += fmt.Sprintf(" AND action = $%d", len(args))
+	}
+	if filter.Actor != "" {
+		args = append(args, filter.Actor)
+		query += fmt.Sprintf(" AND actor = $%d", len(args))
+	}
+	if filter.RequestID != "" {
+		args = append(args, filter.RequestID)
+		query += fmt.Sprintf(" AND request_id = $%d", len(args))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		query += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.AuditEntry, error) {
+		var e domain.AuditEntry
+		err := row.Scan(&e.ID, &e.Actor, &e.RequestID, &e.Entity, &e.EntityID, &e.Action, &e.Before, &e.After, &e.CreatedAt)
+		return e, err
+	})
+}
+
+// jsonOrNull передает пустой JSON как NULL
+func jsonOrNull(data []byte) interface{} {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+// marshalState сериализует состояние сущности; nil остается пустым
+func marshalState(state interface{}) ([]byte, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
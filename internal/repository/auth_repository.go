@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AuthRepository представляет репозиторий для работы с пользователями и API ключами
+type AuthRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewAuthRepository создает новый репозиторий для работы с пользователями и API ключами
+func NewAuthRepository(pool *pgxpool.Pool) *AuthRepository {
+	return &AuthRepository{pool: pool}
+}
+
+// EnsureUser создает пользователя при первом обращении или обновляет его роль и склады.
+// Роль и склады берутся из JWT, поэтому API ключи получают права, которые владелец имел при последнем входе
+func (r *AuthRepository) EnsureUser(ctx context.Context, user domain.User) (domain.User, error) {
+	query := `
+		INSERT INTO users (id, username, role, warehouses)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (username) DO UPDATE SET role = EXCLUDED.role, warehouses = EXCLUDED.warehouses
+		RETURNING id, username, role, warehouses, created_at
+	`
+
+	if user.Warehouses == nil {
+		user.Warehouses = []uuid.UUID{}
+	}
+
+	err := r.pool.QueryRow(ctx, query, uuid.New(), user.Username, user.Role, user.Warehouses).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Role,
+		&user.Warehouses,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		return domain.User{}, mapError(err, EntityUser)
+	}
+
+	return user, nil
+}
+
+// CreateAPIKey сохраняет API ключ и записывает его выпуск в журнал аудита.
+// Сам ключ не хранится ни в таблице, ни в журнале, только его хеш
+func (r *AuthRepository) CreateAPIKey(ctx context.Context, key domain.APIKey, hash string) (domain.APIKey, error) {
+	query := `
+		INSERT INTO api_keys (id, user_id, name, prefix, key_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`
+
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, key.ID, key.UserID, key.Name, key.Prefix, hash, key.ExpiresAt).Scan(&key.CreatedAt); err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, EntityAPIKey, key.ID, domain.AuditCreate, nil, key)
+	})
+	if err != nil {
+		return domain.APIKey{}, mapError(err, EntityAPIKey)
+	}
+
+	return key, nil
+}
+
+// GetAPIKeyByHash возвращает API ключ по хешу вместе с его владельцем
+func (r *AuthRepository) GetAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, domain.User, error) {
+	query := `
+		SELECT k.id, k.user_id, k.name, k.prefix, k.created_at, k.expires_at, k.revoked_at,
+			   u.id, u.username, u.role, u.warehouses, u.created_at
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.key_hash = $1
+	`
+
+	var (
+		key  domain.APIKey
+		user domain.User
+	)
+	err := r.pool.QueryRow(ctx, query, hash).Scan(
+		&key.ID,
+		&key.UserID,
+		&key.Name,
+		&key.Prefix,
+		&key.CreatedAt,
+		&key.ExpiresAt,
+		&key.RevokedAt,
+		&user.ID,
+		&user.Username,
+		&user.Role,
+		&user.Warehouses,
+		&user.CreatedAt,
+	)
+	if err != nil {
+		return domain.APIKey{}, domain.User{}, mapError(err, EntityAPIKey)
+	}
+
+	return key, user, nil
+}
+
+// ListAPIKeys возвращает API ключи пользователя, начиная с последних
+func (r *AuthRepository) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]domain.APIKey, error) {
+	query := `
+		SELECT id, user_id, name, prefix, created_at, expires_at, revoked_at
+		FROM api_keys
+		WHERE user_id = $1
+		ORDER BY created_at DESC, id
+	`
+
+	rows, err := r.pool.Query(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.APIKey, error) {
+		var key domain.APIKey
+		err := row.Scan(&key.ID, &key.UserID, &key.Name, &key.Prefix, &key.CreatedAt, &key.ExpiresAt, &key.RevokedAt)
+		return key, err
+	})
+}
+
+// RevokeAPIKey отзывает API ключ пользователя и записывает отзыв в журнал аудита.
+// Повторный отзыв не меняет время отзыва
+func (r *AuthRepository) RevokeAPIKey(ctx context.Context, id, userID uuid.UUID) (domain.APIKey, error) {
+	query := `
+		UPDATE api_keys
+		SET revoked_at = COALESCE(revoked_at, NOW())
+		WHERE id = $1 AND user_id = $2
+		RETURNING id, user_id, name, prefix, created_at, expires_at, revoked_at
+	`
+
+	var key domain.APIKey
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, id, userID).Scan(
+			&key.ID,
+			&key.UserID,
+			&key.Name,
+			&key.Prefix,
+			&key.CreatedAt,
+			&key.ExpiresAt,
+			&key.RevokedAt,
+		)
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, EntityAPIKey, key.ID, domain.AuditRevoke, nil, key)
+	})
+	if err != nil {
+		return domain.APIKey{}, mapError(err, EntityAPIKey)
+	}
+
+	return key, nil
+}
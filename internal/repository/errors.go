@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Виды ошибок репозитория, не зависящие от конкретной сущности
+var (
+	// ErrNotFound возвращается, если запись не найдена
+	ErrNotFound = errors.New("запись не найдена")
+	// ErrConflict возвращается при нарушении ограничения уникальности
+	ErrConflict = errors.New("запись уже существует")
+	// ErrInvalidReference возвращается при ссылке на несуществующую запись
+	ErrInvalidReference = errors.New("ссылка на несуществующую запись")
+	// ErrVersionMismatch возвращается, если запись изменилась после чтения клиентом
+	ErrVersionMismatch = errors.New("версия записи устарела")
+)
+
+// Коды ошибок PostgreSQL, которые переводятся в ошибки репозитория
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+)
+
+// Названия сущностей совпадают с именами таблиц
+const (
+	EntityWarehouse   = "warehouses"
+	EntityProduct     = "products"
+	EntityInventory   = "inventory"
+	EntityOrder       = "orders"
+	EntityReservation = "reservations"
+	EntityTransfer    = "transfers"
+	EntityUser        = "users"
+	EntityAPIKey      = "api_keys"
+)
+
+// Error ошибка репозитория с указанием вида, сущности и поля.
+// Проверяется через errors.Is(err, ErrNotFound) и аналогичные виды
+type Error struct {
+	Kind   error
+	Entity string
+	Field  string
+	Err    error
+}
+
+// Error реализует интерфейс error
+func (e *Error) Error() string {
+	msg := e.Kind.Error() + ": " + e.Entity
+	if e.Field != "" {
+		msg += "." + e.Field
+	}
+	return msg
+}
+
+// Is сопоставляет ошибку с ее видом
+func (e *Error) Is(target error) bool {
+	return target == e.Kind
+}
+
+// Unwrap возвращает исходную ошибку
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// mapError переводит ошибки pgx и PostgreSQL в ошибки репозитория.
+// entity используется, если запись не найдена; для нарушений ограничений
+// сущность и поле берутся из ошибки PostgreSQL
+func mapError(err error, entity string) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Error{Kind: ErrNotFound, Entity: entity, Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return err
+	}
+
+	switch pgErr.Code {
+	case pgUniqueViolation:
+		return &Error{
+			Kind:   ErrConflict,
+			Entity: pgErr.TableName,
+			Field:  constraintField(pgErr.TableName, pgErr.ConstraintName, "_key"),
+			Err:    err,
+		}
+	case pgForeignKeyViolation:
+		return &Error{
+			Kind:   ErrInvalidReference,
+			Entity: pgErr.TableName,
+			Field:  constraintField(pgErr.TableName, pgErr.ConstraintName, "_fkey"),
+			Err:    err,
+		}
+	}
+
+	return err
+}
+
+// constraintField извлекает имя поля из имени ограничения,
+// созданного PostgreSQL по умолчанию: <таблица>_<поле>_key или <таблица>_<поле>_fkey
+func constraintField(table, constraint, suffix string) string {
+	field := strings.TrimPrefix(constraint, table+"_")
+	return strings.TrimSuffix(field, suffix)
+}
+
+// rowQuerier выполняет запрос, возвращающий одну строку; реализуется pgxpool.Pool и pgx.Tx
+type rowQuerier interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+// Ошибки операций с остатками
+var (
+	// ErrInventoryNotFound возвращается, если товара нет на складе
+	ErrInventoryNotFound = errors.New("товар не найден на складе")
+	// ErrReservationNotFound возвращается, если резерв не существует
+	ErrReservationNotFound = errors.New("резерв не найден")
+	// ErrReservationMismatch возвращается, если резерв относится к другому складу
+	ErrReservationMismatch = errors.New("резерв относится к другому складу")
+)
+
+// InsufficientStockError возвращается, если доступного количества товара не хватает для операции
+type InsufficientStockError struct {
+	WarehouseID uuid.UUID
+	ProductID   uuid.UUID
+	Available   int
+	Requested   int
+}
+
+// Error реализует интерфейс error
+func (e *InsufficientStockError) Error() string {
+	return fmt.Sprintf("недостаточное количество товара %s на складе: доступно %d, запрошено %d",
+		e.ProductID, e.Available, e.Requested)
+}
+
+// inventoryNotFound создает ошибку об отсутствии товара на складе
+func inventoryNotFound(warehouseID, productID uuid.UUID) error {
+	return fmt.Errorf("%w: товар с ID %s не найден на складе %s", ErrInventoryNotFound, productID, warehouseID)
+}
@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/migration"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// HealthRepository проверяет доступность PostgreSQL, версию схемы и загрузку пула соединений
+type HealthRepository struct {
+	pool     *pgxpool.Pool
+	migrator *migration.Migrator
+}
+
+// NewHealthRepository создает новый репозиторий проверок состояния
+func NewHealthRepository(pool *pgxpool.Pool, migrator *migration.Migrator) *HealthRepository {
+	return &HealthRepository{pool: pool, migrator: migrator}
+}
+
+// Checks выполняет проверки базы данных
+func (r *HealthRepository) Checks(ctx context.Context) []domain.HealthCheck {
+	return []domain.HealthCheck{
+		r.checkDatabase(ctx),
+		r.checkMigrations(ctx),
+		r.checkPool(),
+	}
+}
+
+// checkDatabase проверяет, что база данных отвечает на запросы
+func (r *HealthRepository) checkDatabase(ctx context.Context) domain.HealthCheck {
+	check := domain.HealthCheck{Name: "database", Status: domain.HealthOK}
+	if err := r.pool.Ping(ctx); err != nil {
+		check.Status, check.Error = domain.HealthFail, err.Error()
+	}
+	return check
+}
+
+// checkMigrations проверяет, что версия схемы совпадает с версией последней встроенной миграции.
+// Непримененные миграции, схема новее приложения и незавершенная миграция считаются ошибкой
+func (r *HealthRepository) checkMigrations(ctx context.Context) domain.HealthCheck {
+	expected := r.migrator.Latest()
+	check := domain.HealthCheck{Name: "migrations", Status: domain.HealthOK}
+
+	current, dirty, err := r.migrator.CurrentVersion(ctx)
+	if err != nil {
+		check.Status, check.Error = domain.HealthFail, err.Error()
+		return check
+	}
+
+	check.Details = map[string]interface{}{
+		"current":  current,
+		"expected": expected,
+		"dirty":    dirty,
+	}
+	switch {
+	case dirty:
+		check.Status, check.Error = domain.HealthFail, fmt.Sprintf("%s: версия %d", migration.ErrDirty, current)
+	case current < expected:
+		check.Status, check.Error = domain.HealthFail, fmt.Sprintf("не применены миграции: версия схемы %d, ожидается %d", current, expected)
+	case current > expected:
+		check.Status, check.Error = domain.HealthFail, fmt.Sprintf("версия схемы %d новее версии приложения %d", current, expected)
+	}
+	return check
+}
+
+// checkPool сообщает загрузку пула соединений. Заполненный пул не считается ошибкой:
+// вывод реплики из балансировки только перенесет нагрузку на остальные
+func (r *HealthRepository) checkPool() domain.HealthCheck {
+	stat := r.pool.Stat()
+
+	saturation := 0.0
+	if stat.MaxConns() > 0 {
+		saturation = float64(stat.AcquiredConns()) / float64(stat.MaxConns())
+	}
+
+	return domain.HealthCheck{
+		Name:   "pool",
+		Status: domain.HealthOK,
+		Details: map[string]interface{}{
+			"acquired":       stat.AcquiredConns(),
+			"idle":           stat.IdleConns(),
+			"total":          stat.TotalConns(),
+			"max":            stat.MaxConns(),
+			"saturation":     saturation,
+			"empty_acquires": stat.EmptyAcquireCount(),
+		},
+	}
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// IdempotencyRepository представляет репозиторий ключей идемпотентности
+type IdempotencyRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewIdempotencyRepository создает новый репозиторий ключей идемпотентности
+func NewIdempotencyRepository(pool *pgxpool.Pool) *IdempotencyRepository {
+	return &IdempotencyRepository{pool: pool}
+}
+
+// Reserve создает запись ключа в состоянии обработки. Истекшая запись с тем же ключом заменяется.
+// Если действующая запись уже есть, она возвращается вместе с false
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error) {
+	// Запись могут удалить между вставкой и чтением, если первый запрос завершился ошибкой;
+	// тогда вставка повторяется
+	for {
+		// Вставка и замена истекшей записи выполняются одним запросом,
+		// поэтому из двух одновременных запросов ключ получает только один
+		err := r.pool.QueryRow(ctx, `
+			INSERT INTO idempotency_keys (subject, key, request_hash, expires_at)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (subject, key) DO UPDATE
+			SET request_hash = EXCLUDED.request_hash,
+				status = NULL,
+				response = NULL,
+				response_headers = NULL,
+				created_at = NOW(),
+				expires_at = EXCLUDED.expires_at
+			WHERE idempotency_keys.expires_at <= NOW()
+			RETURNING created_at
+		`, record.Subject, record.Key, record.RequestHash, record.ExpiresAt).Scan(&record.CreatedAt)
+		if err == nil {
+			return record, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return domain.IdempotencyRecord{}, false, err
+		}
+
+		existing, err := r.get(ctx, record.Subject, record.Key)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		return existing, false, err
+	}
+}
+
+// get возвращает запись ключа
+func (r *IdempotencyRepository) get(ctx context.Context, subject, key string) (domain.IdempotencyRecord, error) {
+	var (
+		record domain.IdempotencyRecord
+		status *int
+	)
+	err := r.pool.QueryRow(ctx, `
+		SELECT subject, key, request_hash, status, response, response_headers, created_at, expires_at
+		FROM idempotency_keys
+		WHERE subject = $1 AND key = $2
+	`, subject, key).Scan(
+		&record.Subject,
+		&record.Key,
+		&record.RequestHash,
+		&status,
+		&record.Response,
+		&record.Headers,
+		&record.CreatedAt,
+		&record.ExpiresAt,
+	)
+	if err != nil {
+		return domain.IdempotencyRecord{}, err
+	}
+	if status != nil {
+		record.Status = *status
+	}
+
+	return record, nil
+}
+
+// Complete сохраняет ответ на запрос с ключом
+func (r *IdempotencyRepository) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	_, err := r.pool.Exec(ctx, `
+		UPDATE idempotency_keys
+		SET status = $3, response = $4, response_headers = $5
+		WHERE subject = $1 AND key = $2 AND status IS NULL
+	`, record.Subject, record.Key, record.Status, record.Response, record.Headers)
+	return err
+}
+
+// Release удаляет запись ключа в состоянии обработки, чтобы запрос можно было повторить
+func (r *IdempotencyRepository) Release(ctx context.Context, subject, key string) error {
+	_, err := r.pool.Exec(ctx, `
+		DELETE FROM idempotency_keys
+		WHERE subject = $1 AND key = $2 AND status IS NULL
+	`, subject, key)
+	return err
+}
+
+// DeleteExpired удаляет записи, истекшие к моменту before, и возвращает их количество
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	tag, err := r.pool.Exec(ctx, `DELETE FROM idempotency_keys WHERE expires_at <= $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
@@ -1,8 +1,12 @@
 package repository
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/danya1733/practiceGO/internal/domain"
 	"github.com/google/uuid"
@@ -20,36 +24,57 @@ func NewInventoryRepository(pool *pgxpool.Pool) *InventoryRepository {
 	return &InventoryRepository{pool: pool}
 }
 
-// Create создает новую запись инвентаризации
-func (r *InventoryRepository) Create(ctx context.Context, inventory domain.Inventory) (domain.Inventory, error) {
+// Create создает новую запись инвентаризации и записывает создание в журнал аудита.
+// Начальное количество записывается в журнал движения как поступление
+func (r *InventoryRepository) Create(ctx context.Context, inventory domain.Inventory, info domain.MovementInfo) (domain.Inventory, error) {
 	query := `
 		INSERT INTO inventory (id, warehouse_id, product_id, quantity, price, discount)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, warehouse_id, product_id, quantity, price, discount
+		RETURNING id, warehouse_id, product_id, quantity, reserved, quantity - reserved, price, discount, version
 	`
 
 	if inventory.ID == uuid.Nil {
 		inventory.ID = uuid.New()
 	}
+	if info.Type == "" {
+		info.Type = domain.MovementReceipt
+	}
 
-	err := r.pool.QueryRow(ctx, query,
-		inventory.ID,
-		inventory.WarehouseID,
-		inventory.ProductID,
-		inventory.Quantity,
-		inventory.Price,
-		inventory.Discount,
-	).Scan(
-		&inventory.ID,
-		&inventory.WarehouseID,
-		&inventory.ProductID,
-		&inventory.Quantity,
-		&inventory.Price,
-		&inventory.Discount,
-	)
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query,
+			inventory.ID,
+			inventory.WarehouseID,
+			inventory.ProductID,
+			inventory.Quantity,
+			inventory.Price,
+			inventory.Discount,
+		).Scan(
+			&inventory.ID,
+			&inventory.WarehouseID,
+			&inventory.ProductID,
+			&inventory.Quantity,
+			&inventory.Reserved,
+			&inventory.Available,
+			&inventory.Price,
+			&inventory.Discount,
+			&inventory.Version,
+		)
+		if err != nil {
+			return err
+		}
+
+		if inventory.Quantity != 0 {
+			movement := newMovement(inventory.WarehouseID, inventory.ProductID, inventory.Quantity, inventory.Quantity, info)
+			if err := insertMovement(ctx, tx, movement); err != nil {
+				return err
+			}
+		}
+
+		return insertAudit(ctx, tx, EntityInventory, inventory.ID, domain.AuditCreate, nil, inventory)
+	})
 
 	if err != nil {
-		return domain.Inventory{}, err
+		return domain.Inventory{}, mapError(err, EntityInventory)
 	}
 
 	return inventory, nil
@@ -57,95 +82,180 @@ func (r *InventoryRepository) Create(ctx context.Context, inventory domain.Inven
 
 // GetByWarehouseAndProduct возвращает инвентаризацию по складу и товару
 func (r *InventoryRepository) GetByWarehouseAndProduct(ctx context.Context, warehouseID, productID uuid.UUID) (domain.Inventory, error) {
+	inventory, err := getInventory(ctx, r.pool, warehouseID, productID, false)
+	if err != nil {
+		return domain.Inventory{}, mapError(err, EntityInventory)
+	}
+	return inventory, nil
+}
+
+// getInventory читает остаток товара на складе; при forUpdate строка блокируется до конца транзакции
+func getInventory(ctx context.Context, q rowQuerier, warehouseID, productID uuid.UUID, forUpdate bool) (domain.Inventory, error) {
 	query := `
-		SELECT id, warehouse_id, product_id, quantity, price, discount
+		SELECT id, warehouse_id, product_id, quantity, reserved, quantity - reserved, price, discount, version
 		FROM inventory
 		WHERE warehouse_id = $1 AND product_id = $2
 	`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
 
 	var inventory domain.Inventory
-	err := r.pool.QueryRow(ctx, query, warehouseID, productID).Scan(
+	err := q.QueryRow(ctx, query, warehouseID, productID).Scan(
 		&inventory.ID,
 		&inventory.WarehouseID,
 		&inventory.ProductID,
 		&inventory.Quantity,
+		&inventory.Reserved,
+		&inventory.Available,
 		&inventory.Price,
 		&inventory.Discount,
+		&inventory.Version,
 	)
-
-	if err != nil {
-		return domain.Inventory{}, err
-	}
-
-	return inventory, nil
+	return inventory, err
 }
 
-// UpdateQuantity обновляет количество товара на складе
-func (r *InventoryRepository) UpdateQuantity(ctx context.Context, warehouseID, productID uuid.UUID, quantity int) (domain.Inventory, error) {
+// UpdateQuantity изменяет количество товара на складе на quantity
+// и записывает изменение в журналы движения и аудита в той же транзакции.
+// Остаток не может стать меньше зарезервированного количества.
+// Если version не 0, остаток изменяется, только если его текущая версия равна version
+func (r *InventoryRepository) UpdateQuantity(ctx context.Context, warehouseID, productID uuid.UUID, quantity, version int, info domain.MovementInfo) (domain.Inventory, error) {
 	query := `
 		UPDATE inventory
 		SET quantity = quantity + $3
-		WHERE warehouse_id = $1 AND product_id = $2
-		RETURNING id, warehouse_id, product_id, quantity, price, discount
+		WHERE warehouse_id = $1 AND product_id = $2 AND ($4 = 0 OR version = $4)
+			AND quantity + $3 >= reserved
+		RETURNING id, warehouse_id, product_id, quantity, reserved, quantity - reserved, price, discount, version
 	`
 
 	var inventory domain.Inventory
-	err := r.pool.QueryRow(ctx, query, warehouseID, productID, quantity).Scan(
-		&inventory.ID,
-		&inventory.WarehouseID,
-		&inventory.ProductID,
-		&inventory.Quantity,
-		&inventory.Price,
-		&inventory.Discount,
-	)
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query, warehouseID, productID, quantity, version).Scan(
+			&inventory.ID,
+			&inventory.WarehouseID,
+			&inventory.ProductID,
+			&inventory.Quantity,
+			&inventory.Reserved,
+			&inventory.Available,
+			&inventory.Price,
+			&inventory.Discount,
+			&inventory.Version,
+		)
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = rejectedQuantityUpdate(ctx, tx, warehouseID, productID, quantity, version)
+		}
+		if err != nil {
+			return err
+		}
+
+		movement := newMovement(warehouseID, productID, quantity, inventory.Quantity, info)
+		if err := insertMovement(ctx, tx, movement); err != nil {
+			return err
+		}
+
+		// Изменение атомарное, поэтому состояние до него восстанавливается по quantity
+		before := inventory
+		before.Quantity -= quantity
+		before.Available -= quantity
+		before.Version--
+
+		return insertAudit(ctx, tx, EntityInventory, inventory.ID, domain.AuditUpdate, before, inventory)
+	})
 
 	if err != nil {
-		return domain.Inventory{}, err
+		return domain.Inventory{}, mapError(err, EntityInventory)
 	}
 
 	return inventory, nil
 }
 
-// UpdateDiscount обновляет скидку на товар
-func (r *InventoryRepository) UpdateDiscount(ctx context.Context, warehouseID, productID uuid.UUID, discount float64) (domain.Inventory, error) {
+// rejectedQuantityUpdate выясняет, почему изменение количества не затронуло остаток:
+// товара нет на складе (pgx.ErrNoRows), версия устарела или доступного количества не хватает
+func rejectedQuantityUpdate(ctx context.Context, tx pgx.Tx, warehouseID, productID uuid.UUID, quantity, version int) error {
+	var available, current int
+	err := tx.QueryRow(ctx, `
+		SELECT quantity - reserved, version FROM inventory
+		WHERE warehouse_id = $1 AND product_id = $2
+		FOR UPDATE
+	`, warehouseID, productID).Scan(&available, &current)
+	if err != nil {
+		return err
+	}
+
+	if version != 0 && current != version {
+		return &Error{Kind: ErrVersionMismatch, Entity: EntityInventory}
+	}
+	return &InsufficientStockError{
+		WarehouseID: warehouseID,
+		ProductID:   productID,
+		Available:   available,
+		Requested:   -quantity,
+	}
+}
+
+// UpdateDiscount обновляет скидку на товар и записывает изменение в журнал аудита.
+// Если version не 0, скидка изменяется, только если текущая версия остатка равна version
+func (r *InventoryRepository) UpdateDiscount(ctx context.Context, warehouseID, productID uuid.UUID, discount float64, version int) (domain.Inventory, error) {
 	query := `
 		UPDATE inventory
 		SET discount = $3
 		WHERE warehouse_id = $1 AND product_id = $2
-		RETURNING id, warehouse_id, product_id, quantity, price, discount
+		RETURNING id, warehouse_id, product_id, quantity, reserved, quantity - reserved, price, discount, version
 	`
 
 	var inventory domain.Inventory
-	err := r.pool.QueryRow(ctx, query, warehouseID, productID, discount).Scan(
-		&inventory.ID,
-		&inventory.WarehouseID,
-		&inventory.ProductID,
-		&inventory.Quantity,
-		&inventory.Price,
-		&inventory.Discount,
-	)
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		// Состояние до изменения читается из заблокированной строки той же транзакции
+		before, err := getInventory(ctx, tx, warehouseID, productID, true)
+		if err != nil {
+			return err
+		}
+		if version != 0 && before.Version != version {
+			return &Error{Kind: ErrVersionMismatch, Entity: EntityInventory}
+		}
 
+		err = tx.QueryRow(ctx, query, warehouseID, productID, discount).Scan(
+			&inventory.ID,
+			&inventory.WarehouseID,
+			&inventory.ProductID,
+			&inventory.Quantity,
+			&inventory.Reserved,
+			&inventory.Available,
+			&inventory.Price,
+			&inventory.Discount,
+			&inventory.Version,
+		)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, EntityInventory, inventory.ID, domain.AuditUpdate, before, inventory)
+	})
 	if err != nil {
-		return domain.Inventory{}, err
+		return domain.Inventory{}, mapError(err, EntityInventory)
 	}
 
 	return inventory, nil
 }
 
-// GetProductsByWarehouse возвращает список товаров на складе с пагинацией
-func (r *InventoryRepository) GetProductsByWarehouse(ctx context.Context, warehouseID uuid.UUID, page, limit int) ([]domain.InventoryWithProduct, error) {
-	query := `
-		SELECT i.id, i.warehouse_id, i.product_id, i.quantity, i.price, i.discount,
-			   p.id, p.name, p.description, p.characteristics, p.weight, p.barcode
+// GetProductsByWarehouse возвращает список товаров на складе с фильтром по характеристикам и пагинацией
+func (r *InventoryRepository) GetProductsByWarehouse(ctx context.Context, warehouseID uuid.UUID, filter domain.WarehouseProductFilter) ([]domain.InventoryWithProduct, error) {
+	args := []interface{}{warehouseID}
+	conditions := append([]string{"i.warehouse_id = $1"},
+		attributeConditions("p.characteristics", filter.Attributes, &args)...)
+
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query := fmt.Sprintf(`
+		SELECT i.id, i.warehouse_id, i.product_id, i.quantity, i.reserved, i.quantity - i.reserved, i.price, i.discount, i.version,
+			   p.id, p.name, p.description, p.characteristics, p.weight, p.barcode, p.version
 		FROM inventory i
 		JOIN products p ON i.product_id = p.id
-		WHERE i.warehouse_id = $1
+		WHERE %s
 		ORDER BY p.name
-		LIMIT $2 OFFSET $3
-	`
+		LIMIT $%d OFFSET $%d
+	`, strings.Join(conditions, " AND "), len(args)-1, len(args))
 
-	offset := (page - 1) * limit
-	rows, err := r.pool.Query(ctx, query, warehouseID, limit, offset)
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -159,14 +269,18 @@ func (r *InventoryRepository) GetProductsByWarehouse(ctx context.Context, wareho
 			&p.WarehouseID,
 			&p.ProductID,
 			&p.Quantity,
+			&p.Reserved,
+			&p.Available,
 			&p.Price,
 			&p.Discount,
+			&p.Version,
 			&p.Product.ID,
 			&p.Product.Name,
 			&p.Product.Description,
 			&p.Product.Characteristics,
 			&p.Product.Weight,
 			&p.Product.Barcode,
+			&p.Product.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -181,62 +295,149 @@ func (r *InventoryRepository) GetProductsByWarehouse(ctx context.Context, wareho
 }
 
 // PurchaseProducts уменьшает количество товаров на складе при покупке
-func (r *InventoryRepository) PurchaseProducts(ctx context.Context, warehouseID uuid.UUID, products []domain.ProductPurchase) error {
-	tx, err := r.pool.Begin(ctx)
+// и сохраняет покупку как заказ с позициями; создание заказа записывается в журнал аудита.
+//
+// Строки инвентаря блокируются (SELECT ... FOR UPDATE) в порядке возрастания ID товара,
+// поэтому параллельные покупки не могут продать больше остатка и не попадают во взаимную блокировку.
+// При ошибках сериализации транзакция повторяется.
+//
+// Если передан reservationID, резерв списывается в той же транзакции, а зарезервированное
+// количество становится доступным для этой покупки. При пустом products покупаются товары из резерва.
+// Цена позиции со скидкой вычисляется функцией price по заблокированным цене и скидке.
+func (r *InventoryRepository) PurchaseProducts(ctx context.Context, warehouseID uuid.UUID, products []domain.ProductPurchase, reservationID *uuid.UUID, info domain.MovementInfo, price domain.PriceFunc) (domain.Order, error) {
+	info.Type = domain.MovementSale
+
+	var order domain.Order
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		order, err = purchaseInTx(ctx, tx, warehouseID, products, reservationID, info, price)
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, EntityOrder, order.ID, domain.AuditCreate, nil, order)
+	})
 	if err != nil {
-		return err
+		return domain.Order{}, mapError(err, EntityInventory)
 	}
-	defer tx.Rollback(ctx)
 
-	// Проверяем наличие и достаточное количество каждого товара
-	for _, p := range products {
-		var currentQuantity int
+	return order, nil
+}
+
+// purchaseInTx выполняет покупку в рамках переданной транзакции
+func purchaseInTx(ctx context.Context, tx pgx.Tx, warehouseID uuid.UUID, products []domain.ProductPurchase, reservationID *uuid.UUID, info domain.MovementInfo, priceWithDiscount domain.PriceFunc) (domain.Order, error) {
+	type lockedRow struct {
+		quantity int
+		reserved int
+		price    float64
+		discount float64
+	}
+
+	// Резерв снимается с тех же строк инвентаря, поэтому его позиции участвуют
+	// в общей блокировке (с нулевым количеством к покупке)
+	released := make(map[uuid.UUID]int)
+	if reservationID != nil {
+		reservedItems, err := consumeReservation(ctx, tx, *reservationID, warehouseID)
+		if err != nil {
+			return domain.Order{}, err
+		}
+		if len(products) == 0 {
+			products = reservedItems
+		}
+
+		lockSet := append([]domain.ProductPurchase{}, products...)
+		for _, item := range reservedItems {
+			released[item.ProductID] += item.Quantity
+			lockSet = append(lockSet, domain.ProductPurchase{ProductID: item.ProductID})
+		}
+		products = lockSet
+	}
+	products = mergePurchases(products)
+
+	// Блокируем строки и проверяем наличие и достаточное количество каждого товара
+	locked := make([]lockedRow, len(products))
+	for i, p := range products {
 		err := tx.QueryRow(ctx, `
-			SELECT quantity FROM inventory 
+			SELECT quantity, reserved, price, discount FROM inventory
 			WHERE warehouse_id = $1 AND product_id = $2
-		`, warehouseID, p.ProductID).Scan(&currentQuantity)
+			FOR UPDATE
+		`, warehouseID, p.ProductID).Scan(&locked[i].quantity, &locked[i].reserved, &locked[i].price, &locked[i].discount)
 
 		if err != nil {
 			if err == pgx.ErrNoRows {
-				return fmt.Errorf("товар с ID %s не найден на складе %s", p.ProductID, warehouseID)
+				return domain.Order{}, inventoryNotFound(warehouseID, p.ProductID)
 			}
-			return err
+			return domain.Order{}, err
 		}
 
-		if currentQuantity < p.Quantity {
-			return fmt.Errorf("недостаточное количество товара %s на складе: доступно %d, запрошено %d",
-				p.ProductID, currentQuantity, p.Quantity)
+		available := locked[i].quantity - max(locked[i].reserved-released[p.ProductID], 0)
+		if available < p.Quantity {
+			return domain.Order{}, &InsufficientStockError{
+				WarehouseID: warehouseID,
+				ProductID:   p.ProductID,
+				Available:   available,
+				Requested:   p.Quantity,
+			}
 		}
 	}
 
+	order := domain.Order{
+		ID:          uuid.New(),
+		WarehouseID: warehouseID,
+		Items:       make([]domain.OrderItem, 0, len(products)),
+	}
+
 	// Уменьшаем количество товаров и сохраняем аналитику
-	for _, p := range products {
-		var price, discount float64
+	for i, p := range products {
+		price, discount := locked[i].price, locked[i].discount
+		release := min(released[p.ProductID], locked[i].reserved)
 
-		// Получаем текущую цену и скидку
+		// Уменьшаем количество товара и снимаем резерв;
+		// условие на доступный остаток страхует от продажи в минус
+		var quantityAfter int
 		err := tx.QueryRow(ctx, `
-			SELECT price, discount FROM inventory 
-			WHERE warehouse_id = $1 AND product_id = $2
-		`, warehouseID, p.ProductID).Scan(&price, &discount)
+			UPDATE inventory
+			SET quantity = quantity - $3, reserved = reserved - $4
+			WHERE warehouse_id = $1 AND product_id = $2 AND quantity - (reserved - $4) >= $3
+			RETURNING quantity
+		`, warehouseID, p.ProductID, p.Quantity, release).Scan(&quantityAfter)
 
 		if err != nil {
-			return err
+			if err == pgx.ErrNoRows {
+				return domain.Order{}, &InsufficientStockError{
+					WarehouseID: warehouseID,
+					ProductID:   p.ProductID,
+					Requested:   p.Quantity,
+				}
+			}
+			return domain.Order{}, err
+		}
+
+		// Позиция резерва, которая не покупается, только освобождается
+		if p.Quantity == 0 {
+			continue
+		}
+
+		// Записываем продажу в журнал движения
+		movement := newMovement(warehouseID, p.ProductID, -p.Quantity, quantityAfter, info)
+		movement.ReferenceID = &order.ID
+		if err := insertMovement(ctx, tx, movement); err != nil {
+			return domain.Order{}, err
 		}
 
 		// Вычисляем финальную цену с учетом скидки
-		finalPrice := price * (1 - discount/100)
+		finalPrice := priceWithDiscount(price, discount)
 		totalSum := finalPrice * float64(p.Quantity)
 
-		// Уменьшаем количество товара
-		_, err = tx.Exec(ctx, `
-			UPDATE inventory 
-			SET quantity = quantity - $3 
-			WHERE warehouse_id = $1 AND product_id = $2
-		`, warehouseID, p.ProductID, p.Quantity)
-
-		if err != nil {
-			return err
-		}
+		order.Items = append(order.Items, domain.OrderItem{
+			ID:                uuid.New(),
+			ProductID:         p.ProductID,
+			Quantity:          p.Quantity,
+			Price:             price,
+			Discount:          discount,
+			PriceWithDiscount: finalPrice,
+			TotalPrice:        totalSum,
+		})
+		order.TotalSum += totalSum
 
 		// Записываем аналитику
 		_, err = tx.Exec(ctx, `
@@ -247,10 +448,133 @@ func (r *InventoryRepository) PurchaseProducts(ctx context.Context, warehouseID
 				total_sum = analytics.total_sum + $5
 		`, uuid.New(), warehouseID, p.ProductID, p.Quantity, totalSum)
 
+		if err != nil {
+			return domain.Order{}, err
+		}
+	}
+
+	// Сохраняем заказ
+	if err := insertOrder(ctx, tx, &order); err != nil {
+		return domain.Order{}, err
+	}
+
+	return order, nil
+}
+
+// mergePurchases объединяет повторяющиеся товары и сортирует их по ID,
+// чтобы строки инвентаря всегда блокировались в одном и том же порядке
+func mergePurchases(products []domain.ProductPurchase) []domain.ProductPurchase {
+	quantities := make(map[uuid.UUID]int, len(products))
+	merged := make([]domain.ProductPurchase, 0, len(products))
+	for _, p := range products {
+		if _, ok := quantities[p.ProductID]; !ok {
+			merged = append(merged, domain.ProductPurchase{ProductID: p.ProductID})
+		}
+		quantities[p.ProductID] += p.Quantity
+	}
+
+	for i := range merged {
+		merged[i].Quantity = quantities[merged[i].ProductID]
+	}
+
+	slices.SortFunc(merged, func(a, b domain.ProductPurchase) int {
+		return bytes.Compare(a.ProductID[:], b.ProductID[:])
+	})
+
+	return merged
+}
+
+// RebuildFromLedger сверяет остатки с суммой журнала движения.
+// Если dryRun == false, расходящиеся остатки приводятся к значению из журнала.
+// warehouseID ограничивает сверку одним складом.
+// Остаток, для которого значение из журнала меньше резерва, не изменяется и отмечается как конфликт.
+// Каждое исправление записывается в журнал аудита
+func (r *InventoryRepository) RebuildFromLedger(ctx context.Context, warehouseID *uuid.UUID, dryRun bool) ([]domain.LedgerDiscrepancy, error) {
+	var discrepancies []domain.LedgerDiscrepancy
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		discrepancies = nil
+
+		// Остатки блокируются до подсчета сумм: каждое изменение остатка обновляет его строку
+		// и записывает движение в одной транзакции, поэтому до конца сверки ни одно движение
+		// по проверяемым остаткам не появится. Суммы считаются следующим запросом,
+		// который в READ COMMITTED видит все движения, зафиксированные до блокировки
+		_, err := tx.Exec(ctx, `
+			SELECT 1 FROM inventory
+			WHERE $1::UUID IS NULL OR warehouse_id = $1
+			ORDER BY warehouse_id, product_id
+			FOR UPDATE
+		`, warehouseID)
 		if err != nil {
 			return err
 		}
+
+		rows, err := tx.Query(ctx, `
+			SELECT i.warehouse_id, i.product_id, i.quantity, COALESCE(SUM(m.quantity_delta), 0)::INTEGER, i.reserved
+			FROM inventory i
+			LEFT JOIN stock_movements m
+				ON m.warehouse_id = i.warehouse_id AND m.product_id = i.product_id
+			WHERE $1::UUID IS NULL OR i.warehouse_id = $1
+			GROUP BY i.id, i.warehouse_id, i.product_id, i.quantity, i.reserved
+			HAVING i.quantity <> COALESCE(SUM(m.quantity_delta), 0)
+			ORDER BY i.warehouse_id, i.product_id
+		`, warehouseID)
+		if err != nil {
+			return err
+		}
+
+		discrepancies, err = pgx.CollectRows(rows, func(row pgx.CollectableRow) (domain.LedgerDiscrepancy, error) {
+			var d domain.LedgerDiscrepancy
+			err := row.Scan(&d.WarehouseID, &d.ProductID, &d.Quantity, &d.LedgerQuantity, &d.Reserved)
+			d.Conflict = d.LedgerQuantity < d.Reserved
+			return d, err
+		})
+		if err != nil {
+			return err
+		}
+
+		if dryRun {
+			return nil
+		}
+
+		for _, d := range discrepancies {
+			if !d.Fixable() {
+				continue
+			}
+			var after domain.Inventory
+			err := tx.QueryRow(ctx, `
+				UPDATE inventory SET quantity = $3
+				WHERE warehouse_id = $1 AND product_id = $2
+				RETURNING id, warehouse_id, product_id, quantity, reserved, quantity - reserved, price, discount, version
+			`, d.WarehouseID, d.ProductID, d.LedgerQuantity).Scan(
+				&after.ID,
+				&after.WarehouseID,
+				&after.ProductID,
+				&after.Quantity,
+				&after.Reserved,
+				&after.Available,
+				&after.Price,
+				&after.Discount,
+				&after.Version,
+			)
+			if err != nil {
+				return err
+			}
+
+			before := after
+			before.Quantity = d.Quantity
+			before.Available = d.Quantity - before.Reserved
+			before.Version--
+			if err := insertAudit(ctx, tx, EntityInventory, after.ID, domain.AuditRebuild, before, after); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
 	}
 
-	return tx.Commit(ctx)
+	return discrepancies, nil
 }
@@ -0,0 +1,188 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/testdb"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// seedInventory создает склад и товар с остатком quantity
+func seedInventory(t *testing.T, pool *pgxpool.Pool, quantity int) (domain.Warehouse, domain.Product) {
+	t.Helper()
+	ctx := context.Background()
+
+	warehouse, err := NewWarehouseRepository(pool).Create(ctx, domain.Warehouse{Address: "test " + uuid.NewString()})
+	if err != nil {
+		t.Fatalf("создание склада: %v", err)
+	}
+	product, err := NewProductRepository(pool).Create(ctx, domain.Product{
+		Name:            "test",
+		Characteristics: []byte(`{}`),
+		Weight:          1,
+		Barcode:         uuid.NewString(),
+	})
+	if err != nil {
+		t.Fatalf("создание товара: %v", err)
+	}
+	_, err = NewInventoryRepository(pool).Create(ctx, domain.Inventory{
+		WarehouseID: warehouse.ID,
+		ProductID:   product.ID,
+		Quantity:    quantity,
+		Price:       100,
+	}, domain.MovementInfo{Type: domain.MovementReceipt})
+	if err != nil {
+		t.Fatalf("создание остатка: %v", err)
+	}
+
+	return warehouse, product
+}
+
+func TestPurchaseProductsConcurrentNoOversell(t *testing.T) {
+	pool := testdb.Pool(t)
+	ctx := context.Background()
+
+	const (
+		stock  = 10
+		buyers = 50
+	)
+	warehouse, product := seedInventory(t, pool, stock)
+	inventory := NewInventoryRepository(pool)
+	price := func(price, discount float64) float64 { return price * (1 - discount/100) }
+
+	var (
+		wg     sync.WaitGroup
+		sold   atomic.Int64
+		start  = make(chan struct{})
+		failed = make(chan error, buyers)
+	)
+	for range buyers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			_, err := inventory.PurchaseProducts(ctx, warehouse.ID,
+				[]domain.ProductPurchase{{ProductID: product.ID, Quantity: 1}}, nil, domain.MovementInfo{}, price)
+			var stockErr *InsufficientStockError
+			switch {
+			case err == nil:
+				sold.Add(1)
+			case !errors.As(err, &stockErr):
+				failed <- err
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	close(failed)
+
+	for err := range failed {
+		t.Errorf("покупка завершилась неожиданной ошибкой: %v", err)
+	}
+
+	final, err := inventory.GetByWarehouseAndProduct(ctx, warehouse.ID, product.ID)
+	if err != nil {
+		t.Fatalf("получение остатка: %v", err)
+	}
+	if final.Quantity < 0 {
+		t.Errorf("остаток ушел в минус: %d", final.Quantity)
+	}
+	if got := sold.Load(); got != stock {
+		t.Errorf("продано %d единиц, ожидалось %d", got, stock)
+	}
+	if final.Quantity != 0 {
+		t.Errorf("остаток после распродажи %d, ожидался 0", final.Quantity)
+	}
+
+	var ordered int
+	err = pool.QueryRow(ctx, `
+		SELECT coalesce(sum(oi.quantity), 0)
+		FROM order_items oi
+		JOIN orders o ON o.id = oi.order_id
+		WHERE o.warehouse_id = $1
+	`, warehouse.ID).Scan(&ordered)
+	if err != nil {
+		t.Fatalf("подсчет заказанных единиц: %v", err)
+	}
+	if ordered != stock {
+		t.Errorf("в заказах %d единиц, ожидалось %d", ordered, stock)
+	}
+}
+
+func TestUpdateQuantityBelowReserved(t *testing.T) {
+	pool := testdb.Pool(t)
+	ctx := context.Background()
+
+	warehouse, product := seedInventory(t, pool, 5)
+	_, err := NewReservationRepository(pool).Create(ctx, warehouse.ID,
+		[]domain.ProductPurchase{{ProductID: product.ID, Quantity: 3}}, time.Minute)
+	if err != nil {
+		t.Fatalf("создание резерва: %v", err)
+	}
+
+	inventory := NewInventoryRepository(pool)
+	_, err = inventory.UpdateQuantity(ctx, warehouse.ID, product.ID, -3, 0, domain.MovementInfo{Type: domain.MovementAdjustment})
+	var stockErr *InsufficientStockError
+	if !errors.As(err, &stockErr) {
+		t.Fatalf("ожидалась InsufficientStockError, получено %v", err)
+	}
+	if stockErr.Available != 2 || stockErr.Requested != 3 {
+		t.Errorf("доступно %d, запрошено %d; ожидалось 2 и 3", stockErr.Available, stockErr.Requested)
+	}
+
+	final, err := inventory.GetByWarehouseAndProduct(ctx, warehouse.ID, product.ID)
+	if err != nil {
+		t.Fatalf("получение остатка: %v", err)
+	}
+	if final.Quantity != 5 {
+		t.Errorf("остаток изменился: %d, ожидалось 5", final.Quantity)
+	}
+}
+
+func TestRebuildFromLedgerReportsConflictBelowReserved(t *testing.T) {
+	pool := testdb.Pool(t)
+	ctx := context.Background()
+
+	warehouse, product := seedInventory(t, pool, 5)
+	_, err := NewReservationRepository(pool).Create(ctx, warehouse.ID,
+		[]domain.ProductPurchase{{ProductID: product.ID, Quantity: 3}}, time.Minute)
+	if err != nil {
+		t.Fatalf("создание резерва: %v", err)
+	}
+	// Журнал дает 1 при резерве 3: исправление нарушило бы CHECK quantity >= reserved
+	_, err = pool.Exec(ctx, `
+		INSERT INTO stock_movements (id, warehouse_id, product_id, type, quantity_delta, quantity_after)
+		VALUES ($1, $2, $3, 'adjustment', -4, 0)
+	`, uuid.New(), warehouse.ID, product.ID)
+	if err != nil {
+		t.Fatalf("запись движения: %v", err)
+	}
+
+	inventory := NewInventoryRepository(pool)
+	discrepancies, err := inventory.RebuildFromLedger(ctx, &warehouse.ID, false)
+	if err != nil {
+		t.Fatalf("сверка: %v", err)
+	}
+	if len(discrepancies) != 1 {
+		t.Fatalf("найдено %d расхождений, ожидалось 1: %+v", len(discrepancies), discrepancies)
+	}
+	if d := discrepancies[0]; !d.Conflict || d.LedgerQuantity != 1 || d.Reserved != 3 {
+		t.Errorf("ожидался конфликт с журналом 1 и резервом 3, получено %+v", d)
+	}
+
+	final, err := inventory.GetByWarehouseAndProduct(ctx, warehouse.ID, product.ID)
+	if err != nil {
+		t.Fatalf("получение остатка: %v", err)
+	}
+	if final.Quantity != 5 {
+		t.Errorf("остаток изменился: %d, ожидалось 5", final.Quantity)
+	}
+}
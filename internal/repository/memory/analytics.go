@@ -0,0 +1,176 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+// AnalyticsRepository строит аналитику продаж по данным в памяти
+type AnalyticsRepository struct {
+	s *Store
+}
+
+// NewAnalyticsRepository создает новый репозиторий аналитики в памяти
+func NewAnalyticsRepository(s *Store) *AnalyticsRepository {
+	return &AnalyticsRepository{s: s}
+}
+
+// GetWarehouseAnalytics возвращает аналитику по складу
+func (r *AnalyticsRepository) GetWarehouseAnalytics(ctx context.Context, warehouseID uuid.UUID) ([]domain.Analytics, float64, error) {
+	var (
+		analytics []domain.Analytics
+		totalSum  float64
+	)
+	err := r.s.view(func() error {
+		for key, a := range r.s.analytics {
+			if key.warehouseID != warehouseID {
+				continue
+			}
+			analytics = append(analytics, a)
+			totalSum += a.TotalSum
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	slices.SortFunc(analytics, func(a, b domain.Analytics) int {
+		return cmp.Or(cmp.Compare(b.TotalSum, a.TotalSum), compareIDs(a.ProductID, b.ProductID))
+	})
+
+	return analytics, totalSum, nil
+}
+
+// GetTopWarehouses возвращает топ-N складов по выручке.
+// Если задан период [from, to), выручка считается по заказам за этот период, иначе за все время
+func (r *AnalyticsRepository) GetTopWarehouses(ctx context.Context, limit int, from, to *time.Time) ([]domain.WarehouseAnalytics, error) {
+	var warehouses []domain.WarehouseAnalytics
+	err := r.s.view(func() error {
+		revenue := make(map[uuid.UUID]float64)
+		if from != nil || to != nil {
+			for _, o := range r.s.orders {
+				if inPeriod(o.CreatedAt, from, to) {
+					revenue[o.WarehouseID] += o.TotalSum
+				}
+			}
+		} else {
+			for key, a := range r.s.analytics {
+				revenue[key.warehouseID] += a.TotalSum
+			}
+		}
+
+		for _, w := range r.s.warehouses {
+			warehouses = append(warehouses, domain.WarehouseAnalytics{
+				WarehouseID: w.ID,
+				Address:     w.Address,
+				TotalSum:    revenue[w.ID],
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(warehouses, func(a, b domain.WarehouseAnalytics) int {
+		return cmp.Or(cmp.Compare(b.TotalSum, a.TotalSum), compareIDs(a.WarehouseID, b.WarehouseID))
+	})
+
+	return warehouses[:min(limit, len(warehouses))], nil
+}
+
+// GetSalesSeries возвращает продажи за период, сгруппированные по дням, неделям или месяцам.
+// Интервалы без продаж присутствуют в ряду с нулевыми значениями; границы интервалов считаются в UTC
+func (r *AnalyticsRepository) GetSalesSeries(ctx context.Context, q domain.SalesQuery) (domain.SalesSeries, error) {
+	series := domain.SalesSeries{
+		Granularity: q.Granularity,
+		From:        q.From,
+		To:          q.To,
+		Buckets:     []domain.SalesBucket{},
+	}
+
+	index := make(map[time.Time]int)
+	for start := truncate(q.From, q.Granularity); start.Before(q.To); start = next(start, q.Granularity) {
+		index[start] = len(series.Buckets)
+		series.Buckets = append(series.Buckets, domain.SalesBucket{PeriodStart: start})
+	}
+
+	err := r.s.view(func() error {
+		for _, o := range r.s.orders {
+			if o.CreatedAt.Before(q.From) || !o.CreatedAt.Before(q.To) {
+				continue
+			}
+			if q.WarehouseID != nil && o.WarehouseID != *q.WarehouseID {
+				continue
+			}
+
+			i, ok := index[truncate(o.CreatedAt, q.Granularity)]
+			if !ok {
+				continue
+			}
+
+			counted := false
+			for _, item := range o.Items {
+				if q.ProductID != nil && item.ProductID != *q.ProductID {
+					continue
+				}
+				if !counted {
+					series.Buckets[i].Orders++
+					counted = true
+				}
+				series.Buckets[i].Units += item.Quantity
+				series.Buckets[i].Revenue += item.TotalPrice
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.SalesSeries{}, err
+	}
+
+	for _, b := range series.Buckets {
+		series.TotalUnits += b.Units
+		series.TotalRevenue += b.Revenue
+	}
+
+	return series, nil
+}
+
+// inPeriod проверяет, что момент t попадает в полуинтервал [from, to)
+func inPeriod(t time.Time, from, to *time.Time) bool {
+	return (from == nil || !t.Before(*from)) && (to == nil || t.Before(*to))
+}
+
+// truncate возвращает начало интервала, содержащего t, в UTC, аналог date_trunc в PostgreSQL.
+// Неделя начинается с понедельника
+func truncate(t time.Time, granularity domain.SalesGranularity) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+
+	switch granularity {
+	case domain.GranularityWeek:
+		return day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	case domain.GranularityMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+// next возвращает начало следующего интервала
+func next(start time.Time, granularity domain.SalesGranularity) time.Time {
+	switch granularity {
+	case domain.GranularityWeek:
+		return start.AddDate(0, 0, 7)
+	case domain.GranularityMonth:
+		return start.AddDate(0, 1, 0)
+	default:
+		return start.AddDate(0, 0, 1)
+	}
+}
@@ -0,0 +1,158 @@
+package memory
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+// matchAttributes проверяет характеристики товара по фильтрам так же, как хранилище PostgreSQL:
+// равенство сравнивает строку, а для записей чисел и true/false - и типизированное значение,
+// сравнения gt, gte, lt и lte выполняются только для числовых характеристик
+func matchAttributes(characteristics json.RawMessage, filters []domain.AttributeFilter) bool {
+	if len(filters) == 0 {
+		return true
+	}
+
+	doc := decodeCharacteristics(characteristics)
+	for _, f := range filters {
+		value, ok := lookupAttribute(doc, f.Path)
+
+		if f.Op.Ordered() {
+			n, isNumber := value.(json.Number)
+			if !ok || !isNumber {
+				return false
+			}
+			v, err := n.Float64()
+			if err != nil || !compareAttribute(v, f.Op, f.Number) {
+				return false
+			}
+			continue
+		}
+
+		equal := ok && slices.ContainsFunc(f.Values, func(s string) bool { return attributeEquals(value, s) })
+		if equal != (f.Op != domain.AttributeNe) {
+			return false
+		}
+	}
+	return true
+}
+
+// compareAttribute сравнивает значение характеристики с границей фильтра
+func compareAttribute(v float64, op domain.AttributeOp, bound float64) bool {
+	switch op {
+	case domain.AttributeGt:
+		return v > bound
+	case domain.AttributeGte:
+		return v >= bound
+	case domain.AttributeLt:
+		return v < bound
+	default:
+		return v <= bound
+	}
+}
+
+// attributeEquals проверяет, совпадает ли значение характеристики со значением фильтра s
+func attributeEquals(value interface{}, s string) bool {
+	switch v := value.(type) {
+	case string:
+		return v == s
+	case bool:
+		return strconv.FormatBool(v) == s
+	case json.Number:
+		if !json.Valid([]byte(s)) {
+			return false
+		}
+		a, errA := strconv.ParseFloat(v.String(), 64)
+		b, errB := strconv.ParseFloat(s, 64)
+		return errA == nil && errB == nil && a == b
+	}
+	return false
+}
+
+// decodeCharacteristics разбирает характеристики товара; не объект считается пустыми характеристиками
+func decodeCharacteristics(data json.RawMessage) map[string]interface{} {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc map[string]interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil
+	}
+	return doc
+}
+
+// lookupAttribute возвращает значение по пути в характеристиках
+func lookupAttribute(doc map[string]interface{}, path []string) (interface{}, bool) {
+	var value interface{} = doc
+	for _, key := range path {
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if value, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return value, true
+}
+
+// attributeFacets подсчитывает значения характеристик товаров. Вложенные объекты раскрываются
+// в пути через точку; учитываются только строковые, числовые и логические значения,
+// не больше limit самых частых на характеристику
+func attributeFacets(products []domain.Product, limit int) []domain.AttributeFacet {
+	type facet struct {
+		path   []string
+		counts map[string]int
+	}
+	facets := make(map[string]*facet)
+
+	var walk func(path []string, value interface{})
+	walk = func(path []string, value interface{}) {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			for key, nested := range v {
+				walk(append(slices.Clone(path), key), nested)
+			}
+		case string, bool, json.Number:
+			key := strings.Join(path, ".")
+			f, ok := facets[key]
+			if !ok {
+				f = &facet{path: path, counts: make(map[string]int)}
+				facets[key] = f
+			}
+			raw, _ := json.Marshal(v)
+			f.counts[string(raw)]++
+		}
+	}
+	for _, p := range products {
+		walk(nil, decodeCharacteristics(p.Characteristics))
+	}
+
+	result := make([]domain.AttributeFacet, 0, len(facets))
+	for key, f := range facets {
+		facet := domain.AttributeFacet{Key: key}
+		for value, count := range f.counts {
+			facet.Count += count
+			facet.Values = append(facet.Values, domain.AttributeValueCount{Value: json.RawMessage(value), Count: count})
+		}
+		slices.SortFunc(facet.Values, func(a, b domain.AttributeValueCount) int {
+			return cmp.Or(cmp.Compare(b.Count, a.Count), bytes.Compare(a.Value, b.Value))
+		})
+		if len(facet.Values) > limit {
+			facet.Values = facet.Values[:limit]
+		}
+		result = append(result, facet)
+	}
+
+	slices.SortFunc(result, func(a, b domain.AttributeFacet) int {
+		return slices.Compare(facets[a.Key].path, facets[b.Key].path)
+	})
+
+	return result
+}
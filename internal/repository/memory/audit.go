@@ -0,0 +1,87 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"slices"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/pkg/requestctx"
+	"github.com/google/uuid"
+)
+
+// AuditRepository хранит журнал аудита в памяти
+type AuditRepository struct {
+	s *Store
+}
+
+// NewAuditRepository создает новый репозиторий журнала аудита в памяти
+func NewAuditRepository(s *Store) *AuditRepository {
+	return &AuditRepository{s: s}
+}
+
+// addAudit записывает изменение сущности в журнал аудита внутри update,
+// поэтому при откате изменения запись тоже откатывается.
+// Клиент и идентификатор запроса берутся из контекста, так же как в репозиториях PostgreSQL
+func (s *Store) addAudit(ctx context.Context, t *tx, entity string, entityID uuid.UUID, action domain.AuditAction, before, after interface{}) error {
+	entry := domain.AuditEntry{
+		ID:        uuid.New(),
+		Actor:     requestctx.Actor(ctx),
+		RequestID: requestctx.RequestID(ctx),
+		Entity:    entity,
+		EntityID:  entityID,
+		Action:    action,
+		CreatedAt: s.now(),
+	}
+
+	var err error
+	if entry.Before, err = marshalState(before); err != nil {
+		return err
+	}
+	if entry.After, err = marshalState(after); err != nil {
+		return err
+	}
+
+	n := len(s.audit)
+	t.onRollback(func() { s.audit = s.audit[:n] })
+	s.audit = append(s.audit, entry)
+	return nil
+}
+
+// marshalState сериализует состояние сущности; nil остается пустым
+func marshalState(state interface{}) (json.RawMessage, error) {
+	if state == nil {
+		return nil, nil
+	}
+	return json.Marshal(state)
+}
+
+// GetAll возвращает записи журнала аудита по фильтру, начиная с последних
+func (r *AuditRepository) GetAll(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	var entries []domain.AuditEntry
+	err := r.s.view(func() error {
+		for _, e := range r.s.audit {
+			switch {
+			case filter.Entity != "" && e.Entity != filter.Entity,
+				filter.EntityID != nil && e.EntityID != *filter.EntityID,
+				filter.Action != "" && e.Action != filter.Action,
+				filter.Actor != "" && e.Actor != filter.Actor,
+				filter.RequestID != "" && e.RequestID != filter.RequestID,
+				!inPeriod(e.CreatedAt, filter.From, filter.To):
+				continue
+			}
+			entries = append(entries, e)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(entries, func(a, b domain.AuditEntry) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), compareIDs(a.ID, b.ID))
+	})
+
+	return paginate(entries, filter.Page, filter.Limit), nil
+}
@@ -0,0 +1,151 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/google/uuid"
+)
+
+// apiKeyRow API ключ вместе с хешем, по которому он ищется
+type apiKeyRow struct {
+	key  domain.APIKey
+	hash string
+}
+
+// AuthRepository хранит пользователей и API ключи в памяти
+type AuthRepository struct {
+	s *Store
+}
+
+// NewAuthRepository создает новый репозиторий пользователей и API ключей в памяти
+func NewAuthRepository(s *Store) *AuthRepository {
+	return &AuthRepository{s: s}
+}
+
+// EnsureUser создает пользователя при первом обращении или обновляет его роль и склады
+func (r *AuthRepository) EnsureUser(ctx context.Context, user domain.User) (domain.User, error) {
+	user.Warehouses = slices.Clone(user.Warehouses)
+	if user.Warehouses == nil {
+		user.Warehouses = []uuid.UUID{}
+	}
+
+	err := r.s.update(func(t *tx) error {
+		user.ID, user.CreatedAt = uuid.New(), r.s.now()
+		for _, u := range r.s.users {
+			if u.Username == user.Username {
+				user.ID, user.CreatedAt = u.ID, u.CreatedAt
+				break
+			}
+		}
+
+		put(t, r.s.users, user.ID, user)
+		return nil
+	})
+	if err != nil {
+		return domain.User{}, err
+	}
+
+	return user, nil
+}
+
+// CreateAPIKey сохраняет API ключ и записывает его выпуск в журнал аудита.
+// Сам ключ не хранится ни в таблице, ни в журнале, только его хеш
+func (r *AuthRepository) CreateAPIKey(ctx context.Context, key domain.APIKey, hash string) (domain.APIKey, error) {
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+	key.CreatedAt = r.s.now()
+
+	err := r.s.update(func(t *tx) error {
+		if _, ok := r.s.users[key.UserID]; !ok {
+			return invalidReference(repository.EntityAPIKey, "user_id")
+		}
+		for _, row := range r.s.apiKeys {
+			if row.hash == hash {
+				return conflict(repository.EntityAPIKey, "key_hash")
+			}
+		}
+
+		put(t, r.s.apiKeys, key.ID, apiKeyRow{key: key, hash: hash})
+		return r.s.addAudit(ctx, t, repository.EntityAPIKey, key.ID, domain.AuditCreate, nil, key)
+	})
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	return key, nil
+}
+
+// GetAPIKeyByHash возвращает API ключ по хешу вместе с его владельцем
+func (r *AuthRepository) GetAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, domain.User, error) {
+	var (
+		key  domain.APIKey
+		user domain.User
+	)
+	err := r.s.view(func() error {
+		for _, row := range r.s.apiKeys {
+			if row.hash == hash {
+				key, user = row.key, r.s.users[row.key.UserID]
+				return nil
+			}
+		}
+		return notFound(repository.EntityAPIKey)
+	})
+	if err != nil {
+		return domain.APIKey{}, domain.User{}, err
+	}
+
+	return key, user, nil
+}
+
+// ListAPIKeys возвращает API ключи пользователя, начиная с последних
+func (r *AuthRepository) ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]domain.APIKey, error) {
+	var keys []domain.APIKey
+	err := r.s.view(func() error {
+		for _, row := range r.s.apiKeys {
+			if row.key.UserID == userID {
+				keys = append(keys, row.key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(keys, func(a, b domain.APIKey) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), compareIDs(a.ID, b.ID))
+	})
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API ключ пользователя и записывает отзыв в журнал аудита.
+// Повторный отзыв не меняет время отзыва
+func (r *AuthRepository) RevokeAPIKey(ctx context.Context, id, userID uuid.UUID) (domain.APIKey, error) {
+	var key domain.APIKey
+	err := r.s.update(func(t *tx) error {
+		row, ok := r.s.apiKeys[id]
+		if !ok || row.key.UserID != userID {
+			return notFound(repository.EntityAPIKey)
+		}
+
+		if row.key.RevokedAt == nil {
+			now := r.s.now()
+			row.key.RevokedAt = &now
+			put(t, r.s.apiKeys, id, row)
+		}
+
+		key = row.key
+		return r.s.addAudit(ctx, t, repository.EntityAPIKey, key.ID, domain.AuditRevoke, nil, key)
+	})
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,27 @@
+package memory
+
+import (
+	"context"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+// HealthRepository проверяет состояние хранилища в памяти
+type HealthRepository struct {
+	s *Store
+}
+
+// NewHealthRepository создает новый репозиторий проверок состояния в памяти
+func NewHealthRepository(s *Store) *HealthRepository {
+	return &HealthRepository{s: s}
+}
+
+// Checks возвращает единственную проверку: хранилище в памяти доступно, пока работает процесс,
+// а схемы и пула соединений у него нет
+func (r *HealthRepository) Checks(ctx context.Context) []domain.HealthCheck {
+	return []domain.HealthCheck{{
+		Name:    "storage",
+		Status:  domain.HealthOK,
+		Details: map[string]string{"backend": "memory"},
+	}}
+}
@@ -0,0 +1,97 @@
+package memory
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+// idempotencyKey идентифицирует ключ идемпотентности, аналог PRIMARY KEY (subject, key)
+type idempotencyKey struct {
+	subject string
+	key     string
+}
+
+// IdempotencyRepository хранит ключи идемпотентности в памяти
+type IdempotencyRepository struct {
+	s *Store
+}
+
+// NewIdempotencyRepository создает новый репозиторий ключей идемпотентности в памяти
+func NewIdempotencyRepository(s *Store) *IdempotencyRepository {
+	return &IdempotencyRepository{s: s}
+}
+
+// Reserve создает запись ключа в состоянии обработки. Истекшая запись с тем же ключом заменяется.
+// Если действующая запись уже есть, она возвращается вместе с false
+func (r *IdempotencyRepository) Reserve(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error) {
+	var (
+		existing domain.IdempotencyRecord
+		reserved bool
+	)
+	err := r.s.update(func(t *tx) error {
+		k := idempotencyKey{subject: record.Subject, key: record.Key}
+		now := r.s.now()
+
+		if current, ok := r.s.idempotency[k]; ok && current.ExpiresAt.After(now) {
+			existing = current
+			return nil
+		}
+
+		record.Status, record.Response, record.Headers = 0, nil, nil
+		record.CreatedAt = now
+		r.s.idempotency[k] = record
+		existing, reserved = record, true
+		return nil
+	})
+	if err != nil {
+		return domain.IdempotencyRecord{}, false, err
+	}
+
+	return existing, reserved, nil
+}
+
+// Complete сохраняет ответ на запрос с ключом
+func (r *IdempotencyRepository) Complete(ctx context.Context, record domain.IdempotencyRecord) error {
+	return r.s.update(func(t *tx) error {
+		k := idempotencyKey{subject: record.Subject, key: record.Key}
+		current, ok := r.s.idempotency[k]
+		if !ok || current.Completed() {
+			return nil
+		}
+
+		current.Status = record.Status
+		current.Response = append([]byte(nil), record.Response...)
+		current.Headers = maps.Clone(record.Headers)
+		r.s.idempotency[k] = current
+		return nil
+	})
+}
+
+// Release удаляет запись ключа в состоянии обработки, чтобы запрос можно было повторить
+func (r *IdempotencyRepository) Release(ctx context.Context, subject, key string) error {
+	return r.s.update(func(t *tx) error {
+		k := idempotencyKey{subject: subject, key: key}
+		if current, ok := r.s.idempotency[k]; ok && !current.Completed() {
+			delete(r.s.idempotency, k)
+		}
+		return nil
+	})
+}
+
+// DeleteExpired удаляет записи, истекшие к моменту before, и возвращает их количество
+func (r *IdempotencyRepository) DeleteExpired(ctx context.Context, before time.Time) (int, error) {
+	deleted := 0
+	err := r.s.update(func(t *tx) error {
+		for k, record := range r.s.idempotency {
+			if !record.ExpiresAt.After(before) {
+				delete(r.s.idempotency, k)
+				deleted++
+			}
+		}
+		return nil
+	})
+	return deleted, err
+}
@@ -0,0 +1,354 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/google/uuid"
+)
+
+// InventoryRepository хранит остатки товаров на складах в памяти
+type InventoryRepository struct {
+	s *Store
+}
+
+// NewInventoryRepository создает новый репозиторий остатков в памяти
+func NewInventoryRepository(s *Store) *InventoryRepository {
+	return &InventoryRepository{s: s}
+}
+
+// Create создает новую запись инвентаризации и записывает создание в журнал аудита.
+// Начальное количество записывается в журнал движения как поступление
+func (r *InventoryRepository) Create(ctx context.Context, inventory domain.Inventory, info domain.MovementInfo) (domain.Inventory, error) {
+	if inventory.ID == uuid.Nil {
+		inventory.ID = uuid.New()
+	}
+	if info.Type == "" {
+		info.Type = domain.MovementReceipt
+	}
+	inventory.Reserved = 0
+
+	err := r.s.update(func(t *tx) error {
+		if _, ok := r.s.warehouses[inventory.WarehouseID]; !ok {
+			return invalidReference(repository.EntityInventory, "warehouse_id")
+		}
+		if _, ok := r.s.products[inventory.ProductID]; !ok {
+			return invalidReference(repository.EntityInventory, "product_id")
+		}
+
+		key := inventoryKey{inventory.WarehouseID, inventory.ProductID}
+		if _, ok := r.s.inventory[key]; ok {
+			return conflict(repository.EntityInventory, "warehouse_id_product_id")
+		}
+		inventory = r.s.putInventory(t, key, inventory)
+
+		if inventory.Quantity != 0 {
+			r.s.addMovement(t, newMovement(inventory.WarehouseID, inventory.ProductID, inventory.Quantity, inventory.Quantity, info))
+		}
+		return r.s.addAudit(ctx, t, repository.EntityInventory, inventory.ID, domain.AuditCreate, nil, withAvailable(inventory))
+	})
+	if err != nil {
+		return domain.Inventory{}, err
+	}
+
+	return withAvailable(inventory), nil
+}
+
+// GetByWarehouseAndProduct возвращает инвентаризацию по складу и товару
+func (r *InventoryRepository) GetByWarehouseAndProduct(ctx context.Context, warehouseID, productID uuid.UUID) (domain.Inventory, error) {
+	var inventory domain.Inventory
+	err := r.s.view(func() error {
+		var ok bool
+		if inventory, ok = r.s.inventory[inventoryKey{warehouseID, productID}]; !ok {
+			return notFound(repository.EntityInventory)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Inventory{}, err
+	}
+
+	return withAvailable(inventory), nil
+}
+
+// UpdateQuantity изменяет количество товара на складе на quantity
+// и записывает изменение в журналы движения и аудита в той же операции.
+// Остаток не может стать меньше зарезервированного количества.
+// Если version не 0, остаток изменяется, только если его текущая версия равна version
+func (r *InventoryRepository) UpdateQuantity(ctx context.Context, warehouseID, productID uuid.UUID, quantity, version int, info domain.MovementInfo) (domain.Inventory, error) {
+	var inventory domain.Inventory
+	err := r.s.update(func(t *tx) error {
+		key := inventoryKey{warehouseID, productID}
+		var ok bool
+		if inventory, ok = r.s.inventory[key]; !ok {
+			return notFound(repository.EntityInventory)
+		}
+		if version != 0 && inventory.Version != version {
+			return versionMismatch(repository.EntityInventory)
+		}
+
+		if available := inventory.Quantity - inventory.Reserved; available+quantity < 0 {
+			return &repository.InsufficientStockError{
+				WarehouseID: warehouseID,
+				ProductID:   productID,
+				Available:   available,
+				Requested:   -quantity,
+			}
+		}
+
+		before := inventory
+		inventory.Quantity += quantity
+		inventory = r.s.putInventory(t, key, inventory)
+		r.s.addMovement(t, newMovement(warehouseID, productID, quantity, inventory.Quantity, info))
+		return r.s.addAudit(ctx, t, repository.EntityInventory, inventory.ID, domain.AuditUpdate, withAvailable(before), withAvailable(inventory))
+	})
+	if err != nil {
+		return domain.Inventory{}, err
+	}
+
+	return withAvailable(inventory), nil
+}
+
+// UpdateDiscount обновляет скидку на товар и записывает изменение в журнал аудита.
+// Если version не 0, скидка изменяется, только если текущая версия остатка равна version
+func (r *InventoryRepository) UpdateDiscount(ctx context.Context, warehouseID, productID uuid.UUID, discount float64, version int) (domain.Inventory, error) {
+	var inventory domain.Inventory
+	err := r.s.update(func(t *tx) error {
+		key := inventoryKey{warehouseID, productID}
+		var ok bool
+		if inventory, ok = r.s.inventory[key]; !ok {
+			return notFound(repository.EntityInventory)
+		}
+		if version != 0 && inventory.Version != version {
+			return versionMismatch(repository.EntityInventory)
+		}
+
+		before := inventory
+		inventory.Discount = discount
+		inventory = r.s.putInventory(t, key, inventory)
+		return r.s.addAudit(ctx, t, repository.EntityInventory, inventory.ID, domain.AuditUpdate, withAvailable(before), withAvailable(inventory))
+	})
+	if err != nil {
+		return domain.Inventory{}, err
+	}
+
+	return withAvailable(inventory), nil
+}
+
+// GetProductsByWarehouse возвращает список товаров на складе с фильтром по характеристикам
+// и пагинацией, упорядоченный по названию товара
+func (r *InventoryRepository) GetProductsByWarehouse(ctx context.Context, warehouseID uuid.UUID, filter domain.WarehouseProductFilter) ([]domain.InventoryWithProduct, error) {
+	var products []domain.InventoryWithProduct
+	err := r.s.view(func() error {
+		for key, inventory := range r.s.inventory {
+			if key.warehouseID != warehouseID {
+				continue
+			}
+			product := r.s.products[key.productID]
+			if !matchAttributes(product.Characteristics, filter.Attributes) {
+				continue
+			}
+			products = append(products, domain.InventoryWithProduct{
+				Inventory: withAvailable(inventory),
+				Product:   product,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(products, func(a, b domain.InventoryWithProduct) int {
+		return cmp.Or(cmp.Compare(a.Product.Name, b.Product.Name), compareIDs(a.ID, b.ID))
+	})
+
+	return paginate(products, filter.Page, filter.Limit), nil
+}
+
+// PurchaseProducts уменьшает количество товаров на складе при покупке
+// и сохраняет покупку как заказ с позициями; создание заказа записывается в журнал аудита.
+//
+// Наличие всех товаров проверяется до изменения остатков; если какой-либо
+// товар отсутствует или его не хватает, ни один остаток, резерв или заказ не изменяется.
+//
+// Если передан reservationID, резерв списывается в той же операции, а зарезервированное
+// количество становится доступным для этой покупки. При пустом products покупаются товары из резерва.
+// Цена позиции со скидкой вычисляется функцией price.
+func (r *InventoryRepository) PurchaseProducts(ctx context.Context, warehouseID uuid.UUID, products []domain.ProductPurchase, reservationID *uuid.UUID, info domain.MovementInfo, price domain.PriceFunc) (domain.Order, error) {
+	info.Type = domain.MovementSale
+
+	var order domain.Order
+	err := r.s.update(func(t *tx) error {
+		var err error
+		order, err = r.s.purchase(t, warehouseID, products, reservationID, info, price)
+		if err != nil {
+			return err
+		}
+		return r.s.addAudit(ctx, t, repository.EntityOrder, order.ID, domain.AuditCreate, nil, order)
+	})
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	return order, nil
+}
+
+// purchase выполняет покупку в рамках переданной операции
+func (s *Store) purchase(t *tx, warehouseID uuid.UUID, products []domain.ProductPurchase, reservationID *uuid.UUID, info domain.MovementInfo, priceWithDiscount domain.PriceFunc) (domain.Order, error) {
+	// Позиции резерва участвуют в покупке с нулевым количеством,
+	// чтобы резерв был снят даже с товаров, которые не покупаются
+	released := make(map[uuid.UUID]int)
+	if reservationID != nil {
+		reservedItems, err := s.consumeReservation(t, *reservationID, warehouseID)
+		if err != nil {
+			return domain.Order{}, err
+		}
+		if len(products) == 0 {
+			products = reservedItems
+		}
+
+		lockSet := append([]domain.ProductPurchase{}, products...)
+		for _, item := range reservedItems {
+			released[item.ProductID] += item.Quantity
+			lockSet = append(lockSet, domain.ProductPurchase{ProductID: item.ProductID})
+		}
+		products = lockSet
+	}
+	products = mergePurchases(products)
+
+	// Проверяем наличие и достаточное количество каждого товара
+	for _, p := range products {
+		inventory, ok := s.inventory[inventoryKey{warehouseID, p.ProductID}]
+		if !ok {
+			return domain.Order{}, inventoryNotFound(warehouseID, p.ProductID)
+		}
+
+		available := inventory.Quantity - max(inventory.Reserved-released[p.ProductID], 0)
+		if available < p.Quantity {
+			return domain.Order{}, &repository.InsufficientStockError{
+				WarehouseID: warehouseID,
+				ProductID:   p.ProductID,
+				Available:   available,
+				Requested:   p.Quantity,
+			}
+		}
+	}
+
+	order := domain.Order{
+		ID:          uuid.New(),
+		WarehouseID: warehouseID,
+		CreatedAt:   s.now(),
+		Items:       make([]domain.OrderItem, 0, len(products)),
+	}
+
+	// Уменьшаем количество товаров, снимаем резерв и сохраняем аналитику
+	for _, p := range products {
+		key := inventoryKey{warehouseID, p.ProductID}
+		inventory := s.inventory[key]
+
+		inventory.Reserved -= min(released[p.ProductID], inventory.Reserved)
+		inventory.Quantity -= p.Quantity
+		s.putInventory(t, key, inventory)
+
+		// Позиция резерва, которая не покупается, только освобождается
+		if p.Quantity == 0 {
+			continue
+		}
+
+		movement := newMovement(warehouseID, p.ProductID, -p.Quantity, inventory.Quantity, info)
+		movement.ReferenceID = &order.ID
+		s.addMovement(t, movement)
+
+		finalPrice := priceWithDiscount(inventory.Price, inventory.Discount)
+		totalSum := finalPrice * float64(p.Quantity)
+
+		order.Items = append(order.Items, domain.OrderItem{
+			ID:                uuid.New(),
+			OrderID:           order.ID,
+			ProductID:         p.ProductID,
+			Quantity:          p.Quantity,
+			Price:             inventory.Price,
+			Discount:          inventory.Discount,
+			PriceWithDiscount: finalPrice,
+			TotalPrice:        totalSum,
+		})
+		order.TotalSum += totalSum
+
+		analytics, ok := s.analytics[key]
+		if !ok {
+			analytics = domain.Analytics{ID: uuid.New(), WarehouseID: warehouseID, ProductID: p.ProductID}
+		}
+		analytics.SoldQuantity += p.Quantity
+		analytics.TotalSum += totalSum
+		put(t, s.analytics, key, analytics)
+	}
+
+	put(t, s.orders, order.ID, order)
+
+	return cloneOrder(order), nil
+}
+
+// RebuildFromLedger сверяет остатки с суммой журнала движения.
+// Если dryRun == false, расходящиеся остатки приводятся к значению из журнала.
+// warehouseID ограничивает сверку одним складом.
+// Остаток, для которого значение из журнала меньше резерва, не изменяется и отмечается как конфликт.
+// Каждое исправление записывается в журнал аудита
+func (r *InventoryRepository) RebuildFromLedger(ctx context.Context, warehouseID *uuid.UUID, dryRun bool) ([]domain.LedgerDiscrepancy, error) {
+	var discrepancies []domain.LedgerDiscrepancy
+	err := r.s.update(func(t *tx) error {
+		ledger := make(map[inventoryKey]int)
+		for _, m := range r.s.movements {
+			ledger[inventoryKey{m.WarehouseID, m.ProductID}] += m.QuantityDelta
+		}
+
+		for key, inventory := range r.s.inventory {
+			if warehouseID != nil && key.warehouseID != *warehouseID {
+				continue
+			}
+			if inventory.Quantity == ledger[key] {
+				continue
+			}
+			discrepancies = append(discrepancies, domain.LedgerDiscrepancy{
+				WarehouseID:    key.warehouseID,
+				ProductID:      key.productID,
+				Quantity:       inventory.Quantity,
+				LedgerQuantity: ledger[key],
+				Reserved:       inventory.Reserved,
+				Conflict:       ledger[key] < inventory.Reserved,
+			})
+		}
+
+		slices.SortFunc(discrepancies, func(a, b domain.LedgerDiscrepancy) int {
+			return cmp.Or(compareIDs(a.WarehouseID, b.WarehouseID), compareIDs(a.ProductID, b.ProductID))
+		})
+
+		if dryRun {
+			return nil
+		}
+
+		for _, d := range discrepancies {
+			if !d.Fixable() {
+				continue
+			}
+			key := inventoryKey{d.WarehouseID, d.ProductID}
+			before := r.s.inventory[key]
+			after := before
+			after.Quantity = d.LedgerQuantity
+			after = r.s.putInventory(t, key, after)
+			if err := r.s.addAudit(ctx, t, repository.EntityInventory, after.ID, domain.AuditRebuild, withAvailable(before), withAvailable(after)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return discrepancies, nil
+}
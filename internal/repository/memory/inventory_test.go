@@ -0,0 +1,114 @@
+package memory
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+// seedInventory создает склад и товар с остатком quantity
+func seedInventory(t *testing.T, s *Store, quantity int) (domain.Warehouse, domain.Product) {
+	t.Helper()
+	ctx := context.Background()
+
+	warehouse, err := NewWarehouseRepository(s).Create(ctx, domain.Warehouse{Address: "test " + uuid.NewString()})
+	if err != nil {
+		t.Fatalf("создание склада: %v", err)
+	}
+	return warehouse, seedProductInWarehouse(t, s, warehouse, quantity)
+}
+
+// addLedgerDrift записывает в журнал движение без изменения остатка
+func addLedgerDrift(t *testing.T, s *Store, warehouseID, productID uuid.UUID, delta int) {
+	t.Helper()
+
+	err := s.update(func(t *tx) error {
+		s.addMovement(t, newMovement(warehouseID, productID, delta, 0, domain.MovementInfo{Type: domain.MovementAdjustment}))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("запись движения: %v", err)
+	}
+}
+
+func TestRebuildFromLedgerReportsConflictBelowReserved(t *testing.T) {
+	ctx := context.Background()
+	s := NewStore()
+	inventory := NewInventoryRepository(s)
+
+	warehouse, reserved := seedInventory(t, s, 5)
+	if _, err := NewReservationRepository(s).Create(ctx, warehouse.ID,
+		[]domain.ProductPurchase{{ProductID: reserved.ID, Quantity: 3}}, time.Minute); err != nil {
+		t.Fatalf("создание резерва: %v", err)
+	}
+	// Журнал дает 1 при резерве 3: исправление нарушило бы quantity >= reserved
+	addLedgerDrift(t, s, warehouse.ID, reserved.ID, -4)
+
+	fixable := seedProductInWarehouse(t, s, warehouse, 5)
+	addLedgerDrift(t, s, warehouse.ID, fixable.ID, -2)
+
+	discrepancies, err := inventory.RebuildFromLedger(ctx, &warehouse.ID, false)
+	if err != nil {
+		t.Fatalf("сверка: %v", err)
+	}
+	if len(discrepancies) != 2 {
+		t.Fatalf("найдено %d расхождений, ожидалось 2: %+v", len(discrepancies), discrepancies)
+	}
+	for _, d := range discrepancies {
+		switch d.ProductID {
+		case reserved.ID:
+			if !d.Conflict || d.LedgerQuantity != 1 || d.Reserved != 3 {
+				t.Errorf("ожидался конфликт с журналом 1 и резервом 3, получено %+v", d)
+			}
+		case fixable.ID:
+			if d.Conflict || d.LedgerQuantity != 3 {
+				t.Errorf("ожидалось исправимое расхождение с журналом 3, получено %+v", d)
+			}
+		}
+	}
+
+	assertQuantity(t, inventory, warehouse.ID, reserved.ID, 5)
+	assertQuantity(t, inventory, warehouse.ID, fixable.ID, 3)
+}
+
+// seedProductInWarehouse создает товар с остатком quantity на существующем складе
+func seedProductInWarehouse(t *testing.T, s *Store, warehouse domain.Warehouse, quantity int) domain.Product {
+	t.Helper()
+	ctx := context.Background()
+
+	product, err := NewProductRepository(s).Create(ctx, domain.Product{
+		Name:            "test",
+		Characteristics: []byte(`{}`),
+		Weight:          1,
+		Barcode:         uuid.NewString(),
+	})
+	if err != nil {
+		t.Fatalf("создание товара: %v", err)
+	}
+	_, err = NewInventoryRepository(s).Create(ctx, domain.Inventory{
+		WarehouseID: warehouse.ID,
+		ProductID:   product.ID,
+		Quantity:    quantity,
+		Price:       100,
+	}, domain.MovementInfo{Type: domain.MovementReceipt})
+	if err != nil {
+		t.Fatalf("создание остатка: %v", err)
+	}
+	return product
+}
+
+// assertQuantity проверяет остаток товара на складе
+func assertQuantity(t *testing.T, inventory *InventoryRepository, warehouseID, productID uuid.UUID, quantity int) {
+	t.Helper()
+
+	got, err := inventory.GetByWarehouseAndProduct(context.Background(), warehouseID, productID)
+	if err != nil {
+		t.Fatalf("получение остатка: %v", err)
+	}
+	if got.Quantity != quantity {
+		t.Errorf("остаток %d, ожидалось %d", got.Quantity, quantity)
+	}
+}
@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/google/uuid"
+)
+
+// OrderRepository хранит заказы в памяти
+type OrderRepository struct {
+	s *Store
+}
+
+// NewOrderRepository создает новый репозиторий заказов в памяти
+func NewOrderRepository(s *Store) *OrderRepository {
+	return &OrderRepository{s: s}
+}
+
+// GetAll возвращает список заказов с фильтрацией и пагинацией, начиная с последних
+func (r *OrderRepository) GetAll(ctx context.Context, filter domain.OrderFilter) ([]domain.Order, error) {
+	var orders []domain.Order
+	err := r.s.view(func() error {
+		for _, o := range r.s.orders {
+			if filter.WarehouseID != nil && o.WarehouseID != *filter.WarehouseID {
+				continue
+			}
+			if !inPeriod(o.CreatedAt, filter.From, filter.To) {
+				continue
+			}
+			orders = append(orders, o)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(orders, func(a, b domain.Order) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), compareIDs(a.ID, b.ID))
+	})
+
+	orders = paginate(orders, filter.Page, filter.Limit)
+	for i := range orders {
+		orders[i] = cloneOrder(orders[i])
+	}
+
+	return orders, nil
+}
+
+// GetByID возвращает заказ по его ID вместе с позициями
+func (r *OrderRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Order, error) {
+	var order domain.Order
+	err := r.s.view(func() error {
+		var ok bool
+		if order, ok = r.s.orders[id]; !ok {
+			return notFound(repository.EntityOrder)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	return cloneOrder(order), nil
+}
+
+// cloneOrder копирует позиции заказа, чтобы вызывающая сторона не изменила хранимые данные
+func cloneOrder(order domain.Order) domain.Order {
+	order.Items = append([]domain.OrderItem{}, order.Items...)
+	return order
+}
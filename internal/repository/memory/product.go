@@ -0,0 +1,202 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ProductRepository хранит товары в памяти
+type ProductRepository struct {
+	s *Store
+}
+
+// NewProductRepository создает новый репозиторий товаров в памяти
+func NewProductRepository(s *Store) *ProductRepository {
+	return &ProductRepository{s: s}
+}
+
+// Create создает новый товар и записывает создание в журнал аудита; штрихкод должен быть уникальным
+func (r *ProductRepository) Create(ctx context.Context, product domain.Product) (domain.Product, error) {
+	if product.ID == uuid.Nil {
+		product.ID = uuid.New()
+	}
+	product.Characteristics = slices.Clone(product.Characteristics)
+	product.Version = 1
+
+	err := r.s.update(func(t *tx) error {
+		if _, ok := r.s.products[product.ID]; ok {
+			return conflict(repository.EntityProduct, "pkey")
+		}
+		if r.barcodeTaken(product.Barcode, product.ID) {
+			return conflict(repository.EntityProduct, "barcode")
+		}
+		put(t, r.s.products, product.ID, product)
+		return r.s.addAudit(ctx, t, repository.EntityProduct, product.ID, domain.AuditCreate, nil, product)
+	})
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	return product, nil
+}
+
+// GetAll возвращает товары по фильтру с keyset-пагинацией: страница начинается
+// после позиции filter.After в порядке filter.Sort, при равных значениях - по ID.
+// Полнотекстовый поиск упрощен: каждое слово запроса должно встречаться
+// в названии или описании без учета регистра
+func (r *ProductRepository) GetAll(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error) {
+	words := strings.Fields(strings.ToLower(filter.Search))
+
+	var products []domain.Product
+	err := r.s.view(func() error {
+		for _, p := range r.s.products {
+			if matchProduct(p, filter, words) {
+				products = append(products, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	compare := productComparator(filter.Sort)
+	slices.SortFunc(products, compare)
+
+	if after := filter.After; after != nil {
+		pos := domain.Product{ID: after.ID, Name: after.Name, Weight: after.Weight, Barcode: after.Barcode}
+		products = slices.DeleteFunc(products, func(p domain.Product) bool {
+			return compare(p, pos) <= 0
+		})
+	}
+
+	if len(products) > filter.Limit {
+		products = products[:filter.Limit]
+	}
+
+	return products, nil
+}
+
+// GetAttributeFacets возвращает характеристики товаров, подходящих под фильтр, с числом товаров
+// для каждого значения, не больше limit самых частых значений на характеристику
+func (r *ProductRepository) GetAttributeFacets(ctx context.Context, filter domain.ProductFilter, limit int) ([]domain.AttributeFacet, error) {
+	words := strings.Fields(strings.ToLower(filter.Search))
+
+	var products []domain.Product
+	err := r.s.view(func() error {
+		for _, p := range r.s.products {
+			if matchProduct(p, filter, words) {
+				products = append(products, p)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return attributeFacets(products, limit), nil
+}
+
+// matchProduct проверяет, подходит ли товар под фильтр
+func matchProduct(p domain.Product, filter domain.ProductFilter, words []string) bool {
+	if filter.Barcode != "" && p.Barcode != filter.Barcode {
+		return false
+	}
+	if filter.MinWeight != nil && p.Weight < *filter.MinWeight {
+		return false
+	}
+	if filter.MaxWeight != nil && p.Weight > *filter.MaxWeight {
+		return false
+	}
+
+	text := strings.ToLower(p.Name + " " + p.Description)
+	for _, word := range words {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return matchAttributes(p.Characteristics, filter.Attributes)
+}
+
+// productComparator возвращает функцию сравнения товаров в порядке sort с ID для равных значений
+func productComparator(sort domain.ProductSort) func(a, b domain.Product) int {
+	var field func(a, b domain.Product) int
+	switch sort {
+	case domain.ProductSortWeight, domain.ProductSortWeightDesc:
+		field = func(a, b domain.Product) int { return cmp.Compare(a.Weight, b.Weight) }
+	case domain.ProductSortBarcode, domain.ProductSortBarcodeDesc:
+		field = func(a, b domain.Product) int { return cmp.Compare(a.Barcode, b.Barcode) }
+	default:
+		field = func(a, b domain.Product) int { return cmp.Compare(a.Name, b.Name) }
+	}
+
+	if sort.Desc() {
+		return func(a, b domain.Product) int {
+			return cmp.Or(field(b, a), compareIDs(b.ID, a.ID))
+		}
+	}
+	return func(a, b domain.Product) int {
+		return cmp.Or(field(a, b), compareIDs(a.ID, b.ID))
+	}
+}
+
+// GetByID возвращает товар по его ID
+func (r *ProductRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Product, error) {
+	var product domain.Product
+	err := r.s.view(func() error {
+		var ok bool
+		if product, ok = r.s.products[id]; !ok {
+			return notFound(repository.EntityProduct)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	return product, nil
+}
+
+// Update обновляет информацию о товаре и записывает изменение в журнал аудита.
+// Если version не 0, товар обновляется, только если его текущая версия равна version
+func (r *ProductRepository) Update(ctx context.Context, product domain.Product, version int) (domain.Product, error) {
+	product.Characteristics = slices.Clone(product.Characteristics)
+
+	err := r.s.update(func(t *tx) error {
+		current, ok := r.s.products[product.ID]
+		if !ok {
+			return notFound(repository.EntityProduct)
+		}
+		if version != 0 && current.Version != version {
+			return versionMismatch(repository.EntityProduct)
+		}
+		if r.barcodeTaken(product.Barcode, product.ID) {
+			return conflict(repository.EntityProduct, "barcode")
+		}
+		product.Version = current.Version + 1
+		put(t, r.s.products, product.ID, product)
+		return r.s.addAudit(ctx, t, repository.EntityProduct, product.ID, domain.AuditUpdate, current, product)
+	})
+	if err != nil {
+		return domain.Product{}, err
+	}
+
+	return product, nil
+}
+
+// barcodeTaken проверяет, занят ли штрихкод другим товаром
+func (r *ProductRepository) barcodeTaken(barcode string, id uuid.UUID) bool {
+	for _, p := range r.s.products {
+		if p.Barcode == barcode && p.ID != id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,188 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/google/uuid"
+)
+
+// ReservationRepository хранит резервы товаров в памяти
+type ReservationRepository struct {
+	s *Store
+}
+
+// NewReservationRepository создает новый репозиторий резервов в памяти
+func NewReservationRepository(s *Store) *ReservationRepository {
+	return &ReservationRepository{s: s}
+}
+
+// Create резервирует товары на складе на время ttl и записывает создание резерва в журнал аудита
+func (r *ReservationRepository) Create(ctx context.Context, warehouseID uuid.UUID, products []domain.ProductPurchase, ttl time.Duration) (domain.Reservation, error) {
+	items := mergePurchases(products)
+
+	var reservation domain.Reservation
+	err := r.s.update(func(t *tx) error {
+		for _, p := range items {
+			key := inventoryKey{warehouseID, p.ProductID}
+			inventory, ok := r.s.inventory[key]
+			if !ok {
+				return inventoryNotFound(warehouseID, p.ProductID)
+			}
+
+			if available := inventory.Quantity - inventory.Reserved; available < p.Quantity {
+				return &repository.InsufficientStockError{
+					WarehouseID: warehouseID,
+					ProductID:   p.ProductID,
+					Available:   available,
+					Requested:   p.Quantity,
+				}
+			}
+
+			inventory.Reserved += p.Quantity
+			r.s.putInventory(t, key, inventory)
+		}
+
+		now := r.s.now()
+		reservation = domain.Reservation{
+			ID:          uuid.New(),
+			WarehouseID: warehouseID,
+			Status:      domain.ReservationActive,
+			ExpiresAt:   now.Add(ttl),
+			CreatedAt:   now,
+			Items:       items,
+		}
+		put(t, r.s.reservations, reservation.ID, reservation)
+		return r.s.addAudit(ctx, t, repository.EntityReservation, reservation.ID, domain.AuditCreate, nil, reservation)
+	})
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	return cloneReservation(reservation), nil
+}
+
+// GetByID возвращает резерв по его ID
+func (r *ReservationRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Reservation, error) {
+	var reservation domain.Reservation
+	err := r.s.view(func() error {
+		var ok bool
+		if reservation, ok = r.s.reservations[id]; !ok {
+			return notFound(repository.EntityReservation)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	return cloneReservation(reservation), nil
+}
+
+// Release досрочно снимает активный резерв, возвращает товары в доступный остаток
+// и записывает снятие в журнал аудита
+func (r *ReservationRepository) Release(ctx context.Context, id uuid.UUID) (domain.Reservation, error) {
+	var reservation domain.Reservation
+	err := r.s.update(func(t *tx) error {
+		var ok bool
+		if reservation, ok = r.s.reservations[id]; !ok {
+			return notFound(repository.EntityReservation)
+		}
+
+		if reservation.Status != domain.ReservationActive {
+			return fmt.Errorf("%w: %s, статус %s", repository.ErrReservationNotActive, id, reservation.Status)
+		}
+
+		before := reservation
+		r.s.releaseReserved(t, reservation.WarehouseID, reservation.Items)
+		reservation.Status = domain.ReservationReleased
+		put(t, r.s.reservations, id, reservation)
+		return r.s.addAudit(ctx, t, repository.EntityReservation, reservation.ID, domain.AuditRelease, before, reservation)
+	})
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	return cloneReservation(reservation), nil
+}
+
+// ReleaseExpired снимает не более limit истекших резервов и возвращает их количество
+func (r *ReservationRepository) ReleaseExpired(ctx context.Context, limit int) (int, error) {
+	var released int
+	err := r.s.update(func(t *tx) error {
+		now := r.s.now()
+
+		var expired []domain.Reservation
+		for _, reservation := range r.s.reservations {
+			if reservation.Status == domain.ReservationActive && !reservation.ExpiresAt.After(now) {
+				expired = append(expired, reservation)
+			}
+		}
+
+		slices.SortFunc(expired, func(a, b domain.Reservation) int {
+			return cmp.Or(a.ExpiresAt.Compare(b.ExpiresAt), compareIDs(a.ID, b.ID))
+		})
+		expired = expired[:min(limit, len(expired))]
+
+		for _, reservation := range expired {
+			r.s.releaseReserved(t, reservation.WarehouseID, reservation.Items)
+			reservation.Status = domain.ReservationExpired
+			put(t, r.s.reservations, reservation.ID, reservation)
+		}
+
+		released = len(expired)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return released, nil
+}
+
+// consumeReservation помечает активный резерв как использованный и возвращает его позиции.
+// Сам резерв в инвентаре снимается вызывающей стороной вместе со списанием остатка.
+func (s *Store) consumeReservation(t *tx, id, warehouseID uuid.UUID) ([]domain.ProductPurchase, error) {
+	reservation, ok := s.reservations[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", repository.ErrReservationNotFound, id)
+	}
+
+	if reservation.WarehouseID != warehouseID {
+		return nil, fmt.Errorf("%w: %s", repository.ErrReservationMismatch, id)
+	}
+
+	if reservation.Status != domain.ReservationActive || !reservation.ExpiresAt.After(s.now()) {
+		return nil, fmt.Errorf("%w или истек: %s", repository.ErrReservationNotActive, id)
+	}
+
+	reservation.Status = domain.ReservationConsumed
+	put(t, s.reservations, id, reservation)
+
+	return reservation.Items, nil
+}
+
+// releaseReserved уменьшает зарезервированное количество товаров на складе
+func (s *Store) releaseReserved(t *tx, warehouseID uuid.UUID, items []domain.ProductPurchase) {
+	for _, p := range items {
+		key := inventoryKey{warehouseID, p.ProductID}
+		inventory, ok := s.inventory[key]
+		if !ok {
+			continue
+		}
+
+		inventory.Reserved = max(inventory.Reserved-p.Quantity, 0)
+		s.putInventory(t, key, inventory)
+	}
+}
+
+// cloneReservation копирует позиции резерва, чтобы вызывающая сторона не изменила хранимые данные
+func cloneReservation(reservation domain.Reservation) domain.Reservation {
+	reservation.Items = append([]domain.ProductPurchase{}, reservation.Items...)
+	return reservation
+}
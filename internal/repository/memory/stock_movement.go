@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+// StockMovementRepository хранит журнал движения товаров в памяти
+type StockMovementRepository struct {
+	s *Store
+}
+
+// NewStockMovementRepository создает новый репозиторий журнала движения в памяти
+func NewStockMovementRepository(s *Store) *StockMovementRepository {
+	return &StockMovementRepository{s: s}
+}
+
+// GetByWarehouseAndProduct возвращает движения товара на складе, начиная с последних
+func (r *StockMovementRepository) GetByWarehouseAndProduct(ctx context.Context, warehouseID, productID uuid.UUID, filter domain.MovementFilter) ([]domain.StockMovement, error) {
+	var movements []domain.StockMovement
+	err := r.s.view(func() error {
+		for _, m := range r.s.movements {
+			if m.WarehouseID != warehouseID || m.ProductID != productID {
+				continue
+			}
+			if !inPeriod(m.CreatedAt, filter.From, filter.To) {
+				continue
+			}
+			movements = append(movements, m)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortStableFunc(movements, func(a, b domain.StockMovement) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), compareIDs(a.ID, b.ID))
+	})
+
+	return paginate(movements, filter.Page, filter.Limit), nil
+}
@@ -0,0 +1,220 @@
+// Package memory содержит реализацию репозиториев, хранящую данные в памяти процесса.
+// Используется для тестов и демонстрации без PostgreSQL; данные теряются при перезапуске.
+package memory
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/google/uuid"
+)
+
+// inventoryKey идентифицирует товар на складе, аналог UNIQUE (warehouse_id, product_id)
+type inventoryKey struct {
+	warehouseID uuid.UUID
+	productID   uuid.UUID
+}
+
+// Store хранит данные всех репозиториев.
+// Изменения выполняются под общей блокировкой и откатываются целиком при ошибке,
+// поэтому операции атомарны так же, как транзакции PostgreSQL
+type Store struct {
+	mu sync.RWMutex
+
+	warehouses   map[uuid.UUID]domain.Warehouse
+	products     map[uuid.UUID]domain.Product
+	inventory    map[inventoryKey]domain.Inventory
+	analytics    map[inventoryKey]domain.Analytics
+	orders       map[uuid.UUID]domain.Order
+	reservations map[uuid.UUID]domain.Reservation
+	transfers    map[uuid.UUID]domain.Transfer
+	movements    []domain.StockMovement
+	audit        []domain.AuditEntry
+	users        map[uuid.UUID]domain.User
+	apiKeys      map[uuid.UUID]apiKeyRow
+	idempotency  map[idempotencyKey]domain.IdempotencyRecord
+
+	// now возвращает текущее время, аналог NOW() в PostgreSQL
+	now func() time.Time
+}
+
+// NewStore создает пустое хранилище
+func NewStore() *Store {
+	return &Store{
+		warehouses:   make(map[uuid.UUID]domain.Warehouse),
+		products:     make(map[uuid.UUID]domain.Product),
+		inventory:    make(map[inventoryKey]domain.Inventory),
+		analytics:    make(map[inventoryKey]domain.Analytics),
+		orders:       make(map[uuid.UUID]domain.Order),
+		reservations: make(map[uuid.UUID]domain.Reservation),
+		transfers:    make(map[uuid.UUID]domain.Transfer),
+		users:        make(map[uuid.UUID]domain.User),
+		apiKeys:      make(map[uuid.UUID]apiKeyRow),
+		idempotency:  make(map[idempotencyKey]domain.IdempotencyRecord),
+		now:          func() time.Time { return time.Now().UTC() },
+	}
+}
+
+// tx накапливает действия для отката изменений, сделанных внутри update
+type tx struct {
+	undo []func()
+}
+
+// onRollback регистрирует действие, отменяющее изменение
+func (t *tx) onRollback(fn func()) {
+	t.undo = append(t.undo, fn)
+}
+
+// update выполняет fn под блокировкой на запись.
+// Если fn возвращает ошибку, все изменения откатываются в обратном порядке
+func (s *Store) update(fn func(t *tx) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t := &tx{}
+	if err := fn(t); err != nil {
+		for i := len(t.undo) - 1; i >= 0; i-- {
+			t.undo[i]()
+		}
+		return err
+	}
+
+	return nil
+}
+
+// view выполняет fn под блокировкой на чтение
+func (s *Store) view(fn func() error) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return fn()
+}
+
+// put записывает значение в map и регистрирует откат к предыдущему состоянию
+func put[K comparable, V any](t *tx, m map[K]V, key K, value V) {
+	old, existed := m[key]
+	t.onRollback(func() {
+		if existed {
+			m[key] = old
+		} else {
+			delete(m, key)
+		}
+	})
+	m[key] = value
+}
+
+// putInventory записывает остаток и возвращает его с новой версией.
+// Версия увеличивается, только если остаток изменился, так же как триггером inventory_bump_version
+func (s *Store) putInventory(t *tx, key inventoryKey, inventory domain.Inventory) domain.Inventory {
+	inventory.Version = 1
+	if old, ok := s.inventory[key]; ok {
+		inventory.Version = old.Version
+		if inventory != old {
+			inventory.Version++
+		}
+	}
+	put(t, s.inventory, key, inventory)
+	return inventory
+}
+
+// addMovement добавляет движение товара в журнал
+func (s *Store) addMovement(t *tx, m domain.StockMovement) {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+	m.CreatedAt = s.now()
+
+	n := len(s.movements)
+	t.onRollback(func() { s.movements = s.movements[:n] })
+	s.movements = append(s.movements, m)
+}
+
+// newMovement создает движение товара по описанию изменения
+func newMovement(warehouseID, productID uuid.UUID, delta, after int, info domain.MovementInfo) domain.StockMovement {
+	return domain.StockMovement{
+		WarehouseID:   warehouseID,
+		ProductID:     productID,
+		Type:          info.Type,
+		QuantityDelta: delta,
+		QuantityAfter: after,
+		Reason:        info.Reason,
+		Actor:         info.Actor,
+		RequestID:     info.RequestID,
+	}
+}
+
+// withAvailable заполняет доступное количество: quantity - reserved
+func withAvailable(inventory domain.Inventory) domain.Inventory {
+	inventory.Available = inventory.Quantity - inventory.Reserved
+	return inventory
+}
+
+// mergePurchases объединяет повторяющиеся товары и сортирует их по ID,
+// так же как это делает репозиторий PostgreSQL
+func mergePurchases(products []domain.ProductPurchase) []domain.ProductPurchase {
+	quantities := make(map[uuid.UUID]int, len(products))
+	merged := make([]domain.ProductPurchase, 0, len(products))
+	for _, p := range products {
+		if _, ok := quantities[p.ProductID]; !ok {
+			merged = append(merged, domain.ProductPurchase{ProductID: p.ProductID})
+		}
+		quantities[p.ProductID] += p.Quantity
+	}
+
+	for i := range merged {
+		merged[i].Quantity = quantities[merged[i].ProductID]
+	}
+
+	slices.SortFunc(merged, compareProducts)
+
+	return merged
+}
+
+// compareProducts сравнивает позиции по ID товара
+func compareProducts(a, b domain.ProductPurchase) int {
+	return bytes.Compare(a.ProductID[:], b.ProductID[:])
+}
+
+// compareIDs сравнивает UUID так же, как PostgreSQL
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// paginate возвращает страницу page размером limit
+func paginate[T any](items []T, page, limit int) []T {
+	offset := (page - 1) * limit
+	if offset < 0 || offset >= len(items) {
+		return nil
+	}
+	return items[offset:min(offset+limit, len(items))]
+}
+
+// notFound создает ошибку об отсутствии записи
+func notFound(entity string) error {
+	return &repository.Error{Kind: repository.ErrNotFound, Entity: entity}
+}
+
+// conflict создает ошибку нарушения уникальности
+func conflict(entity, field string) error {
+	return &repository.Error{Kind: repository.ErrConflict, Entity: entity, Field: field}
+}
+
+// versionMismatch создает ошибку устаревшей версии записи
+func versionMismatch(entity string) error {
+	return &repository.Error{Kind: repository.ErrVersionMismatch, Entity: entity}
+}
+
+// invalidReference создает ошибку ссылки на несуществующую запись
+func invalidReference(entity, field string) error {
+	return &repository.Error{Kind: repository.ErrInvalidReference, Entity: entity, Field: field}
+}
+
+// inventoryNotFound создает ошибку об отсутствии товара на складе
+func inventoryNotFound(warehouseID, productID uuid.UUID) error {
+	return fmt.Errorf("%w: товар с ID %s не найден на складе %s", repository.ErrInventoryNotFound, productID, warehouseID)
+}
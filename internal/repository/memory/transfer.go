@@ -0,0 +1,274 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/google/uuid"
+)
+
+// TransferRepository хранит перемещения товаров между складами в памяти
+type TransferRepository struct {
+	s *Store
+}
+
+// NewTransferRepository создает новый репозиторий перемещений в памяти
+func NewTransferRepository(s *Store) *TransferRepository {
+	return &TransferRepository{s: s}
+}
+
+// Create создает перемещение в статусе pending.
+// Если immediate == true, товары списываются с исходного склада и зачисляются
+// на склад назначения в той же операции, и перемещение сразу получает статус received.
+// Создание перемещения записывается в журнал аудита
+func (r *TransferRepository) Create(ctx context.Context, request domain.TransferRequest, immediate bool, info domain.MovementInfo) (domain.Transfer, error) {
+	info.Type = domain.MovementTransfer
+
+	var transfer domain.Transfer
+	err := r.s.update(func(t *tx) error {
+		if _, ok := r.s.warehouses[request.SourceWarehouseID]; !ok {
+			return invalidReference(repository.EntityTransfer, "source_warehouse_id")
+		}
+		if _, ok := r.s.warehouses[request.DestinationWarehouseID]; !ok {
+			return invalidReference(repository.EntityTransfer, "destination_warehouse_id")
+		}
+		for _, item := range request.Items {
+			if _, ok := r.s.products[item.ProductID]; !ok {
+				return invalidReference("transfer_items", "product_id")
+			}
+		}
+
+		transfer = domain.Transfer{
+			ID:                     uuid.New(),
+			SourceWarehouseID:      request.SourceWarehouseID,
+			DestinationWarehouseID: request.DestinationWarehouseID,
+			Status:                 domain.TransferPending,
+			Items:                  mergePurchases(request.Items),
+			CreatedAt:              r.s.now(),
+		}
+
+		if immediate {
+			if err := r.s.dispatchTransfer(t, &transfer, info); err != nil {
+				return err
+			}
+			r.s.receiveTransfer(t, &transfer, info)
+		}
+
+		put(t, r.s.transfers, transfer.ID, transfer)
+		return r.s.addAudit(ctx, t, repository.EntityTransfer, transfer.ID, domain.AuditCreate, nil, transfer)
+	})
+	if err != nil {
+		return domain.Transfer{}, err
+	}
+
+	return cloneTransfer(transfer), nil
+}
+
+// Dispatch отгружает перемещение: списывает товары с исходного склада и переводит его в статус in_transit.
+// Переход записывается в журнал аудита
+func (r *TransferRepository) Dispatch(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error) {
+	info.Type = domain.MovementTransfer
+
+	return r.change(ctx, id, domain.AuditDispatch, func(t *tx, transfer *domain.Transfer) error {
+		if transfer.Status != domain.TransferPending {
+			return fmt.Errorf("%w: отгрузить можно только перемещение в статусе pending, текущий статус %s", repository.ErrTransferState, transfer.Status)
+		}
+
+		return r.s.dispatchTransfer(t, transfer, info)
+	})
+}
+
+// Receive принимает перемещение на складе назначения и переводит его в статус received.
+// Переход записывается в журнал аудита
+func (r *TransferRepository) Receive(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error) {
+	info.Type = domain.MovementTransfer
+
+	return r.change(ctx, id, domain.AuditReceive, func(t *tx, transfer *domain.Transfer) error {
+		if transfer.Status != domain.TransferInTransit {
+			return fmt.Errorf("%w: принять можно только перемещение в статусе in_transit, текущий статус %s", repository.ErrTransferState, transfer.Status)
+		}
+
+		r.s.receiveTransfer(t, transfer, info)
+		return nil
+	})
+}
+
+// Cancel отменяет перемещение. Товары отгруженного перемещения возвращаются на исходный склад.
+// Отмена записывается в журнал аудита
+func (r *TransferRepository) Cancel(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error) {
+	info.Type = domain.MovementTransfer
+	info.Reason = "transfer_cancelled"
+
+	return r.change(ctx, id, domain.AuditCancel, func(t *tx, transfer *domain.Transfer) error {
+		switch transfer.Status {
+		case domain.TransferPending:
+		case domain.TransferInTransit:
+			// Возвращаем товары на исходный склад
+			for _, item := range transfer.Items {
+				key := inventoryKey{transfer.SourceWarehouseID, item.ProductID}
+				inventory, ok := r.s.inventory[key]
+				if !ok {
+					return inventoryNotFound(transfer.SourceWarehouseID, item.ProductID)
+				}
+
+				inventory.Quantity += item.Quantity
+				r.s.putInventory(t, key, inventory)
+
+				movement := newMovement(transfer.SourceWarehouseID, item.ProductID, item.Quantity, inventory.Quantity, info)
+				movement.ReferenceID = &transfer.ID
+				r.s.addMovement(t, movement)
+			}
+		default:
+			return fmt.Errorf("%w: нельзя отменить перемещение в статусе %s", repository.ErrTransferState, transfer.Status)
+		}
+
+		now := r.s.now()
+		transfer.Status = domain.TransferCancelled
+		transfer.CancelledAt = &now
+		return nil
+	})
+}
+
+// GetByID возвращает перемещение по его ID
+func (r *TransferRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Transfer, error) {
+	var transfer domain.Transfer
+	err := r.s.view(func() error {
+		var ok bool
+		if transfer, ok = r.s.transfers[id]; !ok {
+			return notFound(repository.EntityTransfer)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Transfer{}, err
+	}
+
+	return cloneTransfer(transfer), nil
+}
+
+// GetByWarehouse возвращает перемещения, в которых склад является источником или получателем.
+// Если statuses не пуст, выбираются только перемещения с указанными статусами
+func (r *TransferRepository) GetByWarehouse(ctx context.Context, warehouseID uuid.UUID, statuses []domain.TransferStatus) ([]domain.Transfer, error) {
+	transfers := []domain.Transfer{}
+	err := r.s.view(func() error {
+		for _, transfer := range r.s.transfers {
+			if transfer.SourceWarehouseID != warehouseID && transfer.DestinationWarehouseID != warehouseID {
+				continue
+			}
+			if len(statuses) > 0 && !slices.Contains(statuses, transfer.Status) {
+				continue
+			}
+			transfers = append(transfers, cloneTransfer(transfer))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(transfers, func(a, b domain.Transfer) int {
+		return cmp.Or(b.CreatedAt.Compare(a.CreatedAt), compareIDs(a.ID, b.ID))
+	})
+
+	return transfers, nil
+}
+
+// change изменяет перемещение функцией fn и, если fn не вернула ошибку,
+// сохраняет результат и записывает переход action в журнал аудита
+func (r *TransferRepository) change(ctx context.Context, id uuid.UUID, action domain.AuditAction, fn func(t *tx, transfer *domain.Transfer) error) (domain.Transfer, error) {
+	var transfer domain.Transfer
+	err := r.s.update(func(t *tx) error {
+		var ok bool
+		if transfer, ok = r.s.transfers[id]; !ok {
+			return notFound(repository.EntityTransfer)
+		}
+
+		before := transfer
+		if err := fn(t, &transfer); err != nil {
+			return err
+		}
+
+		put(t, r.s.transfers, id, transfer)
+		return r.s.addAudit(ctx, t, repository.EntityTransfer, transfer.ID, action, before, transfer)
+	})
+	if err != nil {
+		return domain.Transfer{}, err
+	}
+
+	return cloneTransfer(transfer), nil
+}
+
+// dispatchTransfer списывает товары с исходного склада и переводит перемещение в статус in_transit.
+// Зарезервированное количество отгрузить нельзя
+func (s *Store) dispatchTransfer(t *tx, transfer *domain.Transfer, info domain.MovementInfo) error {
+	info.Reason = "transfer_dispatched"
+
+	for _, item := range transfer.Items {
+		key := inventoryKey{transfer.SourceWarehouseID, item.ProductID}
+		inventory, ok := s.inventory[key]
+		if !ok {
+			return inventoryNotFound(transfer.SourceWarehouseID, item.ProductID)
+		}
+
+		if available := inventory.Quantity - inventory.Reserved; available < item.Quantity {
+			return &repository.InsufficientStockError{
+				WarehouseID: transfer.SourceWarehouseID,
+				ProductID:   item.ProductID,
+				Available:   available,
+				Requested:   item.Quantity,
+			}
+		}
+
+		inventory.Quantity -= item.Quantity
+		s.putInventory(t, key, inventory)
+
+		movement := newMovement(transfer.SourceWarehouseID, item.ProductID, -item.Quantity, inventory.Quantity, info)
+		movement.ReferenceID = &transfer.ID
+		s.addMovement(t, movement)
+	}
+
+	now := s.now()
+	transfer.Status = domain.TransferInTransit
+	transfer.DispatchedAt = &now
+	return nil
+}
+
+// receiveTransfer зачисляет товары на склад назначения и переводит перемещение в статус received.
+// Если товара на складе назначения еще нет, запись инвентаризации создается с ценой исходного склада
+func (s *Store) receiveTransfer(t *tx, transfer *domain.Transfer, info domain.MovementInfo) {
+	info.Reason = "transfer_received"
+
+	for _, item := range transfer.Items {
+		key := inventoryKey{transfer.DestinationWarehouseID, item.ProductID}
+		inventory, ok := s.inventory[key]
+		if !ok {
+			inventory = domain.Inventory{
+				ID:          uuid.New(),
+				WarehouseID: transfer.DestinationWarehouseID,
+				ProductID:   item.ProductID,
+				Price:       s.inventory[inventoryKey{transfer.SourceWarehouseID, item.ProductID}].Price,
+			}
+		}
+
+		inventory.Quantity += item.Quantity
+		s.putInventory(t, key, inventory)
+
+		movement := newMovement(transfer.DestinationWarehouseID, item.ProductID, item.Quantity, inventory.Quantity, info)
+		movement.ReferenceID = &transfer.ID
+		s.addMovement(t, movement)
+	}
+
+	now := s.now()
+	transfer.Status = domain.TransferReceived
+	transfer.ReceivedAt = &now
+}
+
+// cloneTransfer копирует позиции перемещения, чтобы вызывающая сторона не изменила хранимые данные
+func cloneTransfer(transfer domain.Transfer) domain.Transfer {
+	transfer.Items = append([]domain.ProductPurchase{}, transfer.Items...)
+	return transfer
+}
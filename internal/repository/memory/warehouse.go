@@ -0,0 +1,78 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/google/uuid"
+)
+
+// WarehouseRepository хранит склады в памяти
+type WarehouseRepository struct {
+	s *Store
+}
+
+// NewWarehouseRepository создает новый репозиторий складов в памяти
+func NewWarehouseRepository(s *Store) *WarehouseRepository {
+	return &WarehouseRepository{s: s}
+}
+
+// Create создает новый склад и записывает создание в журнал аудита
+func (r *WarehouseRepository) Create(ctx context.Context, warehouse domain.Warehouse) (domain.Warehouse, error) {
+	if warehouse.ID == uuid.Nil {
+		warehouse.ID = uuid.New()
+	}
+
+	err := r.s.update(func(t *tx) error {
+		if _, ok := r.s.warehouses[warehouse.ID]; ok {
+			return conflict(repository.EntityWarehouse, "pkey")
+		}
+		put(t, r.s.warehouses, warehouse.ID, warehouse)
+		return r.s.addAudit(ctx, t, repository.EntityWarehouse, warehouse.ID, domain.AuditCreate, nil, warehouse)
+	})
+	if err != nil {
+		return domain.Warehouse{}, err
+	}
+
+	return warehouse, nil
+}
+
+// GetAll возвращает список всех складов, упорядоченный по адресу
+func (r *WarehouseRepository) GetAll(ctx context.Context) ([]domain.Warehouse, error) {
+	var warehouses []domain.Warehouse
+	err := r.s.view(func() error {
+		for _, w := range r.s.warehouses {
+			warehouses = append(warehouses, w)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(warehouses, func(a, b domain.Warehouse) int {
+		return cmp.Or(cmp.Compare(a.Address, b.Address), compareIDs(a.ID, b.ID))
+	})
+
+	return warehouses, nil
+}
+
+// GetByID возвращает склад по его ID
+func (r *WarehouseRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Warehouse, error) {
+	var warehouse domain.Warehouse
+	err := r.s.view(func() error {
+		var ok bool
+		if warehouse, ok = r.s.warehouses[id]; !ok {
+			return notFound(repository.EntityWarehouse)
+		}
+		return nil
+	})
+	if err != nil {
+		return domain.Warehouse{}, err
+	}
+
+	return warehouse, nil
+}
@@ -0,0 +1,205 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// OrderRepository представляет репозиторий для работы с заказами
+type OrderRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewOrderRepository создает новый репозиторий для работы с заказами
+func NewOrderRepository(pool *pgxpool.Pool) *OrderRepository {
+	return &OrderRepository{pool: pool}
+}
+
+// GetAll возвращает список заказов с фильтрацией и пагинацией
+func (r *OrderRepository) GetAll(ctx context.Context, filter domain.OrderFilter) ([]domain.Order, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+
+	if filter.WarehouseID != nil {
+		args = append(args, *filter.WarehouseID)
+		conditions = append(conditions, fmt.Sprintf("warehouse_id = $%d", len(args)))
+	}
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		conditions = append(conditions, fmt.Sprintf("created_at >= $%d", len(args)))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		conditions = append(conditions, fmt.Sprintf("created_at < $%d", len(args)))
+	}
+
+	query := `
+		SELECT id, warehouse_id, total_sum, created_at
+		FROM orders
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var orders []domain.Order
+	index := make(map[uuid.UUID]int)
+	for rows.Next() {
+		var o domain.Order
+		if err := rows.Scan(&o.ID, &o.WarehouseID, &o.TotalSum, &o.CreatedAt); err != nil {
+			return nil, err
+		}
+		o.Items = []domain.OrderItem{}
+		index[o.ID] = len(orders)
+		orders = append(orders, o)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(orders) == 0 {
+		return orders, nil
+	}
+
+	ids := make([]uuid.UUID, 0, len(orders))
+	for _, o := range orders {
+		ids = append(ids, o.ID)
+	}
+
+	items, err := r.getItems(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		i := index[item.OrderID]
+		orders[i].Items = append(orders[i].Items, item)
+	}
+
+	return orders, nil
+}
+
+// GetByID возвращает заказ по его ID вместе с позициями
+func (r *OrderRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Order, error) {
+	query := `
+		SELECT id, warehouse_id, total_sum, created_at
+		FROM orders
+		WHERE id = $1
+	`
+
+	var order domain.Order
+	err := r.pool.QueryRow(ctx, query, id).Scan(
+		&order.ID,
+		&order.WarehouseID,
+		&order.TotalSum,
+		&order.CreatedAt,
+	)
+	if err != nil {
+		return domain.Order{}, mapError(err, EntityOrder)
+	}
+
+	items, err := r.getItems(ctx, []uuid.UUID{id})
+	if err != nil {
+		return domain.Order{}, err
+	}
+
+	order.Items = items
+	if order.Items == nil {
+		order.Items = []domain.OrderItem{}
+	}
+
+	return order, nil
+}
+
+// getItems возвращает позиции указанных заказов
+func (r *OrderRepository) getItems(ctx context.Context, orderIDs []uuid.UUID) ([]domain.OrderItem, error) {
+	query := `
+		SELECT id, order_id, product_id, quantity, price, discount, price_with_discount, total_price
+		FROM order_items
+		WHERE order_id = ANY($1)
+		ORDER BY order_id, product_id
+	`
+
+	rows, err := r.pool.Query(ctx, query, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []domain.OrderItem
+	for rows.Next() {
+		var item domain.OrderItem
+		if err := rows.Scan(
+			&item.ID,
+			&item.OrderID,
+			&item.ProductID,
+			&item.Quantity,
+			&item.Price,
+			&item.Discount,
+			&item.PriceWithDiscount,
+			&item.TotalPrice,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// insertOrder сохраняет заказ и его позиции в рамках переданной транзакции
+func insertOrder(ctx context.Context, tx pgx.Tx, order *domain.Order) error {
+	err := tx.QueryRow(ctx, `
+		INSERT INTO orders (id, warehouse_id, total_sum)
+		VALUES ($1, $2, $3)
+		RETURNING created_at
+	`, order.ID, order.WarehouseID, order.TotalSum).Scan(&order.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for i := range order.Items {
+		item := &order.Items[i]
+		item.OrderID = order.ID
+
+		_, err := tx.Exec(ctx, `
+			INSERT INTO order_items (id, order_id, product_id, quantity, price, discount, price_with_discount, total_price)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		`,
+			item.ID,
+			item.OrderID,
+			item.ProductID,
+			item.Quantity,
+			item.Price,
+			item.Discount,
+			item.PriceWithDiscount,
+			item.TotalPrice,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -23,6 +23,17 @@ func NewPostgresDB(cfg config.DatabaseConfig) (*PostgresDB, error) {
 	poolConfig.MaxConns = int32(cfg.MaxOpenConns)
 	poolConfig.MinConns = int32(cfg.MaxIdleConns)
 	poolConfig.MaxConnLifetime = cfg.ConnMaxLifetime
+	poolConfig.ConnConfig.Tracer = newQueryTracer()
+
+	// Имя приложения из DATABASE_URL имеет приоритет над DATABASE_APPLICATION_NAME
+	applicationName := poolConfig.ConnConfig.RuntimeParams["application_name"]
+	if applicationName == "" {
+		applicationName = cfg.ApplicationName
+		poolConfig.ConnConfig.RuntimeParams["application_name"] = applicationName
+	}
+	if cfg.TagRequests {
+		poolConfig.BeforeAcquire = tagRequest(applicationName)
+	}
 
 	pool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
 	if err != nil {
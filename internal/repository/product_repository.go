@@ -2,9 +2,12 @@ package repository
 
 import (
 	"context"
+	"fmt"
+	"strings"
 
 	"github.com/danya1733/practiceGO/internal/domain"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,50 +21,99 @@ func NewProductRepository(pool *pgxpool.Pool) *ProductRepository {
 	return &ProductRepository{pool: pool}
 }
 
-// Create создает новый товар
+// Create создает новый товар и записывает создание в журнал аудита
 func (r *ProductRepository) Create(ctx context.Context, product domain.Product) (domain.Product, error) {
 	query := `
 		INSERT INTO products (id, name, description, characteristics, weight, barcode)
 		VALUES ($1, $2, $3, $4, $5, $6)
-		RETURNING id, name, description, characteristics, weight, barcode
+		RETURNING id, name, description, characteristics, weight, barcode, version
 	`
 
 	if product.ID == uuid.Nil {
 		product.ID = uuid.New()
 	}
 
-	err := r.pool.QueryRow(ctx, query,
-		product.ID,
-		product.Name,
-		product.Description,
-		product.Characteristics,
-		product.Weight,
-		product.Barcode,
-	).Scan(
-		&product.ID,
-		&product.Name,
-		&product.Description,
-		&product.Characteristics,
-		&product.Weight,
-		&product.Barcode,
-	)
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		err := tx.QueryRow(ctx, query,
+			product.ID,
+			product.Name,
+			product.Description,
+			product.Characteristics,
+			product.Weight,
+			product.Barcode,
+		).Scan(
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.Characteristics,
+			&product.Weight,
+			&product.Barcode,
+			&product.Version,
+		)
+		if err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, EntityProduct, product.ID, domain.AuditCreate, nil, product)
+	})
 
 	if err != nil {
-		return domain.Product{}, err
+		return domain.Product{}, mapError(err, EntityProduct)
 	}
 
 	return product, nil
 }
 
-// GetAll возвращает список всех товаров
-func (r *ProductRepository) GetAll(ctx context.Context) ([]domain.Product, error) {
+// productSortColumns задает колонку сортировки для каждого порядка сортировки товаров
+var productSortColumns = map[domain.ProductSort]string{
+	domain.ProductSortName:        "name",
+	domain.ProductSortNameDesc:    "name",
+	domain.ProductSortWeight:      "weight",
+	domain.ProductSortWeightDesc:  "weight",
+	domain.ProductSortBarcode:     "barcode",
+	domain.ProductSortBarcodeDesc: "barcode",
+}
+
+// GetAll возвращает товары по фильтру с keyset-пагинацией: страница начинается
+// после позиции filter.After в порядке filter.Sort, при равных значениях - по ID
+func (r *ProductRepository) GetAll(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error) {
+	var args []interface{}
+	conditions := productConditions(filter, &args)
+
+	column, ok := productSortColumns[filter.Sort]
+	if !ok {
+		column = "name"
+	}
+	direction, op := "ASC", ">"
+	if filter.Sort.Desc() {
+		direction, op = "DESC", "<"
+	}
+
+	if after := filter.After; after != nil {
+		var value interface{}
+		switch column {
+		case "weight":
+			value = after.Weight
+		case "barcode":
+			value = after.Barcode
+		default:
+			value = after.Name
+		}
+		args = append(args, value, after.ID)
+		conditions = append(conditions, fmt.Sprintf("(%s, id) %s ($%d, $%d)", column, op, len(args)-1, len(args)))
+	}
+
 	query := `
-		SELECT id, name, description, characteristics, weight, barcode
+		SELECT id, name, description, characteristics, weight, barcode, version
 		FROM products
-		ORDER BY name
 	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
 
-	rows, err := r.pool.Query(ctx, query)
+	args = append(args, filter.Limit)
+	query += fmt.Sprintf(" ORDER BY %[1]s %[2]s, id %[2]s LIMIT $%[3]d", column, direction, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -77,6 +129,7 @@ func (r *ProductRepository) GetAll(ctx context.Context) ([]domain.Product, error
 			&p.Characteristics,
 			&p.Weight,
 			&p.Barcode,
+			&p.Version,
 		); err != nil {
 			return nil, err
 		}
@@ -90,58 +143,180 @@ func (r *ProductRepository) GetAll(ctx context.Context) ([]domain.Product, error
 	return products, nil
 }
 
+// GetAttributeFacets возвращает характеристики товаров, подходящих под фильтр, с числом товаров
+// для каждого значения. Вложенные объекты раскрываются в пути через точку; учитываются
+// только строковые, числовые и логические значения, не больше limit самых частых на характеристику
+func (r *ProductRepository) GetAttributeFacets(ctx context.Context, filter domain.ProductFilter, limit int) ([]domain.AttributeFacet, error) {
+	var args []interface{}
+	conditions := productConditions(filter, &args)
+
+	filtered := "SELECT id, characteristics FROM products"
+	if len(conditions) > 0 {
+		filtered += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	args = append(args, limit)
+	query := fmt.Sprintf(`
+		WITH RECURSIVE filtered AS (
+			%s
+		), attributes(product_id, path, value) AS (
+			SELECT f.id, ARRAY[e.key], e.value
+			FROM filtered f,
+				jsonb_each(CASE WHEN jsonb_typeof(f.characteristics) = 'object' THEN f.characteristics ELSE '{}'::jsonb END) e
+			UNION ALL
+			SELECT a.product_id, a.path || e.key, e.value
+			FROM attributes a,
+				jsonb_each(CASE WHEN jsonb_typeof(a.value) = 'object' THEN a.value ELSE '{}'::jsonb END) e
+		), counts AS (
+			SELECT path, value, count(*) AS products
+			FROM attributes
+			WHERE jsonb_typeof(value) IN ('string', 'number', 'boolean')
+			GROUP BY path, value
+		), ranked AS (
+			SELECT path, value, products,
+				sum(products) OVER (PARTITION BY path)::bigint AS total,
+				row_number() OVER (PARTITION BY path ORDER BY products DESC, value) AS rank
+			FROM counts
+		)
+		SELECT path, value, products, total
+		FROM ranked
+		WHERE rank <= $%d
+		ORDER BY path, rank
+	`, filtered, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var facets []domain.AttributeFacet
+	for rows.Next() {
+		var (
+			path  []string
+			value domain.AttributeValueCount
+			total int
+		)
+		if err := rows.Scan(&path, &value.Value, &value.Count, &total); err != nil {
+			return nil, err
+		}
+
+		key := strings.Join(path, ".")
+		if n := len(facets); n == 0 || facets[n-1].Key != key {
+			facets = append(facets, domain.AttributeFacet{Key: key, Count: total})
+		}
+		facets[len(facets)-1].Values = append(facets[len(facets)-1].Values, value)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return facets, nil
+}
+
+// productConditions строит SQL условия фильтра каталога товаров без учета пагинации
+func productConditions(filter domain.ProductFilter, args *[]interface{}) []string {
+	var conditions []string
+
+	if filter.Search != "" {
+		*args = append(*args, filter.Search)
+		conditions = append(conditions, fmt.Sprintf(
+			"product_search_vector(name, description) @@ (websearch_to_tsquery('russian', $%[1]d) || websearch_to_tsquery('english', $%[1]d))", len(*args)))
+	}
+	if filter.Barcode != "" {
+		*args = append(*args, filter.Barcode)
+		conditions = append(conditions, fmt.Sprintf("barcode = $%d", len(*args)))
+	}
+	if filter.MinWeight != nil {
+		*args = append(*args, *filter.MinWeight)
+		conditions = append(conditions, fmt.Sprintf("weight >= $%d", len(*args)))
+	}
+	if filter.MaxWeight != nil {
+		*args = append(*args, *filter.MaxWeight)
+		conditions = append(conditions, fmt.Sprintf("weight <= $%d", len(*args)))
+	}
+
+	return append(conditions, attributeConditions("characteristics", filter.Attributes, args)...)
+}
+
 // GetByID возвращает товар по его ID
 func (r *ProductRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Product, error) {
+	product, err := getProduct(ctx, r.pool, id, false)
+	if err != nil {
+		return domain.Product{}, mapError(err, EntityProduct)
+	}
+	return product, nil
+}
+
+// getProduct читает товар; при forUpdate строка блокируется до конца транзакции
+func getProduct(ctx context.Context, q rowQuerier, id uuid.UUID, forUpdate bool) (domain.Product, error) {
 	query := `
-		SELECT id, name, description, characteristics, weight, barcode
+		SELECT id, name, description, characteristics, weight, barcode, version
 		FROM products
 		WHERE id = $1
 	`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
 
 	var product domain.Product
-	err := r.pool.QueryRow(ctx, query, id).Scan(
+	err := q.QueryRow(ctx, query, id).Scan(
 		&product.ID,
 		&product.Name,
 		&product.Description,
 		&product.Characteristics,
 		&product.Weight,
 		&product.Barcode,
+		&product.Version,
 	)
-
-	if err != nil {
-		return domain.Product{}, err
-	}
-
-	return product, nil
+	return product, err
 }
 
-// Update обновляет информацию о товаре
-func (r *ProductRepository) Update(ctx context.Context, product domain.Product) (domain.Product, error) {
+// Update обновляет информацию о товаре и записывает изменение в журнал аудита.
+// Если version не 0, товар обновляется, только если его текущая версия равна version
+func (r *ProductRepository) Update(ctx context.Context, product domain.Product, version int) (domain.Product, error) {
 	query := `
 		UPDATE products
 		SET name = $2, description = $3, characteristics = $4, weight = $5, barcode = $6
 		WHERE id = $1
-		RETURNING id, name, description, characteristics, weight, barcode
+		RETURNING id, name, description, characteristics, weight, barcode, version
 	`
 
-	err := r.pool.QueryRow(ctx, query,
-		product.ID,
-		product.Name,
-		product.Description,
-		product.Characteristics,
-		product.Weight,
-		product.Barcode,
-	).Scan(
-		&product.ID,
-		&product.Name,
-		&product.Description,
-		&product.Characteristics,
-		&product.Weight,
-		&product.Barcode,
-	)
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		// Состояние до изменения читается из заблокированной строки той же транзакции
+		before, err := getProduct(ctx, tx, product.ID, true)
+		if err != nil {
+			return err
+		}
+		if version != 0 && before.Version != version {
+			return &Error{Kind: ErrVersionMismatch, Entity: EntityProduct}
+		}
+
+		err = tx.QueryRow(ctx, query,
+			product.ID,
+			product.Name,
+			product.Description,
+			product.Characteristics,
+			product.Weight,
+			product.Barcode,
+		).Scan(
+			&product.ID,
+			&product.Name,
+			&product.Description,
+			&product.Characteristics,
+			&product.Weight,
+			&product.Barcode,
+			&product.Version,
+		)
+		if err != nil {
+			return err
+		}
 
+		return insertAudit(ctx, tx, EntityProduct, product.ID, domain.AuditUpdate, before, product)
+	})
 	if err != nil {
-		return domain.Product{}, err
+		return domain.Product{}, mapError(err, EntityProduct)
 	}
 
 	return product, nil
@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/danya1733/practiceGO/pkg/requestctx"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// maxApplicationNameLength максимальная длина application_name; PostgreSQL обрезает более длинные значения
+	maxApplicationNameLength = 63
+	// tagRequestTimeout ограничивает время смены application_name при выдаче соединения
+	tagRequestTimeout = time.Second
+)
+
+// tagRequest возвращает хук pgxpool.BeforeAcquire, который на время HTTP запроса
+// меняет application_name соединения на "<base>:<request_id>". Так запрос можно найти
+// в pg_stat_activity и в логах PostgreSQL (%a в log_line_prefix) по тому же ID, что и в логах приложения.
+// Соединению, выданному вне HTTP запроса, возвращается исходное имя. Если имя уже совпадает,
+// запрос к базе не выполняется
+func tagRequest(base string) func(context.Context, *pgx.Conn) bool {
+	return func(ctx context.Context, conn *pgx.Conn) bool {
+		name := base
+		if requestID := requestctx.RequestID(ctx); requestID != "" {
+			name = base + ":" + requestID
+		}
+		if len(name) > maxApplicationNameLength {
+			name = name[:maxApplicationNameLength]
+		}
+
+		if conn.PgConn().ParameterStatus("application_name") == name {
+			return true
+		}
+
+		// Отмена запроса клиентом не должна закрывать исправное соединение,
+		// поэтому смена имени выполняется с собственным таймаутом
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tagRequestTimeout)
+		defer cancel()
+
+		// Соединение, на котором не удалось сменить имя, закрывается, и пул выдает другое
+		_, err := conn.Exec(ctx, "SELECT set_config('application_name', $1, false)", name)
+		return err == nil
+	}
+}
@@ -0,0 +1,312 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrReservationNotActive возвращается при попытке использовать или снять неактивный резерв
+var ErrReservationNotActive = errors.New("резерв не активен")
+
+// ReservationRepository представляет репозиторий для работы с резервами товаров
+type ReservationRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewReservationRepository создает новый репозиторий для работы с резервами товаров
+func NewReservationRepository(pool *pgxpool.Pool) *ReservationRepository {
+	return &ReservationRepository{pool: pool}
+}
+
+// Create резервирует товары на складе на время ttl и записывает создание резерва в журнал аудита
+func (r *ReservationRepository) Create(ctx context.Context, warehouseID uuid.UUID, products []domain.ProductPurchase, ttl time.Duration) (domain.Reservation, error) {
+	items := mergePurchases(products)
+
+	var reservation domain.Reservation
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		// Блокируем строки инвентаря в порядке ID товара и увеличиваем резерв
+		for _, p := range items {
+			var available int
+			err := tx.QueryRow(ctx, `
+				SELECT quantity - reserved FROM inventory
+				WHERE warehouse_id = $1 AND product_id = $2
+				FOR UPDATE
+			`, warehouseID, p.ProductID).Scan(&available)
+
+			if err != nil {
+				if err == pgx.ErrNoRows {
+					return inventoryNotFound(warehouseID, p.ProductID)
+				}
+				return err
+			}
+
+			if available < p.Quantity {
+				return &InsufficientStockError{
+					WarehouseID: warehouseID,
+					ProductID:   p.ProductID,
+					Available:   available,
+					Requested:   p.Quantity,
+				}
+			}
+
+			_, err = tx.Exec(ctx, `
+				UPDATE inventory
+				SET reserved = reserved + $3
+				WHERE warehouse_id = $1 AND product_id = $2
+			`, warehouseID, p.ProductID, p.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		reservation = domain.Reservation{
+			ID:          uuid.New(),
+			WarehouseID: warehouseID,
+			Status:      domain.ReservationActive,
+			ExpiresAt:   time.Now().Add(ttl).UTC(),
+			Items:       items,
+		}
+
+		err := tx.QueryRow(ctx, `
+			INSERT INTO reservations (id, warehouse_id, status, expires_at)
+			VALUES ($1, $2, $3, $4)
+			RETURNING created_at
+		`, reservation.ID, reservation.WarehouseID, reservation.Status, reservation.ExpiresAt).Scan(&reservation.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		for _, p := range items {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO reservation_items (reservation_id, product_id, quantity)
+				VALUES ($1, $2, $3)
+			`, reservation.ID, p.ProductID, p.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		return insertAudit(ctx, tx, EntityReservation, reservation.ID, domain.AuditCreate, nil, reservation)
+	})
+	if err != nil {
+		return domain.Reservation{}, mapError(err, EntityReservation)
+	}
+
+	return reservation, nil
+}
+
+// GetByID возвращает резерв по его ID
+func (r *ReservationRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Reservation, error) {
+	var reservation domain.Reservation
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		reservation, err = getReservation(ctx, tx, id, false)
+		return err
+	})
+	if err != nil {
+		return domain.Reservation{}, mapError(err, EntityReservation)
+	}
+
+	return reservation, nil
+}
+
+// Release досрочно снимает активный резерв, возвращает товары в доступный остаток
+// и записывает снятие в журнал аудита
+func (r *ReservationRepository) Release(ctx context.Context, id uuid.UUID) (domain.Reservation, error) {
+	var reservation domain.Reservation
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		reservation, err = getReservation(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		if reservation.Status != domain.ReservationActive {
+			return fmt.Errorf("%w: %s, статус %s", ErrReservationNotActive, id, reservation.Status)
+		}
+
+		if err := releaseReserved(ctx, tx, reservation.WarehouseID, reservation.Items); err != nil {
+			return err
+		}
+
+		before := reservation
+		reservation.Status = domain.ReservationReleased
+		if err := setReservationStatus(ctx, tx, []uuid.UUID{id}, reservation.Status); err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, EntityReservation, reservation.ID, domain.AuditRelease, before, reservation)
+	})
+	if err != nil {
+		return domain.Reservation{}, mapError(err, EntityReservation)
+	}
+
+	return reservation, nil
+}
+
+// ReleaseExpired снимает не более limit истекших резервов и возвращает их количество
+func (r *ReservationRepository) ReleaseExpired(ctx context.Context, limit int) (int, error) {
+	var released int
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		// SKIP LOCKED позволяет нескольким репликам чистить резервы параллельно
+		rows, err := tx.Query(ctx, `
+			SELECT id FROM reservations
+			WHERE status = $1 AND expires_at <= NOW()
+			ORDER BY expires_at
+			LIMIT $2
+			FOR UPDATE SKIP LOCKED
+		`, domain.ReservationActive, limit)
+		if err != nil {
+			return err
+		}
+
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+		if err != nil {
+			return err
+		}
+
+		if len(ids) == 0 {
+			released = 0
+			return nil
+		}
+
+		// Суммируем позиции по складу, чтобы блокировать строки инвентаря один раз и по порядку
+		byWarehouse := make(map[uuid.UUID][]domain.ProductPurchase)
+		for _, id := range ids {
+			reservation, err := getReservation(ctx, tx, id, false)
+			if err != nil {
+				return err
+			}
+			byWarehouse[reservation.WarehouseID] = append(byWarehouse[reservation.WarehouseID], reservation.Items...)
+		}
+
+		// Склады обходятся в порядке возрастания ID, чтобы параллельные очистки и покупки
+		// блокировали строки инвентаря в одном порядке и не попадали во взаимную блокировку
+		warehouseIDs := slices.SortedFunc(maps.Keys(byWarehouse), func(a, b uuid.UUID) int {
+			return bytes.Compare(a[:], b[:])
+		})
+		for _, warehouseID := range warehouseIDs {
+			if err := releaseReserved(ctx, tx, warehouseID, mergePurchases(byWarehouse[warehouseID])); err != nil {
+				return err
+			}
+		}
+
+		released = len(ids)
+		return setReservationStatus(ctx, tx, ids, domain.ReservationExpired)
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return released, nil
+}
+
+// getReservation читает резерв и его позиции в рамках транзакции
+func getReservation(ctx context.Context, tx pgx.Tx, id uuid.UUID, forUpdate bool) (domain.Reservation, error) {
+	query := `
+		SELECT id, warehouse_id, status, expires_at, created_at
+		FROM reservations
+		WHERE id = $1
+	`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var reservation domain.Reservation
+	err := tx.QueryRow(ctx, query, id).Scan(
+		&reservation.ID,
+		&reservation.WarehouseID,
+		&reservation.Status,
+		&reservation.ExpiresAt,
+		&reservation.CreatedAt,
+	)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT product_id, quantity
+		FROM reservation_items
+		WHERE reservation_id = $1
+		ORDER BY product_id
+	`, id)
+	if err != nil {
+		return domain.Reservation{}, err
+	}
+	defer rows.Close()
+
+	reservation.Items = []domain.ProductPurchase{}
+	for rows.Next() {
+		var item domain.ProductPurchase
+		if err := rows.Scan(&item.ProductID, &item.Quantity); err != nil {
+			return domain.Reservation{}, err
+		}
+		reservation.Items = append(reservation.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return domain.Reservation{}, err
+	}
+
+	return reservation, nil
+}
+
+// consumeReservation помечает активный резерв как использованный и возвращает его позиции.
+// Сам резерв в инвентаре снимается вызывающей стороной вместе со списанием остатка.
+func consumeReservation(ctx context.Context, tx pgx.Tx, id, warehouseID uuid.UUID) ([]domain.ProductPurchase, error) {
+	reservation, err := getReservation(ctx, tx, id, true)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, fmt.Errorf("%w: %s", ErrReservationNotFound, id)
+		}
+		return nil, err
+	}
+
+	if reservation.WarehouseID != warehouseID {
+		return nil, fmt.Errorf("%w: %s", ErrReservationMismatch, id)
+	}
+
+	if reservation.Status != domain.ReservationActive || !reservation.ExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("%w или истек: %s", ErrReservationNotActive, id)
+	}
+
+	if err := setReservationStatus(ctx, tx, []uuid.UUID{id}, domain.ReservationConsumed); err != nil {
+		return nil, err
+	}
+
+	return reservation.Items, nil
+}
+
+// releaseReserved уменьшает зарезервированное количество товаров на складе.
+// Позиции должны быть отсортированы по ID товара (см. mergePurchases).
+func releaseReserved(ctx context.Context, tx pgx.Tx, warehouseID uuid.UUID, items []domain.ProductPurchase) error {
+	for _, p := range items {
+		_, err := tx.Exec(ctx, `
+			UPDATE inventory
+			SET reserved = GREATEST(reserved - $3, 0)
+			WHERE warehouse_id = $1 AND product_id = $2
+		`, warehouseID, p.ProductID, p.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setReservationStatus изменяет статус резервов
+func setReservationStatus(ctx context.Context, tx pgx.Tx, ids []uuid.UUID, status domain.ReservationStatus) error {
+	_, err := tx.Exec(ctx, `
+		UPDATE reservations SET status = $2 WHERE id = ANY($1)
+	`, ids, status)
+	return err
+}
@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// StockMovementRepository представляет репозиторий для работы с журналом движения товаров
+type StockMovementRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewStockMovementRepository создает новый репозиторий для работы с журналом движения товаров
+func NewStockMovementRepository(pool *pgxpool.Pool) *StockMovementRepository {
+	return &StockMovementRepository{pool: pool}
+}
+
+// GetByWarehouseAndProduct возвращает движения товара на складе, начиная с последних
+func (r *StockMovementRepository) GetByWarehouseAndProduct(ctx context.Context, warehouseID, productID uuid.UUID, filter domain.MovementFilter) ([]domain.StockMovement, error) {
+	query := `
+		SELECT id, warehouse_id, product_id, type, quantity_delta, quantity_after,
+			   reason, actor, request_id, reference_id, created_at
+		FROM stock_movements
+		WHERE warehouse_id = $1 AND product_id = $2
+	`
+	args := []interface{}{warehouseID, productID}
+
+	if filter.From != nil {
+		args = append(args, *filter.From)
+		query += fmt.Sprintf(" AND created_at >= $%d", len(args))
+	}
+	if filter.To != nil {
+		args = append(args, *filter.To)
+		query += fmt.Sprintf(" AND created_at < $%d", len(args))
+	}
+
+	args = append(args, filter.Limit, (filter.Page-1)*filter.Limit)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := r.pool.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var movements []domain.StockMovement
+	for rows.Next() {
+		var m domain.StockMovement
+		if err := rows.Scan(
+			&m.ID,
+			&m.WarehouseID,
+			&m.ProductID,
+			&m.Type,
+			&m.QuantityDelta,
+			&m.QuantityAfter,
+			&m.Reason,
+			&m.Actor,
+			&m.RequestID,
+			&m.ReferenceID,
+			&m.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		movements = append(movements, m)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return movements, nil
+}
+
+// insertMovement записывает движение товара в рамках переданной транзакции
+func insertMovement(ctx context.Context, tx pgx.Tx, m *domain.StockMovement) error {
+	if m.ID == uuid.Nil {
+		m.ID = uuid.New()
+	}
+
+	return tx.QueryRow(ctx, `
+		INSERT INTO stock_movements (id, warehouse_id, product_id, type, quantity_delta, quantity_after,
+			reason, actor, request_id, reference_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at
+	`,
+		m.ID,
+		m.WarehouseID,
+		m.ProductID,
+		m.Type,
+		m.QuantityDelta,
+		m.QuantityAfter,
+		m.Reason,
+		m.Actor,
+		m.RequestID,
+		m.ReferenceID,
+	).Scan(&m.CreatedAt)
+}
+
+// newMovement создает движение товара по описанию изменения
+func newMovement(warehouseID, productID uuid.UUID, delta, after int, info domain.MovementInfo) *domain.StockMovement {
+	return &domain.StockMovement{
+		WarehouseID:   warehouseID,
+		ProductID:     productID,
+		Type:          info.Type,
+		QuantityDelta: delta,
+		QuantityAfter: after,
+		Reason:        info.Reason,
+		Actor:         info.Actor,
+		RequestID:     info.RequestID,
+	}
+}
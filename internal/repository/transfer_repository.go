@@ -0,0 +1,419 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrTransferState возвращается, если операция недопустима в текущем статусе перемещения
+var ErrTransferState = errors.New("недопустимый статус перемещения")
+
+// TransferRepository представляет репозиторий для работы с перемещениями товаров между складами
+type TransferRepository struct {
+	pool *pgxpool.Pool
+}
+
+// NewTransferRepository создает новый репозиторий для работы с перемещениями
+func NewTransferRepository(pool *pgxpool.Pool) *TransferRepository {
+	return &TransferRepository{pool: pool}
+}
+
+// Create создает перемещение в статусе pending.
+// Если immediate == true, товары списываются с исходного склада и зачисляются
+// на склад назначения в той же транзакции, и перемещение сразу получает статус received.
+// Создание перемещения записывается в журнал аудита
+func (r *TransferRepository) Create(ctx context.Context, request domain.TransferRequest, immediate bool, info domain.MovementInfo) (domain.Transfer, error) {
+	info.Type = domain.MovementTransfer
+
+	var transfer domain.Transfer
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		transfer = domain.Transfer{
+			ID:                     uuid.New(),
+			SourceWarehouseID:      request.SourceWarehouseID,
+			DestinationWarehouseID: request.DestinationWarehouseID,
+			Status:                 domain.TransferPending,
+			Items:                  mergePurchases(request.Items),
+		}
+
+		err := tx.QueryRow(ctx, `
+			INSERT INTO transfers (id, source_warehouse_id, destination_warehouse_id, status)
+			VALUES ($1, $2, $3, $4)
+			RETURNING created_at
+		`, transfer.ID, transfer.SourceWarehouseID, transfer.DestinationWarehouseID, transfer.Status).Scan(&transfer.CreatedAt)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range transfer.Items {
+			_, err := tx.Exec(ctx, `
+				INSERT INTO transfer_items (transfer_id, product_id, quantity)
+				VALUES ($1, $2, $3)
+			`, transfer.ID, item.ProductID, item.Quantity)
+			if err != nil {
+				return err
+			}
+		}
+
+		if immediate {
+			// Строки обоих складов блокируются в едином порядке, чтобы встречные перемещения не блокировали друг друга
+			if err := lockInventoryRows(ctx, tx, []uuid.UUID{transfer.SourceWarehouseID, transfer.DestinationWarehouseID}, transfer.Items); err != nil {
+				return err
+			}
+			if err := dispatchTransfer(ctx, tx, &transfer, info); err != nil {
+				return err
+			}
+			if err := receiveTransfer(ctx, tx, &transfer, info); err != nil {
+				return err
+			}
+		}
+
+		return insertAudit(ctx, tx, EntityTransfer, transfer.ID, domain.AuditCreate, nil, transfer)
+	})
+	if err != nil {
+		return domain.Transfer{}, mapError(err, EntityTransfer)
+	}
+
+	return transfer, nil
+}
+
+// Dispatch отгружает перемещение: списывает товары с исходного склада и переводит его в статус in_transit.
+// Переход записывается в журнал аудита
+func (r *TransferRepository) Dispatch(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error) {
+	info.Type = domain.MovementTransfer
+
+	var transfer domain.Transfer
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		transfer, err = getTransfer(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		if transfer.Status != domain.TransferPending {
+			return fmt.Errorf("%w: отгрузить можно только перемещение в статусе pending, текущий статус %s", ErrTransferState, transfer.Status)
+		}
+
+		before := transfer
+		if err := dispatchTransfer(ctx, tx, &transfer, info); err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, EntityTransfer, transfer.ID, domain.AuditDispatch, before, transfer)
+	})
+	if err != nil {
+		return domain.Transfer{}, mapError(err, EntityTransfer)
+	}
+
+	return transfer, nil
+}
+
+// Receive принимает перемещение на складе назначения и переводит его в статус received.
+// Переход записывается в журнал аудита
+func (r *TransferRepository) Receive(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error) {
+	info.Type = domain.MovementTransfer
+
+	var transfer domain.Transfer
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		transfer, err = getTransfer(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		if transfer.Status != domain.TransferInTransit {
+			return fmt.Errorf("%w: принять можно только перемещение в статусе in_transit, текущий статус %s", ErrTransferState, transfer.Status)
+		}
+
+		before := transfer
+		if err := receiveTransfer(ctx, tx, &transfer, info); err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, EntityTransfer, transfer.ID, domain.AuditReceive, before, transfer)
+	})
+	if err != nil {
+		return domain.Transfer{}, mapError(err, EntityTransfer)
+	}
+
+	return transfer, nil
+}
+
+// Cancel отменяет перемещение. Товары отгруженного перемещения возвращаются на исходный склад.
+// Отмена записывается в журнал аудита
+func (r *TransferRepository) Cancel(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error) {
+	info.Type = domain.MovementTransfer
+
+	var transfer domain.Transfer
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		transfer, err = getTransfer(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+
+		before := transfer
+		switch transfer.Status {
+		case domain.TransferPending:
+		case domain.TransferInTransit:
+			// Возвращаем товары на исходный склад
+			for _, item := range transfer.Items {
+				var quantityAfter int
+				err := tx.QueryRow(ctx, `
+					UPDATE inventory
+					SET quantity = quantity + $3
+					WHERE warehouse_id = $1 AND product_id = $2
+					RETURNING quantity
+				`, transfer.SourceWarehouseID, item.ProductID, item.Quantity).Scan(&quantityAfter)
+				if err != nil {
+					return err
+				}
+
+				info.Reason = "transfer_cancelled"
+				movement := newMovement(transfer.SourceWarehouseID, item.ProductID, item.Quantity, quantityAfter, info)
+				movement.ReferenceID = &transfer.ID
+				if err := insertMovement(ctx, tx, movement); err != nil {
+					return err
+				}
+			}
+		default:
+			return fmt.Errorf("%w: нельзя отменить перемещение в статусе %s", ErrTransferState, transfer.Status)
+		}
+
+		transfer.Status = domain.TransferCancelled
+		err = tx.QueryRow(ctx, `
+			UPDATE transfers SET status = $2, cancelled_at = NOW()
+			WHERE id = $1
+			RETURNING cancelled_at
+		`, transfer.ID, transfer.Status).Scan(&transfer.CancelledAt)
+		if err != nil {
+			return err
+		}
+
+		return insertAudit(ctx, tx, EntityTransfer, transfer.ID, domain.AuditCancel, before, transfer)
+	})
+	if err != nil {
+		return domain.Transfer{}, mapError(err, EntityTransfer)
+	}
+
+	return transfer, nil
+}
+
+// GetByID возвращает перемещение по его ID
+func (r *TransferRepository) GetByID(ctx context.Context, id uuid.UUID) (domain.Transfer, error) {
+	var transfer domain.Transfer
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		var err error
+		transfer, err = getTransfer(ctx, tx, id, false)
+		return err
+	})
+	if err != nil {
+		return domain.Transfer{}, mapError(err, EntityTransfer)
+	}
+
+	return transfer, nil
+}
+
+// GetByWarehouse возвращает перемещения, в которых склад является источником или получателем.
+// Если statuses не пуст, выбираются только перемещения с указанными статусами
+func (r *TransferRepository) GetByWarehouse(ctx context.Context, warehouseID uuid.UUID, statuses []domain.TransferStatus) ([]domain.Transfer, error) {
+	statusValues := make([]string, 0, len(statuses))
+	for _, status := range statuses {
+		statusValues = append(statusValues, string(status))
+	}
+
+	var transfers []domain.Transfer
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, `
+			SELECT id FROM transfers
+			WHERE (source_warehouse_id = $1 OR destination_warehouse_id = $1)
+				AND (cardinality($2::TEXT[]) = 0 OR status = ANY($2))
+			ORDER BY created_at DESC, id
+		`, warehouseID, statusValues)
+		if err != nil {
+			return err
+		}
+
+		ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+		if err != nil {
+			return err
+		}
+
+		transfers = make([]domain.Transfer, 0, len(ids))
+		for _, id := range ids {
+			transfer, err := getTransfer(ctx, tx, id, false)
+			if err != nil {
+				return err
+			}
+			transfers = append(transfers, transfer)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return transfers, nil
+}
+
+// getTransfer читает перемещение и его позиции в рамках транзакции
+func getTransfer(ctx context.Context, tx pgx.Tx, id uuid.UUID, forUpdate bool) (domain.Transfer, error) {
+	query := `
+		SELECT id, source_warehouse_id, destination_warehouse_id, status,
+			   created_at, dispatched_at, received_at, cancelled_at
+		FROM transfers
+		WHERE id = $1
+	`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	var transfer domain.Transfer
+	err := tx.QueryRow(ctx, query, id).Scan(
+		&transfer.ID,
+		&transfer.SourceWarehouseID,
+		&transfer.DestinationWarehouseID,
+		&transfer.Status,
+		&transfer.CreatedAt,
+		&transfer.DispatchedAt,
+		&transfer.ReceivedAt,
+		&transfer.CancelledAt,
+	)
+	if err != nil {
+		return domain.Transfer{}, err
+	}
+
+	rows, err := tx.Query(ctx, `
+		SELECT product_id, quantity
+		FROM transfer_items
+		WHERE transfer_id = $1
+		ORDER BY product_id
+	`, id)
+	if err != nil {
+		return domain.Transfer{}, err
+	}
+	defer rows.Close()
+
+	transfer.Items = []domain.ProductPurchase{}
+	for rows.Next() {
+		var item domain.ProductPurchase
+		if err := rows.Scan(&item.ProductID, &item.Quantity); err != nil {
+			return domain.Transfer{}, err
+		}
+		transfer.Items = append(transfer.Items, item)
+	}
+
+	if err := rows.Err(); err != nil {
+		return domain.Transfer{}, err
+	}
+
+	return transfer, nil
+}
+
+// dispatchTransfer списывает товары с исходного склада и переводит перемещение в статус in_transit
+func dispatchTransfer(ctx context.Context, tx pgx.Tx, transfer *domain.Transfer, info domain.MovementInfo) error {
+	info.Reason = "transfer_dispatched"
+
+	for _, item := range transfer.Items {
+		var available int
+		err := tx.QueryRow(ctx, `
+			SELECT quantity - reserved FROM inventory
+			WHERE warehouse_id = $1 AND product_id = $2
+			FOR UPDATE
+		`, transfer.SourceWarehouseID, item.ProductID).Scan(&available)
+		if err != nil {
+			if err == pgx.ErrNoRows {
+				return inventoryNotFound(transfer.SourceWarehouseID, item.ProductID)
+			}
+			return err
+		}
+
+		if available < item.Quantity {
+			return &InsufficientStockError{
+				WarehouseID: transfer.SourceWarehouseID,
+				ProductID:   item.ProductID,
+				Available:   available,
+				Requested:   item.Quantity,
+			}
+		}
+
+		var quantityAfter int
+		err = tx.QueryRow(ctx, `
+			UPDATE inventory
+			SET quantity = quantity - $3
+			WHERE warehouse_id = $1 AND product_id = $2
+			RETURNING quantity
+		`, transfer.SourceWarehouseID, item.ProductID, item.Quantity).Scan(&quantityAfter)
+		if err != nil {
+			return err
+		}
+
+		movement := newMovement(transfer.SourceWarehouseID, item.ProductID, -item.Quantity, quantityAfter, info)
+		movement.ReferenceID = &transfer.ID
+		if err := insertMovement(ctx, tx, movement); err != nil {
+			return err
+		}
+	}
+
+	transfer.Status = domain.TransferInTransit
+	return tx.QueryRow(ctx, `
+		UPDATE transfers SET status = $2, dispatched_at = NOW()
+		WHERE id = $1
+		RETURNING dispatched_at
+	`, transfer.ID, transfer.Status).Scan(&transfer.DispatchedAt)
+}
+
+// receiveTransfer зачисляет товары на склад назначения и переводит перемещение в статус received.
+// Если товара на складе назначения еще нет, запись инвентаризации создается с ценой исходного склада
+func receiveTransfer(ctx context.Context, tx pgx.Tx, transfer *domain.Transfer, info domain.MovementInfo) error {
+	info.Reason = "transfer_received"
+
+	for _, item := range transfer.Items {
+		var quantityAfter int
+		err := tx.QueryRow(ctx, `
+			INSERT INTO inventory (id, warehouse_id, product_id, quantity, price, discount)
+			SELECT $1, $2, $3, $4, COALESCE((
+				SELECT price FROM inventory WHERE warehouse_id = $5 AND product_id = $3
+			), 0), 0
+			ON CONFLICT (warehouse_id, product_id) DO UPDATE
+			SET quantity = inventory.quantity + EXCLUDED.quantity
+			RETURNING quantity
+		`, uuid.New(), transfer.DestinationWarehouseID, item.ProductID, item.Quantity, transfer.SourceWarehouseID).Scan(&quantityAfter)
+		if err != nil {
+			return err
+		}
+
+		movement := newMovement(transfer.DestinationWarehouseID, item.ProductID, item.Quantity, quantityAfter, info)
+		movement.ReferenceID = &transfer.ID
+		if err := insertMovement(ctx, tx, movement); err != nil {
+			return err
+		}
+	}
+
+	transfer.Status = domain.TransferReceived
+	return tx.QueryRow(ctx, `
+		UPDATE transfers SET status = $2, received_at = NOW()
+		WHERE id = $1
+		RETURNING received_at
+	`, transfer.ID, transfer.Status).Scan(&transfer.ReceivedAt)
+}
+
+// lockInventoryRows блокирует строки инвентаря нескольких складов в порядке (склад, товар)
+func lockInventoryRows(ctx context.Context, tx pgx.Tx, warehouseIDs []uuid.UUID, items []domain.ProductPurchase) error {
+	productIDs := make([]uuid.UUID, 0, len(items))
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	_, err := tx.Exec(ctx, `
+		SELECT 1 FROM inventory
+		WHERE warehouse_id = ANY($1) AND product_id = ANY($2)
+		ORDER BY warehouse_id, product_id
+		FOR UPDATE
+	`, warehouseIDs, productIDs)
+	return err
+}
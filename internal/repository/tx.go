@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	// maxTxAttempts ограничивает количество попыток выполнения транзакции
+	maxTxAttempts = 5
+	// txRetryBaseDelay базовая задержка между повторными попытками
+	txRetryBaseDelay = 10 * time.Millisecond
+)
+
+// Коды ошибок PostgreSQL, после которых транзакцию безопасно повторить
+const (
+	pgSerializationFailure = "40001"
+	pgDeadlockDetected     = "40P01"
+)
+
+// withTx выполняет fn в транзакции и повторяет ее при ошибках сериализации и взаимных блокировках
+func withTx(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	var err error
+	for attempt := 1; attempt <= maxTxAttempts; attempt++ {
+		err = runTx(ctx, pool, fn)
+		if err == nil || !isRetryable(err) {
+			return err
+		}
+
+		// Линейная задержка перед следующей попыткой
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Duration(attempt) * txRetryBaseDelay):
+		}
+	}
+	return err
+}
+
+// runTx выполняет одну попытку транзакции
+func runTx(ctx context.Context, pool *pgxpool.Pool, fn func(tx pgx.Tx) error) error {
+	tx, err := pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// isRetryable проверяет, можно ли повторить транзакцию после ошибки
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == pgSerializationFailure || pgErr.Code == pgDeadlockDetected
+	}
+	return false
+}
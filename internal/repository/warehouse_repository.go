@@ -5,6 +5,7 @@ import (
 
 	"github.com/danya1733/practiceGO/internal/domain"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -18,7 +19,7 @@ func NewWarehouseRepository(pool *pgxpool.Pool) *WarehouseRepository {
 	return &WarehouseRepository{pool: pool}
 }
 
-// Create создает новый склад
+// Create создает новый склад и записывает создание в журнал аудита
 func (r *WarehouseRepository) Create(ctx context.Context, warehouse domain.Warehouse) (domain.Warehouse, error) {
 	query := `
 		INSERT INTO warehouses (id, address)
@@ -30,7 +31,12 @@ func (r *WarehouseRepository) Create(ctx context.Context, warehouse domain.Wareh
 		warehouse.ID = uuid.New()
 	}
 
-	err := r.pool.QueryRow(ctx, query, warehouse.ID, warehouse.Address).Scan(&warehouse.ID, &warehouse.Address)
+	err := withTx(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, query, warehouse.ID, warehouse.Address).Scan(&warehouse.ID, &warehouse.Address); err != nil {
+			return err
+		}
+		return insertAudit(ctx, tx, EntityWarehouse, warehouse.ID, domain.AuditCreate, nil, warehouse)
+	})
 	if err != nil {
 		return domain.Warehouse{}, err
 	}
@@ -79,7 +85,7 @@ func (r *WarehouseRepository) GetByID(ctx context.Context, id uuid.UUID) (domain
 	var warehouse domain.Warehouse
 	err := r.pool.QueryRow(ctx, query, id).Scan(&warehouse.ID, &warehouse.Address)
 	if err != nil {
-		return domain.Warehouse{}, err
+		return domain.Warehouse{}, mapError(err, EntityWarehouse)
 	}
 
 	return warehouse, nil
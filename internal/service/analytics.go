@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+// AnalyticsService предоставляет аналитику продаж
+type AnalyticsService struct {
+	analytics AnalyticsRepository
+}
+
+// NewAnalyticsService создает новый сервис аналитики
+func NewAnalyticsService(analytics AnalyticsRepository) *AnalyticsService {
+	return &AnalyticsService{analytics: analytics}
+}
+
+// WarehouseAnalytics возвращает продажи склада по товарам и общую выручку
+func (s *AnalyticsService) WarehouseAnalytics(ctx context.Context, warehouseID uuid.UUID) ([]domain.Analytics, float64, error) {
+	return s.analytics.GetWarehouseAnalytics(ctx, warehouseID)
+}
+
+// TopWarehouses возвращает склады с наибольшей выручкой за все время или за период
+func (s *AnalyticsService) TopWarehouses(ctx context.Context, limit int, from, to *time.Time) ([]domain.WarehouseAnalytics, error) {
+	return s.analytics.GetTopWarehouses(ctx, limit, from, to)
+}
+
+// SalesSeries возвращает временной ряд продаж
+func (s *AnalyticsService) SalesSeries(ctx context.Context, q domain.SalesQuery) (domain.SalesSeries, error) {
+	return s.analytics.GetSalesSeries(ctx, q)
+}
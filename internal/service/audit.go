@@ -0,0 +1,31 @@
+package service
+
+import (
+	"context"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+// AuditService читает журнал аудита изменений. Записи в журнал добавляют
+// репозитории в той же транзакции, что и изменение, поэтому изменение
+// без записи в журнал (и запись без изменения) невозможно
+type AuditService struct {
+	entries AuditRepository
+}
+
+// NewAuditService создает новый сервис журнала аудита
+func NewAuditService(entries AuditRepository) *AuditService {
+	return &AuditService{entries: entries}
+}
+
+// List возвращает записи журнала аудита по фильтру
+func (s *AuditService) List(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error) {
+	entries, err := s.entries.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if entries == nil {
+		entries = []domain.AuditEntry{}
+	}
+	return entries, nil
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/danya1733/practiceGO/pkg/requestctx"
+	"github.com/google/uuid"
+)
+
+// auditEntries возвращает записи журнала аудита сущности
+func auditEntries(t *testing.T, s testServices, entity string, id uuid.UUID) []domain.AuditEntry {
+	t.Helper()
+
+	entries, err := s.storage.audit.GetAll(context.Background(), domain.AuditFilter{
+		Entity:   entity,
+		EntityID: &id,
+		Page:     1,
+		Limit:    100,
+	})
+	if err != nil {
+		t.Fatalf("чтение журнала аудита: %v", err)
+	}
+	return entries
+}
+
+// decodeState разбирает состояние сущности из записи журнала аудита
+func decodeState(t *testing.T, state json.RawMessage) domain.Inventory {
+	t.Helper()
+
+	var inventory domain.Inventory
+	if err := json.Unmarshal(state, &inventory); err != nil {
+		t.Fatalf("разбор состояния %s: %v", state, err)
+	}
+	return inventory
+}
+
+func TestAuditRecordedWithChange(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s testServices) {
+		ctx := requestctx.WithRequestID(requestctx.WithActor(context.Background(), "manager"), "req-1")
+		warehouse := seedWarehouse(t, s)
+		product := seedProduct(t, s)
+		seedInventory(t, s, warehouse, product, 5, 100)
+
+		created, err := s.storage.inventory.GetByWarehouseAndProduct(ctx, warehouse.ID, product.ID)
+		if err != nil {
+			t.Fatalf("получение остатка: %v", err)
+		}
+
+		updated, err := s.inventory.UpdateDiscount(ctx, warehouse.ID, product.ID, 10, created.Version)
+		if err != nil {
+			t.Fatalf("изменение скидки: %v", err)
+		}
+
+		entries := auditEntries(t, s, repository.EntityInventory, updated.ID)
+		if len(entries) != 2 {
+			t.Fatalf("записей в журнале %d, ожидалось 2 (создание и изменение)", len(entries))
+		}
+
+		// Записи идут от последних к первым
+		entry := entries[0]
+		if entry.Action != domain.AuditUpdate || entry.Actor != "manager" || entry.RequestID != "req-1" {
+			t.Errorf("действие %s, клиент %q, запрос %q; ожидалось update, manager и req-1",
+				entry.Action, entry.Actor, entry.RequestID)
+		}
+		before, after := decodeState(t, entry.Before), decodeState(t, entry.After)
+		if before.Discount != 0 || before.Version != created.Version {
+			t.Errorf("состояние до изменения: скидка %v, версия %d; ожидалось 0 и %d", before.Discount, before.Version, created.Version)
+		}
+		if after.Discount != 10 || after.Version != updated.Version {
+			t.Errorf("состояние после изменения: скидка %v, версия %d; ожидалось 10 и %d", after.Discount, after.Version, updated.Version)
+		}
+
+		// Изменения вне HTTP запроса выполняются от имени system
+		if entries[1].Actor != "system" {
+			t.Errorf("клиент записи о создании %q, ожидался system", entries[1].Actor)
+		}
+	})
+}
+
+func TestAuditNotRecordedForRejectedChange(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s testServices) {
+		ctx := context.Background()
+		warehouse := seedWarehouse(t, s)
+		product := seedProduct(t, s)
+		seedInventory(t, s, warehouse, product, 5, 100)
+
+		inventory, err := s.storage.inventory.GetByWarehouseAndProduct(ctx, warehouse.ID, product.ID)
+		if err != nil {
+			t.Fatalf("получение остатка: %v", err)
+		}
+
+		_, err = s.inventory.UpdateDiscount(ctx, warehouse.ID, product.ID, 10, inventory.Version+1)
+		if !errors.Is(err, repository.ErrVersionMismatch) {
+			t.Fatalf("ожидалась ErrVersionMismatch, получено %v", err)
+		}
+		_, err = s.inventory.UpdateQuantity(ctx, warehouse.ID, product.ID, -6, 0, domain.MovementInfo{})
+		var stockErr *repository.InsufficientStockError
+		if !errors.As(err, &stockErr) {
+			t.Fatalf("ожидалась InsufficientStockError, получено %v", err)
+		}
+
+		// Отклоненные изменения откатываются вместе с записями журнала
+		if entries := auditEntries(t, s, repository.EntityInventory, inventory.ID); len(entries) != 1 {
+			t.Errorf("записей в журнале %d, ожидалась 1 (создание)", len(entries))
+		}
+	})
+}
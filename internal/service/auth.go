@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/auth"
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/google/uuid"
+)
+
+// anonymous субъект запросов при отключенной аутентификации
+const anonymous = "anonymous"
+
+// AuthService аутентифицирует клиентов и управляет API ключами
+type AuthService struct {
+	users    AuthRepository
+	verifier *auth.Verifier
+	enabled  bool
+}
+
+// NewAuthService создает новый сервис аутентификации.
+// Если enabled == false, все запросы выполняются от имени анонимного клиента
+func NewAuthService(users AuthRepository, verifier *auth.Verifier, enabled bool) *AuthService {
+	return &AuthService{
+		users:    users,
+		verifier: verifier,
+		enabled:  enabled,
+	}
+}
+
+// Enabled сообщает, требуется ли аутентификация
+func (s *AuthService) Enabled() bool {
+	return s.enabled
+}
+
+// Anonymous возвращает клиента, от имени которого выполняются запросы при отключенной аутентификации.
+// Такой клиент имеет роль администратора, чтобы API работало так же, как без проверки прав
+func (s *AuthService) Anonymous() domain.Principal {
+	return domain.Principal{Subject: anonymous, Method: domain.AuthMethodNone, Role: domain.RoleAdmin}
+}
+
+// Authenticate проверяет API ключ или JWT и возвращает клиента.
+// Строки с префиксом API ключа проверяются как API ключи, остальные - как JWT
+func (s *AuthService) Authenticate(ctx context.Context, credential string) (domain.Principal, error) {
+	if credential == "" {
+		return domain.Principal{}, fmt.Errorf("%w: учетные данные не переданы", ErrUnauthenticated)
+	}
+
+	if auth.IsAPIKey(credential) {
+		return s.authenticateAPIKey(ctx, credential)
+	}
+
+	claims, err := s.verifier.Verify(credential)
+	if err != nil {
+		return domain.Principal{}, fmt.Errorf("%w: %v", ErrUnauthenticated, err)
+	}
+
+	return domain.Principal{
+		Subject:    claims.Subject,
+		Method:     domain.AuthMethodJWT,
+		Role:       claims.Role,
+		Warehouses: claims.Warehouses,
+	}, nil
+}
+
+// authenticateAPIKey находит API ключ по хешу и проверяет, что он не отозван и не истек
+func (s *AuthService) authenticateAPIKey(ctx context.Context, credential string) (domain.Principal, error) {
+	key, user, err := s.users.GetAPIKeyByHash(ctx, auth.HashAPIKey(credential))
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return domain.Principal{}, fmt.Errorf("%w: неизвестный API ключ", ErrUnauthenticated)
+		}
+		return domain.Principal{}, err
+	}
+
+	if key.RevokedAt != nil {
+		return domain.Principal{}, fmt.Errorf("%w: API ключ %s отозван", ErrUnauthenticated, key.ID)
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(time.Now()) {
+		return domain.Principal{}, fmt.Errorf("%w: срок действия API ключа %s истек", ErrUnauthenticated, key.ID)
+	}
+
+	return domain.Principal{
+		Subject:    user.Username,
+		Method:     domain.AuthMethodAPIKey,
+		Role:       user.Role,
+		Warehouses: user.Warehouses,
+		APIKeyID:   &key.ID,
+	}, nil
+}
+
+// IssueAPIKey выпускает API ключ для клиента.
+// Пользователь создается при первом выпуске ключа; ключ получает роль и склады клиента
+func (s *AuthService) IssueAPIKey(ctx context.Context, principal domain.Principal, request domain.APIKeyRequest) (domain.IssuedAPIKey, error) {
+	user, err := s.ensureUser(ctx, principal)
+	if err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+
+	key, prefix, hash, err := auth.GenerateAPIKey()
+	if err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+
+	apiKey := domain.APIKey{
+		UserID: user.ID,
+		Name:   request.Name,
+		Prefix: prefix,
+	}
+	if request.ExpiresIn > 0 {
+		expiresAt := time.Now().Add(time.Duration(request.ExpiresIn) * time.Second).UTC()
+		apiKey.ExpiresAt = &expiresAt
+	}
+
+	apiKey, err = s.users.CreateAPIKey(ctx, apiKey, hash)
+	if err != nil {
+		return domain.IssuedAPIKey{}, err
+	}
+
+	return domain.IssuedAPIKey{APIKey: apiKey, Key: key}, nil
+}
+
+// ListAPIKeys возвращает API ключи клиента
+func (s *AuthService) ListAPIKeys(ctx context.Context, principal domain.Principal) ([]domain.APIKey, error) {
+	user, err := s.ensureUser(ctx, principal)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := s.users.ListAPIKeys(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []domain.APIKey{}
+	}
+
+	return keys, nil
+}
+
+// RevokeAPIKey отзывает API ключ клиента. Чужие ключи считаются несуществующими
+func (s *AuthService) RevokeAPIKey(ctx context.Context, principal domain.Principal, id uuid.UUID) (domain.APIKey, error) {
+	user, err := s.ensureUser(ctx, principal)
+	if err != nil {
+		return domain.APIKey{}, err
+	}
+
+	return s.users.RevokeAPIKey(ctx, id, user.ID)
+}
+
+// ensureUser сохраняет пользователя, от имени которого действует клиент.
+// При отключенной аутентификации все запросы анонимны, поэтому пользователь
+// не сохраняется, а работа с API ключами запрещена
+func (s *AuthService) ensureUser(ctx context.Context, principal domain.Principal) (domain.User, error) {
+	if !s.enabled {
+		return domain.User{}, ErrAuthDisabled
+	}
+	return s.users.EnsureUser(ctx, userOf(principal))
+}
+
+// userOf описывает пользователя, от имени которого действует клиент
+func userOf(principal domain.Principal) domain.User {
+	return domain.User{
+		Username:   principal.Subject,
+		Role:       principal.Role,
+		Warehouses: principal.Warehouses,
+	}
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/auth"
+	"github.com/danya1733/practiceGO/internal/config"
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/danya1733/practiceGO/internal/repository/memory"
+	"github.com/google/uuid"
+)
+
+// newTestAuthService создает сервис аутентификации с API ключами в памяти
+func newTestAuthService(t *testing.T, enabled bool) *AuthService {
+	t.Helper()
+
+	verifier, err := auth.NewVerifier(config.AuthConfig{JWTAlgorithm: config.JWTAlgorithmHS256, JWTSecret: "test-secret"})
+	if err != nil {
+		t.Fatalf("создание проверки JWT: %v", err)
+	}
+	return NewAuthService(memory.NewAuthRepository(memory.NewStore()), verifier, enabled)
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	ctx := context.Background()
+	s := newTestAuthService(t, true)
+	warehouse := uuid.New()
+	owner := domain.Principal{Subject: "alice", Role: domain.RoleClerk, Warehouses: []uuid.UUID{warehouse}}
+
+	issued, err := s.IssueAPIKey(ctx, owner, domain.APIKeyRequest{Name: "ci"})
+	if err != nil {
+		t.Fatalf("выпуск API ключа: %v", err)
+	}
+	if !auth.IsAPIKey(issued.Key) || issued.Prefix != issued.Key[:len(issued.Prefix)] {
+		t.Errorf("ключ %q с префиксом %q не похож на API ключ", issued.Key, issued.Prefix)
+	}
+
+	// Ключ находится по хешу и действует с ролью и складами владельца
+	principal, err := s.Authenticate(ctx, issued.Key)
+	if err != nil {
+		t.Fatalf("аутентификация по API ключу: %v", err)
+	}
+	if principal.Subject != "alice" || principal.Method != domain.AuthMethodAPIKey || principal.Role != domain.RoleClerk {
+		t.Errorf("клиент %+v, ожидался alice с API ключом и ролью clerk", principal)
+	}
+	if len(principal.Warehouses) != 1 || principal.Warehouses[0] != warehouse {
+		t.Errorf("склады %v, ожидался %s", principal.Warehouses, warehouse)
+	}
+	if principal.APIKeyID == nil || *principal.APIKeyID != issued.ID {
+		t.Errorf("ID ключа %v, ожидался %s", principal.APIKeyID, issued.ID)
+	}
+
+	// Неизвестный ключ с тем же префиксом не подходит
+	if _, err := s.Authenticate(ctx, issued.Key+"x"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("неизвестный ключ: ожидалась ErrUnauthenticated, получено %v", err)
+	}
+
+	if _, err := s.RevokeAPIKey(ctx, owner, issued.ID); err != nil {
+		t.Fatalf("отзыв API ключа: %v", err)
+	}
+	if _, err := s.Authenticate(ctx, issued.Key); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("отозванный ключ: ожидалась ErrUnauthenticated, получено %v", err)
+	}
+}
+
+func TestRevokeForeignAPIKey(t *testing.T) {
+	ctx := context.Background()
+	s := newTestAuthService(t, true)
+
+	issued, err := s.IssueAPIKey(ctx, domain.Principal{Subject: "alice", Role: domain.RoleAdmin}, domain.APIKeyRequest{Name: "ci"})
+	if err != nil {
+		t.Fatalf("выпуск API ключа: %v", err)
+	}
+
+	// Чужой ключ считается несуществующим
+	_, err = s.RevokeAPIKey(ctx, domain.Principal{Subject: "bob", Role: domain.RoleAdmin}, issued.ID)
+	if !errors.Is(err, repository.ErrNotFound) {
+		t.Errorf("ожидалась ErrNotFound, получено %v", err)
+	}
+	if _, err := s.Authenticate(ctx, issued.Key); err != nil {
+		t.Errorf("ключ перестал действовать после отзыва чужим клиентом: %v", err)
+	}
+}
+
+func TestAPIKeysRequireAuthentication(t *testing.T) {
+	ctx := context.Background()
+	s := newTestAuthService(t, false)
+	anonymous := s.Anonymous()
+
+	if _, err := s.IssueAPIKey(ctx, anonymous, domain.APIKeyRequest{Name: "ci"}); !errors.Is(err, ErrAuthDisabled) {
+		t.Errorf("выпуск: ожидалась ErrAuthDisabled, получено %v", err)
+	}
+	if _, err := s.ListAPIKeys(ctx, anonymous); !errors.Is(err, ErrAuthDisabled) {
+		t.Errorf("список: ожидалась ErrAuthDisabled, получено %v", err)
+	}
+	if _, err := s.RevokeAPIKey(ctx, anonymous, uuid.New()); !errors.Is(err, ErrAuthDisabled) {
+		t.Errorf("отзыв: ожидалась ErrAuthDisabled, получено %v", err)
+	}
+}
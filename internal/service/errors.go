@@ -0,0 +1,25 @@
+package service
+
+import "errors"
+
+// ErrUnauthenticated возвращается, если учетные данные отсутствуют или недействительны
+var ErrUnauthenticated = errors.New("требуется аутентификация")
+
+// ErrAuthDisabled возвращается при работе с API ключами, если аутентификация отключена:
+// анонимный клиент не может владеть ключами
+var ErrAuthDisabled = errors.New("аутентификация отключена, API ключи недоступны")
+
+// ValidationError возвращается, если запрос нарушает бизнес-правило
+type ValidationError struct {
+	Message string
+}
+
+// Error реализует интерфейс error
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// invalid создает ошибку нарушения бизнес-правила
+func invalid(message string) error {
+	return &ValidationError{Message: message}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+// healthCheckTimeout ограничивает время проверок готовности, чтобы зависшая база данных
+// не задерживала ответ балансировщику дольше его собственного таймаута
+const healthCheckTimeout = 2 * time.Second
+
+// HealthService отвечает на проверки живости и готовности сервиса
+type HealthService struct {
+	storage  HealthRepository
+	draining atomic.Bool
+}
+
+// NewHealthService создает новый сервис проверок состояния
+func NewHealthService(storage HealthRepository) *HealthService {
+	return &HealthService{storage: storage}
+}
+
+// Drain переводит сервис в состояние остановки: проверка готовности начинает
+// возвращать not_ready, чтобы балансировщик перестал направлять новые запросы
+func (s *HealthService) Drain() {
+	s.draining.Store(true)
+}
+
+// Ready проверяет готовность сервиса принимать запросы
+func (s *HealthService) Ready(ctx context.Context) domain.Readiness {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	shutdown := domain.HealthCheck{Name: "shutdown", Status: domain.HealthOK}
+	if s.draining.Load() {
+		shutdown.Status, shutdown.Error = domain.HealthFail, "сервис останавливается"
+	}
+
+	readiness := domain.Readiness{
+		Status: domain.Ready,
+		Checks: append([]domain.HealthCheck{shutdown}, s.storage.Checks(ctx)...),
+	}
+	for _, check := range readiness.Checks {
+		if check.Status != domain.HealthOK {
+			readiness.Status = domain.NotReady
+		}
+	}
+
+	return readiness
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+// ErrIdempotencyKeyMismatch возвращается, если ключ идемпотентности уже использован для другого запроса
+var ErrIdempotencyKeyMismatch = errors.New("ключ идемпотентности уже использован для другого запроса")
+
+// ErrIdempotencyInProgress возвращается, если запрос с тем же ключом идемпотентности еще обрабатывается
+var ErrIdempotencyInProgress = errors.New("запрос с этим ключом идемпотентности еще обрабатывается")
+
+// IdempotencyService хранит ответы на запросы с заголовком Idempotency-Key,
+// чтобы повтор запроса не выполнял операцию второй раз
+type IdempotencyService struct {
+	repo IdempotencyRepository
+	ttl  time.Duration
+}
+
+// NewIdempotencyService создает новый сервис ключей идемпотентности.
+// Ответ хранится ttl с момента первого запроса
+func NewIdempotencyService(repo IdempotencyRepository, ttl time.Duration) *IdempotencyService {
+	return &IdempotencyService{repo: repo, ttl: ttl}
+}
+
+// Begin занимает ключ клиента subject для запроса с хешем requestHash.
+// Если ключ уже использован для того же запроса и ответ сохранен, возвращается запись
+// с сохраненным ответом и true: операцию выполнять не нужно. Если ключ свободен,
+// возвращается false, и после выполнения операции нужно вызвать Complete или Release
+func (s *IdempotencyService) Begin(ctx context.Context, subject, key, requestHash string) (domain.IdempotencyRecord, bool, error) {
+	record, reserved, err := s.repo.Reserve(ctx, domain.IdempotencyRecord{
+		Subject:     subject,
+		Key:         key,
+		RequestHash: requestHash,
+		ExpiresAt:   time.Now().Add(s.ttl),
+	})
+	if err != nil {
+		return domain.IdempotencyRecord{}, false, err
+	}
+	if reserved {
+		return record, false, nil
+	}
+
+	if record.RequestHash != requestHash {
+		return domain.IdempotencyRecord{}, false, ErrIdempotencyKeyMismatch
+	}
+	if !record.Completed() {
+		return domain.IdempotencyRecord{}, false, ErrIdempotencyInProgress
+	}
+
+	return record, true, nil
+}
+
+// Complete сохраняет ответ на запрос с занятым ключом: статус, тело и заголовки
+func (s *IdempotencyService) Complete(ctx context.Context, subject, key string, status int, response []byte, headers map[string]string) error {
+	return s.repo.Complete(ctx, domain.IdempotencyRecord{
+		Subject:  subject,
+		Key:      key,
+		Status:   status,
+		Response: response,
+		Headers:  headers,
+	})
+}
+
+// Release освобождает ключ, если запрос не удалось выполнить, чтобы клиент мог его повторить
+func (s *IdempotencyService) Release(ctx context.Context, subject, key string) error {
+	return s.repo.Release(ctx, subject, key)
+}
+
+// DeleteExpired удаляет истекшие ключи и возвращает их количество
+func (s *IdempotencyService) DeleteExpired(ctx context.Context) (int, error) {
+	return s.repo.DeleteExpired(ctx, time.Now())
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+// InventoryService управляет остатками товаров на складах
+type InventoryService struct {
+	inventory InventoryRepository
+	products  ProductRepository
+	movements StockMovementRepository
+}
+
+// NewInventoryService создает новый сервис остатков
+func NewInventoryService(inventory InventoryRepository, products ProductRepository, movements StockMovementRepository) *InventoryService {
+	return &InventoryService{
+		inventory: inventory,
+		products:  products,
+		movements: movements,
+	}
+}
+
+// Create добавляет товар на склад; начальное количество записывается в журнал как поступление
+func (s *InventoryService) Create(ctx context.Context, inventory domain.Inventory, info domain.MovementInfo) (domain.Inventory, error) {
+	info.Type = domain.MovementReceipt
+	return s.inventory.Create(ctx, inventory, info)
+}
+
+// UpdateQuantity изменяет количество товара на складе на delta, если текущая версия остатка
+// равна version; версия 0 означает изменение без проверки. Тип движения по умолчанию - корректировка
+func (s *InventoryService) UpdateQuantity(ctx context.Context, warehouseID, productID uuid.UUID, delta, version int, info domain.MovementInfo) (domain.Inventory, error) {
+	if info.Type == "" {
+		info.Type = domain.MovementAdjustment
+	}
+	if err := validateManualMovement(info.Type, delta); err != nil {
+		return domain.Inventory{}, err
+	}
+
+	return s.inventory.UpdateQuantity(ctx, warehouseID, productID, delta, version, info)
+}
+
+// UpdateDiscount устанавливает скидку на товар на складе, если текущая версия остатка
+// равна version; версия 0 означает изменение без проверки
+func (s *InventoryService) UpdateDiscount(ctx context.Context, warehouseID, productID uuid.UUID, discount float64, version int) (domain.Inventory, error) {
+	return s.inventory.UpdateDiscount(ctx, warehouseID, productID, discount, version)
+}
+
+// ListByWarehouse возвращает страницу товаров на складе
+func (s *InventoryService) ListByWarehouse(ctx context.Context, warehouseID uuid.UUID, filter domain.WarehouseProductFilter) ([]domain.InventoryWithProduct, error) {
+	return s.inventory.GetProductsByWarehouse(ctx, warehouseID, filter)
+}
+
+// Get возвращает остаток товара на складе вместе с информацией о товаре
+func (s *InventoryService) Get(ctx context.Context, warehouseID, productID uuid.UUID) (domain.InventoryWithProduct, error) {
+	inventory, err := s.inventory.GetByWarehouseAndProduct(ctx, warehouseID, productID)
+	if err != nil {
+		return domain.InventoryWithProduct{}, err
+	}
+
+	product, err := s.products.GetByID(ctx, productID)
+	if err != nil {
+		return domain.InventoryWithProduct{}, err
+	}
+
+	return domain.InventoryWithProduct{
+		Inventory: inventory,
+		Product:   product,
+	}, nil
+}
+
+// Movements возвращает журнал движения товара на складе
+func (s *InventoryService) Movements(ctx context.Context, warehouseID, productID uuid.UUID, filter domain.MovementFilter) ([]domain.StockMovement, error) {
+	movements, err := s.movements.GetByWarehouseAndProduct(ctx, warehouseID, productID, filter)
+	if err != nil {
+		return nil, err
+	}
+	if movements == nil {
+		movements = []domain.StockMovement{}
+	}
+	return movements, nil
+}
+
+// RebuildFromLedger сверяет остатки с журналом движения; без dryRun расхождения исправляются,
+// кроме конфликтов, в которых значение из журнала меньше резерва
+func (s *InventoryService) RebuildFromLedger(ctx context.Context, warehouseID *uuid.UUID, dryRun bool) ([]domain.LedgerDiscrepancy, error) {
+	discrepancies, err := s.inventory.RebuildFromLedger(ctx, warehouseID, dryRun)
+	if err != nil {
+		return nil, err
+	}
+	if discrepancies == nil {
+		discrepancies = []domain.LedgerDiscrepancy{}
+	}
+	return discrepancies, nil
+}
+
+// validateManualMovement проверяет тип и знак изменения остатка, заданного вручную.
+// Продажи и перемещения создаются только соответствующими операциями
+func validateManualMovement(t domain.MovementType, delta int) error {
+	switch t {
+	case domain.MovementReceipt, domain.MovementReturn:
+		if delta <= 0 {
+			return invalid("Количество для поступления или возврата должно быть положительным")
+		}
+	case domain.MovementWriteOff:
+		if delta >= 0 {
+			return invalid("Количество для списания должно быть отрицательным")
+		}
+	case domain.MovementAdjustment:
+		if delta == 0 {
+			return invalid("Количество для корректировки не может быть нулевым")
+		}
+	default:
+		return invalid("Недопустимый тип движения: " + string(t))
+	}
+	return nil
+}
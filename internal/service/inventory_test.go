@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+)
+
+func TestUpdateQuantityBelowReserved(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s testServices) {
+		ctx := context.Background()
+		warehouse := seedWarehouse(t, s)
+		product := seedProduct(t, s)
+		seedInventory(t, s, warehouse, product, 5, 100)
+
+		_, err := s.sales.Calculate(ctx, domain.PurchaseRequest{
+			WarehouseID: warehouse.ID,
+			Products:    []domain.ProductPurchase{{ProductID: product.ID, Quantity: 3}},
+			Reserve:     true,
+		})
+		if err != nil {
+			t.Fatalf("расчет с резервом: %v", err)
+		}
+
+		_, err = s.inventory.UpdateQuantity(ctx, warehouse.ID, product.ID, -3, 0, domain.MovementInfo{})
+		var stockErr *repository.InsufficientStockError
+		if !errors.As(err, &stockErr) {
+			t.Fatalf("ожидалась InsufficientStockError, получено %v", err)
+		}
+		if stockErr.Available != 2 || stockErr.Requested != 3 {
+			t.Errorf("доступно %d, запрошено %d; ожидалось 2 и 3", stockErr.Available, stockErr.Requested)
+		}
+		assertStock(t, s, warehouse.ID, product.ID, 5, 3)
+
+		// Списать можно только незарезервированный остаток
+		updated, err := s.inventory.UpdateQuantity(ctx, warehouse.ID, product.ID, -2, 0, domain.MovementInfo{})
+		if err != nil {
+			t.Fatalf("списание доступного остатка: %v", err)
+		}
+		if updated.Quantity != 3 || updated.Available != 0 {
+			t.Errorf("остаток %d, доступно %d; ожидалось 3 и 0", updated.Quantity, updated.Available)
+		}
+	})
+}
+
+func TestUpdateQuantityVersionMismatch(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s testServices) {
+		ctx := context.Background()
+		warehouse := seedWarehouse(t, s)
+		product := seedProduct(t, s)
+		seedInventory(t, s, warehouse, product, 5, 100)
+
+		inventory, err := s.storage.inventory.GetByWarehouseAndProduct(ctx, warehouse.ID, product.ID)
+		if err != nil {
+			t.Fatalf("получение остатка: %v", err)
+		}
+
+		_, err = s.inventory.UpdateQuantity(ctx, warehouse.ID, product.ID, -1, inventory.Version+1, domain.MovementInfo{})
+		if !errors.Is(err, repository.ErrVersionMismatch) {
+			t.Fatalf("ожидалась ErrVersionMismatch, получено %v", err)
+		}
+		assertStock(t, s, warehouse.ID, product.ID, 5, 0)
+	})
+}
@@ -0,0 +1,7 @@
+package service
+
+// PriceWithDiscount возвращает цену единицы товара с учетом скидки в процентах.
+// Используется и при расчете стоимости, и при покупке, поэтому суммы совпадают
+func PriceWithDiscount(price, discount float64) float64 {
+	return price * (1 - discount/100)
+}
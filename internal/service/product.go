@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+// ProductService управляет каталогом товаров
+type ProductService struct {
+	products ProductRepository
+}
+
+// NewProductService создает новый сервис товаров
+func NewProductService(products ProductRepository) *ProductService {
+	return &ProductService{products: products}
+}
+
+// List возвращает страницу каталога товаров по фильтру и позицию следующей страницы;
+// позиция равна nil, если страница последняя
+func (s *ProductService) List(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, *domain.ProductCursor, error) {
+	limit := filter.Limit
+	// Лишний товар показывает, что за страницей есть еще товары
+	filter.Limit++
+	products, err := s.products.GetAll(ctx, filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(products) <= limit {
+		return products, nil, nil
+	}
+
+	products = products[:limit]
+	last := products[limit-1]
+	next := &domain.ProductCursor{Sort: filter.Sort, ID: last.ID}
+	switch filter.Sort {
+	case domain.ProductSortWeight, domain.ProductSortWeightDesc:
+		next.Weight = last.Weight
+	case domain.ProductSortBarcode, domain.ProductSortBarcodeDesc:
+		next.Barcode = last.Barcode
+	default:
+		next.Name = last.Name
+	}
+
+	return products, next, nil
+}
+
+// Create создает товар
+func (s *ProductService) Create(ctx context.Context, product domain.Product) (domain.Product, error) {
+	return s.products.Create(ctx, product)
+}
+
+// Get возвращает товар по ID
+func (s *ProductService) Get(ctx context.Context, id uuid.UUID) (domain.Product, error) {
+	return s.products.GetByID(ctx, id)
+}
+
+// Update обновляет товар с ID product.ID, если его текущая версия равна version.
+// Версия 0 означает обновление без проверки
+func (s *ProductService) Update(ctx context.Context, product domain.Product, version int) (domain.Product, error) {
+	return s.products.Update(ctx, product, version)
+}
+
+// Facets возвращает характеристики товаров, подходящих под фильтр, с числом товаров
+// для не больше limit самых частых значений каждой характеристики
+func (s *ProductService) Facets(ctx context.Context, filter domain.ProductFilter, limit int) ([]domain.AttributeFacet, error) {
+	return s.products.GetAttributeFacets(ctx, filter, limit)
+}
@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+// WarehouseRepository хранилище складов
+type WarehouseRepository interface {
+	Create(ctx context.Context, warehouse domain.Warehouse) (domain.Warehouse, error)
+	GetAll(ctx context.Context) ([]domain.Warehouse, error)
+	GetByID(ctx context.Context, id uuid.UUID) (domain.Warehouse, error)
+}
+
+// ProductRepository хранилище товаров
+type ProductRepository interface {
+	Create(ctx context.Context, product domain.Product) (domain.Product, error)
+	// GetAll возвращает не более filter.Limit товаров по фильтру в порядке filter.Sort
+	GetAll(ctx context.Context, filter domain.ProductFilter) ([]domain.Product, error)
+	GetByID(ctx context.Context, id uuid.UUID) (domain.Product, error)
+	// GetAttributeFacets возвращает характеристики товаров по фильтру и не больше limit самых частых значений каждой
+	GetAttributeFacets(ctx context.Context, filter domain.ProductFilter, limit int) ([]domain.AttributeFacet, error)
+	// Update обновляет товар; если version не 0, только при совпадении текущей версии
+	Update(ctx context.Context, product domain.Product, version int) (domain.Product, error)
+}
+
+// InventoryRepository хранилище остатков товаров на складах
+type InventoryRepository interface {
+	Create(ctx context.Context, inventory domain.Inventory, info domain.MovementInfo) (domain.Inventory, error)
+	GetByWarehouseAndProduct(ctx context.Context, warehouseID, productID uuid.UUID) (domain.Inventory, error)
+	// UpdateQuantity и UpdateDiscount изменяют остаток; если version не 0, только при совпадении текущей версии
+	UpdateQuantity(ctx context.Context, warehouseID, productID uuid.UUID, quantity, version int, info domain.MovementInfo) (domain.Inventory, error)
+	UpdateDiscount(ctx context.Context, warehouseID, productID uuid.UUID, discount float64, version int) (domain.Inventory, error)
+	GetProductsByWarehouse(ctx context.Context, warehouseID uuid.UUID, filter domain.WarehouseProductFilter) ([]domain.InventoryWithProduct, error)
+	// PurchaseProducts атомарно списывает товары и сохраняет заказ;
+	// цены позиций вычисляются функцией price по зафиксированным в транзакции цене и скидке
+	PurchaseProducts(ctx context.Context, warehouseID uuid.UUID, products []domain.ProductPurchase, reservationID *uuid.UUID, info domain.MovementInfo, price domain.PriceFunc) (domain.Order, error)
+	RebuildFromLedger(ctx context.Context, warehouseID *uuid.UUID, dryRun bool) ([]domain.LedgerDiscrepancy, error)
+}
+
+// AnalyticsRepository хранилище аналитики продаж
+type AnalyticsRepository interface {
+	GetWarehouseAnalytics(ctx context.Context, warehouseID uuid.UUID) ([]domain.Analytics, float64, error)
+	GetTopWarehouses(ctx context.Context, limit int, from, to *time.Time) ([]domain.WarehouseAnalytics, error)
+	GetSalesSeries(ctx context.Context, q domain.SalesQuery) (domain.SalesSeries, error)
+}
+
+// OrderRepository хранилище заказов
+type OrderRepository interface {
+	GetAll(ctx context.Context, filter domain.OrderFilter) ([]domain.Order, error)
+	GetByID(ctx context.Context, id uuid.UUID) (domain.Order, error)
+}
+
+// ReservationRepository хранилище резервов
+type ReservationRepository interface {
+	Create(ctx context.Context, warehouseID uuid.UUID, products []domain.ProductPurchase, ttl time.Duration) (domain.Reservation, error)
+	GetByID(ctx context.Context, id uuid.UUID) (domain.Reservation, error)
+	Release(ctx context.Context, id uuid.UUID) (domain.Reservation, error)
+	ReleaseExpired(ctx context.Context, limit int) (int, error)
+}
+
+// StockMovementRepository журнал движения товаров
+type StockMovementRepository interface {
+	GetByWarehouseAndProduct(ctx context.Context, warehouseID, productID uuid.UUID, filter domain.MovementFilter) ([]domain.StockMovement, error)
+}
+
+// TransferRepository хранилище перемещений между складами
+type TransferRepository interface {
+	Create(ctx context.Context, request domain.TransferRequest, immediate bool, info domain.MovementInfo) (domain.Transfer, error)
+	Dispatch(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error)
+	Receive(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error)
+	Cancel(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error)
+	GetByID(ctx context.Context, id uuid.UUID) (domain.Transfer, error)
+	GetByWarehouse(ctx context.Context, warehouseID uuid.UUID, statuses []domain.TransferStatus) ([]domain.Transfer, error)
+}
+
+// AuthRepository хранилище пользователей и API ключей
+type AuthRepository interface {
+	EnsureUser(ctx context.Context, user domain.User) (domain.User, error)
+	CreateAPIKey(ctx context.Context, key domain.APIKey, hash string) (domain.APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (domain.APIKey, domain.User, error)
+	ListAPIKeys(ctx context.Context, userID uuid.UUID) ([]domain.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id, userID uuid.UUID) (domain.APIKey, error)
+}
+
+// AuditRepository хранилище журнала аудита. Записи добавляют сами репозитории
+// в транзакции изменения, поэтому интерфейс только читает журнал
+type AuditRepository interface {
+	GetAll(ctx context.Context, filter domain.AuditFilter) ([]domain.AuditEntry, error)
+}
+
+// HealthRepository проверки состояния хранилища для готовности сервиса
+type HealthRepository interface {
+	Checks(ctx context.Context) []domain.HealthCheck
+}
+
+// IdempotencyRepository хранилище ключей идемпотентности
+type IdempotencyRepository interface {
+	Reserve(ctx context.Context, record domain.IdempotencyRecord) (domain.IdempotencyRecord, bool, error)
+	Complete(ctx context.Context, record domain.IdempotencyRecord) error
+	Release(ctx context.Context, subject, key string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int, error)
+}
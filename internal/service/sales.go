@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/config"
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/metrics"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/google/uuid"
+)
+
+// SalesService отвечает за расчет стоимости, резервы, покупки и заказы
+type SalesService struct {
+	inventory    InventoryRepository
+	products     ProductRepository
+	reservations ReservationRepository
+	orders       OrderRepository
+	metrics      *metrics.Metrics
+	cfg          config.ReservationConfig
+}
+
+// NewSalesService создает новый сервис продаж
+func NewSalesService(
+	inventory InventoryRepository,
+	products ProductRepository,
+	reservations ReservationRepository,
+	orders OrderRepository,
+	metrics *metrics.Metrics,
+	cfg config.ReservationConfig,
+) *SalesService {
+	return &SalesService{
+		inventory:    inventory,
+		products:     products,
+		reservations: reservations,
+		orders:       orders,
+		metrics:      metrics,
+		cfg:          cfg,
+	}
+}
+
+// Calculate рассчитывает стоимость товаров с учетом скидок и доступного остатка.
+// Если request.Reserve, товары резервируются до покупки
+func (s *SalesService) Calculate(ctx context.Context, request domain.PurchaseRequest) (domain.CalculationResult, error) {
+	result := domain.CalculationResult{
+		Items: make([]domain.CalculationItem, 0, len(request.Products)),
+	}
+
+	for _, p := range request.Products {
+		inventory, err := s.inventory.GetByWarehouseAndProduct(ctx, request.WarehouseID, p.ProductID)
+		if err != nil {
+			if errors.Is(err, repository.ErrNotFound) {
+				return domain.CalculationResult{}, fmt.Errorf("%w: товар с ID %s не найден на складе %s",
+					repository.ErrInventoryNotFound, p.ProductID, request.WarehouseID)
+			}
+			return domain.CalculationResult{}, err
+		}
+
+		product, err := s.products.GetByID(ctx, p.ProductID)
+		if err != nil {
+			return domain.CalculationResult{}, err
+		}
+
+		// Резервы других покупателей недоступны для продажи
+		if inventory.Available < p.Quantity {
+			return domain.CalculationResult{}, &repository.InsufficientStockError{
+				WarehouseID: request.WarehouseID,
+				ProductID:   p.ProductID,
+				Available:   inventory.Available,
+				Requested:   p.Quantity,
+			}
+		}
+
+		priceWithDiscount := PriceWithDiscount(inventory.Price, inventory.Discount)
+		totalPrice := priceWithDiscount * float64(p.Quantity)
+
+		result.Items = append(result.Items, domain.CalculationItem{
+			ProductID:         p.ProductID,
+			Name:              product.Name,
+			Quantity:          p.Quantity,
+			Price:             inventory.Price,
+			PriceWithDiscount: priceWithDiscount,
+			TotalPrice:        totalPrice,
+		})
+		result.TotalSum += totalPrice
+	}
+
+	if request.Reserve {
+		reservation, err := s.reservations.Create(ctx, request.WarehouseID, request.Products, s.reservationTTL(request.ReservationTTL))
+		if err != nil {
+			return domain.CalculationResult{}, err
+		}
+		result.Reservation = &reservation
+	}
+
+	return result, nil
+}
+
+// reservationTTL возвращает время жизни резерва: запрошенное в секундах,
+// но не больше максимального, или значение по умолчанию
+func (s *SalesService) reservationTTL(seconds int) time.Duration {
+	if seconds <= 0 {
+		return s.cfg.DefaultTTL
+	}
+	return min(time.Duration(seconds)*time.Second, s.cfg.MaxTTL)
+}
+
+// Purchase покупает товары и возвращает созданный заказ
+func (s *SalesService) Purchase(ctx context.Context, request domain.PurchaseRequest, info domain.MovementInfo) (domain.Order, error) {
+	order, err := s.inventory.PurchaseProducts(ctx, request.WarehouseID, request.Products, request.ReservationID, info, PriceWithDiscount)
+	if err != nil {
+		s.metrics.ObservePurchaseFailure(purchaseFailureReason(err))
+		return domain.Order{}, err
+	}
+	s.metrics.ObservePurchase(order)
+
+	return order, nil
+}
+
+// purchaseFailureReason возвращает причину неудачной покупки для метрик
+func purchaseFailureReason(err error) string {
+	var stockErr *repository.InsufficientStockError
+	switch {
+	case errors.As(err, &stockErr):
+		return "insufficient_stock"
+	case errors.Is(err, repository.ErrInventoryNotFound):
+		return "inventory_not_found"
+	case errors.Is(err, repository.ErrReservationNotFound):
+		return "reservation_not_found"
+	case errors.Is(err, repository.ErrReservationMismatch):
+		return "reservation_mismatch"
+	case errors.Is(err, repository.ErrReservationNotActive):
+		return "reservation_not_active"
+	case errors.Is(err, repository.ErrNotFound), errors.Is(err, repository.ErrInvalidReference):
+		return "not_found"
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return "canceled"
+	default:
+		return "internal"
+	}
+}
+
+// GetReservation возвращает резерв по ID
+func (s *SalesService) GetReservation(ctx context.Context, id uuid.UUID) (domain.Reservation, error) {
+	return s.reservations.GetByID(ctx, id)
+}
+
+// ReleaseReservation досрочно снимает активный резерв
+func (s *SalesService) ReleaseReservation(ctx context.Context, id uuid.UUID) (domain.Reservation, error) {
+	return s.reservations.Release(ctx, id)
+}
+
+// ReleaseExpiredReservations снимает истекшие резервы пачками по batch штук
+// и возвращает общее количество снятых резервов
+func (s *SalesService) ReleaseExpiredReservations(ctx context.Context, batch int) (int, error) {
+	total := 0
+	for {
+		released, err := s.reservations.ReleaseExpired(ctx, batch)
+		if err != nil {
+			return total, err
+		}
+
+		total += released
+		if released < batch {
+			return total, nil
+		}
+	}
+}
+
+// ListOrders возвращает заказы по фильтру
+func (s *SalesService) ListOrders(ctx context.Context, filter domain.OrderFilter) ([]domain.Order, error) {
+	return s.orders.GetAll(ctx, filter)
+}
+
+// GetOrder возвращает заказ по ID
+func (s *SalesService) GetOrder(ctx context.Context, id uuid.UUID) (domain.Order, error) {
+	return s.orders.GetByID(ctx, id)
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+)
+
+func TestPurchase(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s testServices) {
+		ctx := context.Background()
+		warehouse := seedWarehouse(t, s)
+		product := seedProduct(t, s)
+		seedInventory(t, s, warehouse, product, 5, 100)
+
+		order, err := s.sales.Purchase(ctx, domain.PurchaseRequest{
+			WarehouseID: warehouse.ID,
+			Products:    []domain.ProductPurchase{{ProductID: product.ID, Quantity: 2}},
+		}, domain.MovementInfo{})
+		if err != nil {
+			t.Fatalf("покупка: %v", err)
+		}
+		if len(order.Items) != 1 || order.Items[0].Quantity != 2 {
+			t.Fatalf("неожиданные позиции заказа: %+v", order.Items)
+		}
+		if order.TotalSum != 200 {
+			t.Errorf("сумма заказа %v, ожидалось 200", order.TotalSum)
+		}
+		assertStock(t, s, warehouse.ID, product.ID, 3, 0)
+
+		_, err = s.sales.Purchase(ctx, domain.PurchaseRequest{
+			WarehouseID: warehouse.ID,
+			Products:    []domain.ProductPurchase{{ProductID: product.ID, Quantity: 4}},
+		}, domain.MovementInfo{})
+		var stockErr *repository.InsufficientStockError
+		if !errors.As(err, &stockErr) {
+			t.Fatalf("ожидалась InsufficientStockError, получено %v", err)
+		}
+		if stockErr.Available != 3 || stockErr.Requested != 4 {
+			t.Errorf("доступно %d, запрошено %d; ожидалось 3 и 4", stockErr.Available, stockErr.Requested)
+		}
+		assertStock(t, s, warehouse.ID, product.ID, 3, 0)
+	})
+}
+
+func TestPurchaseWithReservation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s testServices) {
+		ctx := context.Background()
+		warehouse := seedWarehouse(t, s)
+		product := seedProduct(t, s)
+		seedInventory(t, s, warehouse, product, 5, 100)
+
+		result, err := s.sales.Calculate(ctx, domain.PurchaseRequest{
+			WarehouseID: warehouse.ID,
+			Products:    []domain.ProductPurchase{{ProductID: product.ID, Quantity: 3}},
+			Reserve:     true,
+		})
+		if err != nil {
+			t.Fatalf("расчет с резервом: %v", err)
+		}
+		if result.Reservation == nil || result.Reservation.Status != domain.ReservationActive {
+			t.Fatalf("ожидался активный резерв, получено %+v", result.Reservation)
+		}
+		assertStock(t, s, warehouse.ID, product.ID, 5, 3)
+
+		// Зарезервированные единицы недоступны другим покупателям
+		_, err = s.sales.Purchase(ctx, domain.PurchaseRequest{
+			WarehouseID: warehouse.ID,
+			Products:    []domain.ProductPurchase{{ProductID: product.ID, Quantity: 3}},
+		}, domain.MovementInfo{})
+		var stockErr *repository.InsufficientStockError
+		if !errors.As(err, &stockErr) {
+			t.Fatalf("ожидалась InsufficientStockError, получено %v", err)
+		}
+
+		order, err := s.sales.Purchase(ctx, domain.PurchaseRequest{
+			WarehouseID:   warehouse.ID,
+			Products:      []domain.ProductPurchase{{ProductID: product.ID, Quantity: 3}},
+			ReservationID: &result.Reservation.ID,
+		}, domain.MovementInfo{})
+		if err != nil {
+			t.Fatalf("покупка по резерву: %v", err)
+		}
+		if order.TotalSum != 300 {
+			t.Errorf("сумма заказа %v, ожидалось 300", order.TotalSum)
+		}
+		assertStock(t, s, warehouse.ID, product.ID, 2, 0)
+
+		reservation, err := s.sales.GetReservation(ctx, result.Reservation.ID)
+		if err != nil {
+			t.Fatalf("получение резерва: %v", err)
+		}
+		if reservation.Status != domain.ReservationConsumed {
+			t.Errorf("статус резерва %s, ожидался %s", reservation.Status, domain.ReservationConsumed)
+		}
+
+		// Использованный резерв нельзя применить повторно
+		_, err = s.sales.Purchase(ctx, domain.PurchaseRequest{
+			WarehouseID:   warehouse.ID,
+			Products:      []domain.ProductPurchase{{ProductID: product.ID, Quantity: 1}},
+			ReservationID: &result.Reservation.ID,
+		}, domain.MovementInfo{})
+		if !errors.Is(err, repository.ErrReservationNotActive) {
+			t.Errorf("ожидалась ErrReservationNotActive, получено %v", err)
+		}
+	})
+}
+
+func TestReleaseReservation(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s testServices) {
+		ctx := context.Background()
+		warehouse := seedWarehouse(t, s)
+		product := seedProduct(t, s)
+		seedInventory(t, s, warehouse, product, 5, 100)
+
+		result, err := s.sales.Calculate(ctx, domain.PurchaseRequest{
+			WarehouseID: warehouse.ID,
+			Products:    []domain.ProductPurchase{{ProductID: product.ID, Quantity: 4}},
+			Reserve:     true,
+		})
+		if err != nil {
+			t.Fatalf("расчет с резервом: %v", err)
+		}
+		assertStock(t, s, warehouse.ID, product.ID, 5, 4)
+
+		released, err := s.sales.ReleaseReservation(ctx, result.Reservation.ID)
+		if err != nil {
+			t.Fatalf("снятие резерва: %v", err)
+		}
+		if released.Status != domain.ReservationReleased {
+			t.Errorf("статус резерва %s, ожидался %s", released.Status, domain.ReservationReleased)
+		}
+		assertStock(t, s, warehouse.ID, product.ID, 5, 0)
+
+		_, err = s.sales.ReleaseReservation(ctx, result.Reservation.ID)
+		if !errors.Is(err, repository.ErrReservationNotActive) {
+			t.Errorf("ожидалась ErrReservationNotActive, получено %v", err)
+		}
+	})
+}
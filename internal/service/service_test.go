@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/danya1733/practiceGO/internal/config"
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/metrics"
+	"github.com/danya1733/practiceGO/internal/repository"
+	"github.com/danya1733/practiceGO/internal/repository/memory"
+	"github.com/danya1733/practiceGO/internal/testdb"
+	"github.com/google/uuid"
+)
+
+// testStorage набор хранилищ одного бэкенда для тестов сервисов
+type testStorage struct {
+	warehouses   WarehouseRepository
+	products     ProductRepository
+	inventory    InventoryRepository
+	orders       OrderRepository
+	reservations ReservationRepository
+	movements    StockMovementRepository
+	transfers    TransferRepository
+	audit        AuditRepository
+}
+
+// testServices сервисы, собранные поверх хранилищ одного бэкенда
+type testServices struct {
+	storage   testStorage
+	inventory *InventoryService
+	sales     *SalesService
+	transfers *TransferService
+}
+
+// newTestServices собирает сервисы поверх хранилищ
+func newTestServices(storage testStorage) testServices {
+	return testServices{
+		storage:   storage,
+		inventory: NewInventoryService(storage.inventory, storage.products, storage.movements),
+		sales: NewSalesService(storage.inventory, storage.products, storage.reservations, storage.orders, metrics.New(), config.ReservationConfig{
+			DefaultTTL: time.Minute,
+			MaxTTL:     time.Hour,
+		}),
+		transfers: NewTransferService(storage.transfers),
+	}
+}
+
+// memoryStorage создает хранилища в памяти
+func memoryStorage() testStorage {
+	store := memory.NewStore()
+	return testStorage{
+		warehouses:   memory.NewWarehouseRepository(store),
+		products:     memory.NewProductRepository(store),
+		inventory:    memory.NewInventoryRepository(store),
+		orders:       memory.NewOrderRepository(store),
+		reservations: memory.NewReservationRepository(store),
+		movements:    memory.NewStockMovementRepository(store),
+		transfers:    memory.NewTransferRepository(store),
+		audit:        memory.NewAuditRepository(store),
+	}
+}
+
+// postgresStorage подключается к базе из TEST_DATABASE_URL, применяет миграции
+// и создает хранилища PostgreSQL
+func postgresStorage(t *testing.T) testStorage {
+	t.Helper()
+
+	pool := testdb.Pool(t)
+	return testStorage{
+		warehouses:   repository.NewWarehouseRepository(pool),
+		products:     repository.NewProductRepository(pool),
+		inventory:    repository.NewInventoryRepository(pool),
+		orders:       repository.NewOrderRepository(pool),
+		reservations: repository.NewReservationRepository(pool),
+		movements:    repository.NewStockMovementRepository(pool),
+		transfers:    repository.NewTransferRepository(pool),
+		audit:        repository.NewAuditRepository(pool),
+	}
+}
+
+// forEachBackend запускает тест на хранилище в памяти и, если задан TEST_DATABASE_URL,
+// на PostgreSQL, чтобы поведение бэкендов не расходилось
+func forEachBackend(t *testing.T, test func(t *testing.T, s testServices)) {
+	t.Run("memory", func(t *testing.T) {
+		test(t, newTestServices(memoryStorage()))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		test(t, newTestServices(postgresStorage(t)))
+	})
+}
+
+// seedWarehouse создает склад
+func seedWarehouse(t *testing.T, s testServices) domain.Warehouse {
+	t.Helper()
+
+	warehouse, err := s.storage.warehouses.Create(context.Background(), domain.Warehouse{Address: "test " + uuid.NewString()})
+	if err != nil {
+		t.Fatalf("создание склада: %v", err)
+	}
+	return warehouse
+}
+
+// seedProduct создает товар
+func seedProduct(t *testing.T, s testServices) domain.Product {
+	t.Helper()
+
+	product, err := s.storage.products.Create(context.Background(), domain.Product{
+		Name:            "test",
+		Characteristics: []byte(`{}`),
+		Weight:          1,
+		Barcode:         uuid.NewString(),
+	})
+	if err != nil {
+		t.Fatalf("создание товара: %v", err)
+	}
+	return product
+}
+
+// seedInventory создает товар на складе с остатком quantity и ценой price
+func seedInventory(t *testing.T, s testServices, warehouse domain.Warehouse, product domain.Product, quantity int, price float64) {
+	t.Helper()
+
+	_, err := s.inventory.Create(context.Background(), domain.Inventory{
+		WarehouseID: warehouse.ID,
+		ProductID:   product.ID,
+		Quantity:    quantity,
+		Price:       price,
+	}, domain.MovementInfo{})
+	if err != nil {
+		t.Fatalf("создание остатка: %v", err)
+	}
+}
+
+// assertStock проверяет остаток и резерв товара на складе
+func assertStock(t *testing.T, s testServices, warehouseID, productID uuid.UUID, quantity, reserved int) {
+	t.Helper()
+
+	inventory, err := s.storage.inventory.GetByWarehouseAndProduct(context.Background(), warehouseID, productID)
+	if err != nil {
+		t.Fatalf("получение остатка: %v", err)
+	}
+	if inventory.Quantity != quantity || inventory.Reserved != reserved {
+		t.Errorf("остаток %d, резерв %d; ожидалось %d и %d", inventory.Quantity, inventory.Reserved, quantity, reserved)
+	}
+	if inventory.Available != quantity-reserved {
+		t.Errorf("доступно %d, ожидалось %d", inventory.Available, quantity-reserved)
+	}
+}
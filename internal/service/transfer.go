@@ -0,0 +1,48 @@
+package service
+
+import (
+	"context"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/google/uuid"
+)
+
+// TransferService управляет перемещениями товаров между складами
+type TransferService struct {
+	transfers TransferRepository
+}
+
+// NewTransferService создает новый сервис перемещений
+func NewTransferService(transfers TransferRepository) *TransferService {
+	return &TransferService{transfers: transfers}
+}
+
+// Create создает перемещение; при request.Immediate оно сразу выполняется целиком
+func (s *TransferService) Create(ctx context.Context, request domain.TransferRequest, info domain.MovementInfo) (domain.Transfer, error) {
+	return s.transfers.Create(ctx, request, request.Immediate, info)
+}
+
+// Get возвращает перемещение по ID
+func (s *TransferService) Get(ctx context.Context, id uuid.UUID) (domain.Transfer, error) {
+	return s.transfers.GetByID(ctx, id)
+}
+
+// Dispatch отгружает товары со склада-источника
+func (s *TransferService) Dispatch(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error) {
+	return s.transfers.Dispatch(ctx, id, info)
+}
+
+// Receive зачисляет товары на склад назначения
+func (s *TransferService) Receive(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error) {
+	return s.transfers.Receive(ctx, id, info)
+}
+
+// Cancel отменяет перемещение и возвращает отгруженные товары на склад-источник
+func (s *TransferService) Cancel(ctx context.Context, id uuid.UUID, info domain.MovementInfo) (domain.Transfer, error) {
+	return s.transfers.Cancel(ctx, id, info)
+}
+
+// ListByWarehouse возвращает перемещения склада с указанными статусами
+func (s *TransferService) ListByWarehouse(ctx context.Context, warehouseID uuid.UUID, statuses []domain.TransferStatus) ([]domain.Transfer, error) {
+	return s.transfers.GetByWarehouse(ctx, warehouseID, statuses)
+}
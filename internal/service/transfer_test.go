@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+	"github.com/danya1733/practiceGO/internal/repository"
+)
+
+func TestTransfer(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s testServices) {
+		ctx := context.Background()
+		source := seedWarehouse(t, s)
+		destination := seedWarehouse(t, s)
+		product := seedProduct(t, s)
+		seedInventory(t, s, source, product, 10, 100)
+
+		transfer, err := s.transfers.Create(ctx, domain.TransferRequest{
+			SourceWarehouseID:      source.ID,
+			DestinationWarehouseID: destination.ID,
+			Items:                  []domain.ProductPurchase{{ProductID: product.ID, Quantity: 4}},
+		}, domain.MovementInfo{})
+		if err != nil {
+			t.Fatalf("создание перемещения: %v", err)
+		}
+		if transfer.Status != domain.TransferPending {
+			t.Errorf("статус %s, ожидался %s", transfer.Status, domain.TransferPending)
+		}
+		assertStock(t, s, source.ID, product.ID, 10, 0)
+
+		transfer, err = s.transfers.Dispatch(ctx, transfer.ID, domain.MovementInfo{})
+		if err != nil {
+			t.Fatalf("отгрузка: %v", err)
+		}
+		if transfer.Status != domain.TransferInTransit {
+			t.Errorf("статус %s, ожидался %s", transfer.Status, domain.TransferInTransit)
+		}
+		assertStock(t, s, source.ID, product.ID, 6, 0)
+
+		// Отгруженное перемещение нельзя отгрузить повторно
+		if _, err := s.transfers.Dispatch(ctx, transfer.ID, domain.MovementInfo{}); !errors.Is(err, repository.ErrTransferState) {
+			t.Errorf("ожидалась ErrTransferState, получено %v", err)
+		}
+
+		transfer, err = s.transfers.Receive(ctx, transfer.ID, domain.MovementInfo{})
+		if err != nil {
+			t.Fatalf("приемка: %v", err)
+		}
+		if transfer.Status != domain.TransferReceived {
+			t.Errorf("статус %s, ожидался %s", transfer.Status, domain.TransferReceived)
+		}
+		assertStock(t, s, source.ID, product.ID, 6, 0)
+		assertStock(t, s, destination.ID, product.ID, 4, 0)
+	})
+}
+
+func TestTransferCancelReturnsStock(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s testServices) {
+		ctx := context.Background()
+		source := seedWarehouse(t, s)
+		destination := seedWarehouse(t, s)
+		product := seedProduct(t, s)
+		seedInventory(t, s, source, product, 10, 100)
+
+		transfer, err := s.transfers.Create(ctx, domain.TransferRequest{
+			SourceWarehouseID:      source.ID,
+			DestinationWarehouseID: destination.ID,
+			Items:                  []domain.ProductPurchase{{ProductID: product.ID, Quantity: 7}},
+		}, domain.MovementInfo{})
+		if err != nil {
+			t.Fatalf("создание перемещения: %v", err)
+		}
+		if _, err := s.transfers.Dispatch(ctx, transfer.ID, domain.MovementInfo{}); err != nil {
+			t.Fatalf("отгрузка: %v", err)
+		}
+		assertStock(t, s, source.ID, product.ID, 3, 0)
+
+		transfer, err = s.transfers.Cancel(ctx, transfer.ID, domain.MovementInfo{})
+		if err != nil {
+			t.Fatalf("отмена: %v", err)
+		}
+		if transfer.Status != domain.TransferCancelled {
+			t.Errorf("статус %s, ожидался %s", transfer.Status, domain.TransferCancelled)
+		}
+		assertStock(t, s, source.ID, product.ID, 10, 0)
+	})
+}
+
+func TestTransferRespectsReservations(t *testing.T) {
+	forEachBackend(t, func(t *testing.T, s testServices) {
+		ctx := context.Background()
+		source := seedWarehouse(t, s)
+		destination := seedWarehouse(t, s)
+		product := seedProduct(t, s)
+		seedInventory(t, s, source, product, 5, 100)
+
+		_, err := s.sales.Calculate(ctx, domain.PurchaseRequest{
+			WarehouseID: source.ID,
+			Products:    []domain.ProductPurchase{{ProductID: product.ID, Quantity: 3}},
+			Reserve:     true,
+		})
+		if err != nil {
+			t.Fatalf("расчет с резервом: %v", err)
+		}
+
+		_, err = s.transfers.Create(ctx, domain.TransferRequest{
+			SourceWarehouseID:      source.ID,
+			DestinationWarehouseID: destination.ID,
+			Items:                  []domain.ProductPurchase{{ProductID: product.ID, Quantity: 3}},
+			Immediate:              true,
+		}, domain.MovementInfo{})
+		var stockErr *repository.InsufficientStockError
+		if !errors.As(err, &stockErr) {
+			t.Fatalf("ожидалась InsufficientStockError, получено %v", err)
+		}
+		if stockErr.Available != 2 || stockErr.Requested != 3 {
+			t.Errorf("доступно %d, запрошено %d; ожидалось 2 и 3", stockErr.Available, stockErr.Requested)
+		}
+		assertStock(t, s, source.ID, product.ID, 5, 3)
+	})
+}
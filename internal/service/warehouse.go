@@ -0,0 +1,27 @@
+package service
+
+import (
+	"context"
+
+	"github.com/danya1733/practiceGO/internal/domain"
+)
+
+// WarehouseService управляет складами
+type WarehouseService struct {
+	warehouses WarehouseRepository
+}
+
+// NewWarehouseService создает новый сервис складов
+func NewWarehouseService(warehouses WarehouseRepository) *WarehouseService {
+	return &WarehouseService{warehouses: warehouses}
+}
+
+// List возвращает все склады
+func (s *WarehouseService) List(ctx context.Context) ([]domain.Warehouse, error) {
+	return s.warehouses.GetAll(ctx)
+}
+
+// Create создает склад
+func (s *WarehouseService) Create(ctx context.Context, warehouse domain.Warehouse) (domain.Warehouse, error) {
+	return s.warehouses.Create(ctx, warehouse)
+}
@@ -0,0 +1,93 @@
+// Package testdb подключает тесты к PostgreSQL из переменной окружения TEST_DATABASE_URL.
+// Без TEST_DATABASE_URL тесты с PostgreSQL пропускаются
+package testdb
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/danya1733/practiceGO/internal/migration"
+	"github.com/danya1733/practiceGO/migrations"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// Connect подключается к базе из TEST_DATABASE_URL без применения миграций.
+// Пул закрывается по завершении теста
+func Connect(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+
+	cfg := config(t)
+	pool, err := pgxpool.NewWithConfig(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("подключение к базе: %v", err)
+	}
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+// Schema подключается к базе из TEST_DATABASE_URL с пустой схемой в search_path.
+// Таблицы без указания схемы создаются в ней, поэтому тест не видит таблиц других тестов.
+// Схема удаляется по завершении теста
+func Schema(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+
+	ctx := context.Background()
+	admin := Connect(t)
+	schema := "test_" + strings.ReplaceAll(uuid.NewString(), "-", "")
+	if _, err := admin.Exec(ctx, "CREATE SCHEMA "+schema); err != nil {
+		t.Fatalf("создание схемы %s: %v", schema, err)
+	}
+
+	cfg := config(t)
+	// public остается в search_path ради расширений, установленных в нее
+	cfg.ConnConfig.RuntimeParams["search_path"] = schema + ", public"
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("подключение к базе: %v", err)
+	}
+	// Cleanup выполняются в обратном порядке: пул схемы закрывается до удаления схемы
+	t.Cleanup(func() {
+		if _, err := admin.Exec(context.Background(), "DROP SCHEMA "+schema+" CASCADE"); err != nil {
+			t.Errorf("удаление схемы %s: %v", schema, err)
+		}
+	})
+	t.Cleanup(pool.Close)
+
+	return pool
+}
+
+// config разбирает TEST_DATABASE_URL или пропускает тест, если переменная не задана
+func config(t testing.TB) *pgxpool.Config {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL не задан, тест с PostgreSQL пропущен")
+	}
+
+	cfg, err := pgxpool.ParseConfig(url)
+	if err != nil {
+		t.Fatalf("разбор TEST_DATABASE_URL: %v", err)
+	}
+	return cfg
+}
+
+// Pool подключается к базе из TEST_DATABASE_URL и применяет миграции
+func Pool(t testing.TB) *pgxpool.Pool {
+	t.Helper()
+
+	pool := Connect(t)
+	migrator, err := migration.New(pool, migrations.FS)
+	if err != nil {
+		t.Fatalf("загрузка миграций: %v", err)
+	}
+	if _, err := migrator.Up(context.Background()); err != nil {
+		t.Fatalf("применение миграций: %v", err)
+	}
+
+	return pool
+}
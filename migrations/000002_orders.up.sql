@@ -0,0 +1,24 @@
+-- Таблица заказов (покупок)
+CREATE TABLE IF NOT EXISTS orders (
+    id UUID PRIMARY KEY,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    total_sum FLOAT NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Таблица позиций заказа
+CREATE TABLE IF NOT EXISTS order_items (
+    id UUID PRIMARY KEY,
+    order_id UUID NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id),
+    quantity INTEGER NOT NULL,
+    price FLOAT NOT NULL,
+    discount FLOAT NOT NULL DEFAULT 0,
+    price_with_discount FLOAT NOT NULL,
+    total_price FLOAT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_orders_warehouse ON orders(warehouse_id);
+CREATE INDEX IF NOT EXISTS idx_orders_created_at ON orders(created_at);
+CREATE INDEX IF NOT EXISTS idx_order_items_order ON order_items(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_items_product ON order_items(product_id);
@@ -0,0 +1 @@
+ALTER TABLE inventory DROP CONSTRAINT IF EXISTS inventory_quantity_non_negative;
@@ -0,0 +1,3 @@
+-- Запрет отрицательных остатков на уровне базы данных
+ALTER TABLE inventory
+    ADD CONSTRAINT inventory_quantity_non_negative CHECK (quantity >= 0);
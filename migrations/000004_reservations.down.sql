@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS reservation_items;
+DROP TABLE IF EXISTS reservations;
+ALTER TABLE inventory DROP CONSTRAINT IF EXISTS inventory_reserved_range;
+ALTER TABLE inventory DROP COLUMN IF EXISTS reserved;
@@ -0,0 +1,24 @@
+-- Зарезервированное количество товара; доступно для продажи quantity - reserved
+ALTER TABLE inventory ADD COLUMN IF NOT EXISTS reserved INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE inventory
+    ADD CONSTRAINT inventory_reserved_range CHECK (reserved >= 0 AND reserved <= quantity);
+
+-- Таблица резервов товаров между расчетом стоимости и покупкой
+CREATE TABLE IF NOT EXISTS reservations (
+    id UUID PRIMARY KEY,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    status TEXT NOT NULL DEFAULT 'active'
+        CHECK (status IN ('active', 'consumed', 'released', 'expired')),
+    expires_at TIMESTAMPTZ NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Таблица позиций резерва
+CREATE TABLE IF NOT EXISTS reservation_items (
+    reservation_id UUID NOT NULL REFERENCES reservations(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (reservation_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_reservations_active_expires ON reservations(expires_at) WHERE status = 'active';
@@ -0,0 +1,36 @@
+-- Журнал движения товаров: каждое изменение остатка записывается отдельной строкой
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id UUID PRIMARY KEY,
+    warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    product_id UUID NOT NULL REFERENCES products(id),
+    type TEXT NOT NULL
+        CHECK (type IN ('receipt', 'sale', 'adjustment', 'transfer', 'return', 'write_off')),
+    quantity_delta INTEGER NOT NULL,
+    quantity_after INTEGER NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    actor TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    reference_id UUID,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_inventory
+    ON stock_movements(warehouse_id, product_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_reference ON stock_movements(reference_id);
+
+-- Журнал только дополняется: изменение и удаление записей запрещены
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movements_no_update_delete
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+-- Начальные остатки, чтобы сумма журнала совпадала с текущим количеством
+INSERT INTO stock_movements (id, warehouse_id, product_id, type, quantity_delta, quantity_after, reason, actor)
+SELECT uuid_generate_v4(), warehouse_id, product_id, 'adjustment', quantity, quantity, 'opening_balance', 'system'
+FROM inventory
+WHERE quantity <> 0;
@@ -0,0 +1,24 @@
+-- Таблица перемещений товаров между складами
+CREATE TABLE IF NOT EXISTS transfers (
+    id UUID PRIMARY KEY,
+    source_warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    destination_warehouse_id UUID NOT NULL REFERENCES warehouses(id),
+    status TEXT NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'in_transit', 'received', 'cancelled')),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    dispatched_at TIMESTAMPTZ,
+    received_at TIMESTAMPTZ,
+    cancelled_at TIMESTAMPTZ,
+    CHECK (source_warehouse_id <> destination_warehouse_id)
+);
+
+-- Таблица позиций перемещения
+CREATE TABLE IF NOT EXISTS transfer_items (
+    transfer_id UUID NOT NULL REFERENCES transfers(id) ON DELETE CASCADE,
+    product_id UUID NOT NULL REFERENCES products(id),
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (transfer_id, product_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_transfers_source ON transfers(source_warehouse_id, status);
+CREATE INDEX IF NOT EXISTS idx_transfers_destination ON transfers(destination_warehouse_id, status);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_order_items_order_product;
+DROP INDEX IF EXISTS idx_orders_warehouse_created_at;
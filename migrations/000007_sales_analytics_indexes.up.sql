@@ -0,0 +1,3 @@
+-- Индексы для выборки продаж склада за период
+CREATE INDEX IF NOT EXISTS idx_orders_warehouse_created_at ON orders(warehouse_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_order_items_order_product ON order_items(order_id, product_id);
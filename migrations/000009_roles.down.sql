@@ -0,0 +1,3 @@
+ALTER TABLE users
+    DROP COLUMN IF EXISTS warehouses,
+    DROP COLUMN IF EXISTS role;
@@ -0,0 +1,4 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT ''
+        CHECK (role IN ('', 'admin', 'merchandiser', 'clerk')),
+    ADD COLUMN IF NOT EXISTS warehouses UUID[] NOT NULL DEFAULT '{}';
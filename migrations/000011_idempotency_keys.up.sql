@@ -0,0 +1,14 @@
+-- Ответы на запросы с заголовком Idempotency-Key.
+-- Запись без статуса означает, что запрос с этим ключом еще обрабатывается
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    subject TEXT NOT NULL,
+    key TEXT NOT NULL,
+    request_hash TEXT NOT NULL,
+    status INTEGER,
+    response BYTEA,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ NOT NULL,
+    PRIMARY KEY (subject, key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
@@ -0,0 +1,5 @@
+DROP TRIGGER IF EXISTS inventory_bump_version ON inventory;
+DROP TRIGGER IF EXISTS products_bump_version ON products;
+DROP FUNCTION IF EXISTS bump_row_version();
+ALTER TABLE inventory DROP COLUMN IF EXISTS version;
+ALTER TABLE products DROP COLUMN IF EXISTS version;
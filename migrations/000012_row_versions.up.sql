@@ -0,0 +1,20 @@
+-- Версии записей для оптимистичной блокировки (заголовки ETag и If-Match)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE inventory ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+
+-- Версия увеличивается при любом изменении записи, в том числе при продажах,
+-- резервах и перемещениях, которые обновляют остатки без проверки версии
+CREATE OR REPLACE FUNCTION bump_row_version() RETURNS trigger AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER products_bump_version
+    BEFORE UPDATE ON products
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION bump_row_version();
+
+CREATE TRIGGER inventory_bump_version
+    BEFORE UPDATE ON inventory
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION bump_row_version();
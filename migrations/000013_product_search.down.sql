@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_products_weight_id;
+DROP INDEX IF EXISTS idx_products_name_id;
+DROP INDEX IF EXISTS idx_products_search;
+DROP FUNCTION IF EXISTS product_search_vector(TEXT, TEXT);
@@ -0,0 +1,15 @@
+-- Полнотекстовый поиск по названию и описанию товара на русском и английском языках.
+-- Вектор вычисляется функцией, чтобы запрос и индекс использовали одно выражение;
+-- сгенерированная колонка не подходит: триггер версии сравнивает строки целиком
+CREATE OR REPLACE FUNCTION product_search_vector(name TEXT, description TEXT) RETURNS tsvector AS $$
+    SELECT setweight(to_tsvector('russian'::regconfig, coalesce(name, '')), 'A')
+        || setweight(to_tsvector('english'::regconfig, coalesce(name, '')), 'A')
+        || setweight(to_tsvector('russian'::regconfig, coalesce(description, '')), 'B')
+        || setweight(to_tsvector('english'::regconfig, coalesce(description, '')), 'B')
+$$ LANGUAGE sql IMMUTABLE PARALLEL SAFE;
+
+CREATE INDEX IF NOT EXISTS idx_products_search ON products USING GIN (product_search_vector(name, description));
+
+-- Индексы для сортировки и keyset-пагинации каталога; штрихкод уже проиндексирован ограничением UNIQUE
+CREATE INDEX IF NOT EXISTS idx_products_name_id ON products(name, id);
+CREATE INDEX IF NOT EXISTS idx_products_weight_id ON products(weight, id);
@@ -0,0 +1,3 @@
+-- Индекс для фильтров по характеристикам товаров: равенство attr.<путь>=<значение>
+-- проверяется вхождением JSON документа (characteristics @> ...), которое поддерживает jsonb_path_ops
+CREATE INDEX IF NOT EXISTS idx_products_characteristics ON products USING GIN (characteristics jsonb_path_ops);
@@ -0,0 +1,2 @@
+-- Заголовки сохраненного ответа (Content-Type, ETag, Location), которые возвращаются при повторе запроса
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS response_headers JSONB;
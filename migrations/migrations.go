@@ -0,0 +1,9 @@
+// Package migrations встраивает SQL файлы миграций в бинарный файл приложения
+package migrations
+
+import "embed"
+
+// FS содержит файлы миграций в формате golang-migrate: <версия>_<название>.<up|down>.sql
+//
+//go:embed *.sql
+var FS embed.FS
@@ -4,13 +4,16 @@ import (
 	"context"
 	"fmt"
 
-	"github.com/google/uuid"
+	"github.com/danya1733/practiceGO/pkg/requestctx"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
 const (
 	requestIDKey = "request_id"
+	traceIDKey   = "trace_id"
+	spanIDKey    = "span_id"
 )
 
 // Logger представляет логгер приложения
@@ -42,14 +45,22 @@ func NewLogger(level string) (*Logger, error) {
 	return &Logger{zapLogger}, nil
 }
 
-// WithRequestID добавляет request_id в логгер из контекста
+// WithRequestID добавляет request_id в логгер из контекста.
+// Если в контексте есть спан трассировки, добавляются также trace_id и span_id.
+// Вне HTTP запроса (например, в фоновых задачах) request_id не добавляется
 func (l *Logger) WithRequestID(ctx context.Context) *Logger {
-	requestID, ok := ctx.Value(requestIDKey).(string)
-	if !ok || requestID == "" {
-		requestID = uuid.New().String()
+	var fields []zap.Field
+	if requestID := requestctx.RequestID(ctx); requestID != "" {
+		fields = append(fields, zap.String(requestIDKey, requestID))
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+		fields = append(fields,
+			zap.String(traceIDKey, spanContext.TraceID().String()),
+			zap.String(spanIDKey, spanContext.SpanID().String()),
+		)
 	}
 
-	return &Logger{l.With(zap.String(requestIDKey, requestID))}
+	return &Logger{l.With(fields...)}
 }
 
 // Error создает поле ошибки для логгера
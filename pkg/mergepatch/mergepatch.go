@@ -0,0 +1,64 @@
+// Package mergepatch применяет JSON Merge Patch (RFC 7396) к JSON документам.
+// Поля патча заменяют поля документа, вложенные объекты объединяются рекурсивно,
+// а значение null удаляет поле
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// Apply применяет patch к документу doc и возвращает измененный документ.
+// Пустой doc считается отсутствующим документом
+func Apply(doc, patch []byte) ([]byte, error) {
+	var target interface{}
+	if len(bytes.TrimSpace(doc)) > 0 {
+		if err := unmarshal(doc, &target); err != nil {
+			return nil, fmt.Errorf("некорректный документ: %w", err)
+		}
+	}
+
+	var p interface{}
+	if err := unmarshal(patch, &p); err != nil {
+		return nil, fmt.Errorf("некорректный патч: %w", err)
+	}
+
+	return json.Marshal(merge(target, p))
+}
+
+// merge реализует алгоритм MergePatch из раздела 2 RFC 7396
+func merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = make(map[string]interface{}, len(patchObject))
+	}
+
+	for name, value := range patchObject {
+		if value == nil {
+			delete(targetObject, name)
+			continue
+		}
+		targetObject[name] = merge(targetObject[name], value)
+	}
+
+	return targetObject
+}
+
+// unmarshal разбирает JSON, сохраняя числа без потери точности
+func unmarshal(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("лишние данные после JSON значения")
+	}
+	return nil
+}
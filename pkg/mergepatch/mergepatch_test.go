@@ -0,0 +1,81 @@
+package mergepatch
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestApplyRFC7396 проверяет примеры из приложения A RFC 7396
+func TestApplyRFC7396(t *testing.T) {
+	tests := []struct {
+		name   string
+		doc    string
+		patch  string
+		result string
+	}{
+		{"замена значения", `{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"добавление поля", `{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{"null удаляет поле", `{"a":"b"}`, `{"a":null}`, `{}`},
+		{"null удаляет только указанное поле", `{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{"массив заменяется значением", `{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{"значение заменяется массивом", `{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{"вложенное объединение", `{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{"массив заменяется целиком", `{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{"массив заменяет массив", `["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{"массив заменяет объект", `{"a":"b"}`, `["c"]`, `["c"]`},
+		{"null заменяет документ", `{"a":"foo"}`, `null`, `null`},
+		{"строка заменяет документ", `{"a":"foo"}`, `"bar"`, `"bar"`},
+		{"null в документе сохраняется", `{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{"объект заменяет массив", `[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{"вложенный null не создает поле", `{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+		{"пустой документ", ``, `{"a":1}`, `{"a":1}`},
+		{"большие числа без потери точности", `{"a":1}`, `{"b":12345678901234567890}`, `{"a":1,"b":12345678901234567890}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+			if err != nil {
+				t.Fatalf("Apply: %v", err)
+			}
+			if !jsonEqual(t, got, []byte(tt.result)) {
+				t.Errorf("Apply(%s, %s) = %s, ожидалось %s", tt.doc, tt.patch, got, tt.result)
+			}
+		})
+	}
+}
+
+func TestApplyInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		doc   string
+		patch string
+	}{
+		{"некорректный патч", `{}`, `{"a":`},
+		{"пустой патч", `{}`, ``},
+		{"лишние данные после патча", `{}`, `{"a":1} {"b":2}`},
+		{"некорректный документ", `{"a"`, `{}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := Apply([]byte(tt.doc), []byte(tt.patch)); err == nil {
+				t.Errorf("ожидалась ошибка, получено %s", got)
+			}
+		})
+	}
+}
+
+// jsonEqual сравнивает JSON документы без учета порядка полей и форматирования
+func jsonEqual(t *testing.T, a, b []byte) bool {
+	t.Helper()
+
+	var va, vb interface{}
+	if err := unmarshal(a, &va); err != nil {
+		t.Fatalf("разбор %s: %v", a, err)
+	}
+	if err := unmarshal(b, &vb); err != nil {
+		t.Fatalf("разбор %s: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
@@ -0,0 +1,75 @@
+// Package requestctx хранит идентификатор HTTP запроса и имя клиента в контексте.
+// Пакет используется обработчиками, логгером и слоем доступа к данным,
+// чтобы все они видели один и тот же запрос
+package requestctx
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+)
+
+// Header заголовок HTTP с идентификатором запроса
+const Header = "X-Request-ID"
+
+// maxRequestIDLength ограничивает длину идентификатора, переданного клиентом
+const maxRequestIDLength = 128
+
+// requestIDKey ключ контекста с идентификатором запроса
+type requestIDKey struct{}
+
+// WithRequestID сохраняет идентификатор запроса в контексте
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID возвращает идентификатор запроса из контекста или пустую строку,
+// если контекст не относится к HTTP запросу
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// actorKey ключ контекста с именем клиента, выполняющего изменение
+type actorKey struct{}
+
+// systemActor имя клиента для изменений вне HTTP запросов
+const systemActor = "system"
+
+// WithActor сохраняет в контексте имя клиента для журнала аудита
+func WithActor(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, actorKey{}, subject)
+}
+
+// Actor возвращает имя клиента из контекста. Изменения без клиента
+// (например, снятие истекших резервов) выполняются от имени system
+func Actor(ctx context.Context) string {
+	subject, ok := ctx.Value(actorKey{}).(string)
+	if !ok {
+		return systemActor
+	}
+	return subject
+}
+
+// FromHeader возвращает идентификатор, переданный клиентом, если он пригоден
+// для логов и базы данных, иначе создает новый. Пригодный идентификатор не длиннее
+// 128 символов и состоит из печатных символов ASCII
+func FromHeader(value string) string {
+	if valid(value) {
+		return value
+	}
+	return uuid.New().String()
+}
+
+// valid проверяет идентификатор, переданный клиентом
+func valid(value string) bool {
+	if value == "" || len(value) > maxRequestIDLength {
+		return false
+	}
+	for i := 0; i < len(value); i++ {
+		if value[i] < 0x21 || value[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}